@@ -1,15 +1,23 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/bventy/backend/internal/background"
 	"github.com/bventy/backend/internal/config"
 	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/db/migrations"
+	"github.com/bventy/backend/internal/migrate"
 	"github.com/bventy/backend/internal/routes"
+	"github.com/bventy/backend/internal/services"
 )
 
 func main() {
@@ -20,6 +28,19 @@ func main() {
 	// Step 1: Connect DB
 	db.Connect(cfg)
 
+	// Step 1.5: Apply pending migrations (opt-in via MIGRATE_ON_STARTUP)
+	if cfg.MigrateOnStartup {
+		migrator, err := migrate.New(db.Pool, migrations.FS)
+		if err != nil {
+			log.Fatalf("Failed to load migrations: %v", err)
+		}
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			log.Fatalf("Failed to apply migrations: %v", err)
+		}
+		log.Printf("Applied %d migration(s) on startup", len(applied))
+	}
+
 	// Step 2: Start Gin server
 	r := gin.Default()
 
@@ -32,25 +53,63 @@ func main() {
 			"http://localhost:3000",
 		},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "If-None-Match", "If-Modified-Since", "If-Match", "Idempotency-Key"},
+		ExposeHeaders:    []string{"Deprecation", "Sunset", "Link", "ETag", "Last-Modified", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
 	// Step 3: Register routes
-	routes.RegisterRoutes(r)
+	media, err := services.NewMediaStore(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize media store: %v", err)
+	}
+	if local, ok := media.(*services.LocalMediaStore); ok {
+		r.Static("/media", cfg.LocalMediaDir)
+		r.GET("/private-media/*key", gin.WrapH(http.StripPrefix("/private-media", local.PrivateHandler())))
+	}
+	routes.RegisterRoutes(r, cfg, media)
 
 	// DEBUG: Print all registered routes
 	for _, route := range r.Routes() {
 		log.Printf("Route: %s %s", route.Method, route.Path)
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Step 3.5: Background jobs and outbox (set EMBEDDED_WORKER=false when cmd/worker runs separately)
+	workerDone := make(chan struct{})
+	if cfg.EmbeddedWorker {
+		go func() {
+			defer close(workerDone)
+			background.Run(ctx, cfg, db.Pool, media)
+		}()
+	} else {
+		close(workerDone)
+	}
+
 	// Step 4: Run server
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8082"
 	}
 
-	log.Printf("Starting server on port %s...", port)
-	r.Run("0.0.0.0:" + port)
+	srv := &http.Server{Addr: "0.0.0.0:" + port, Handler: r}
+	go func() {
+		log.Printf("Starting server on port %s...", port)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	// Step 5: On SIGINT/SIGTERM, drain requests, then let running jobs finish
+	<-ctx.Done()
+	log.Println("Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠️  Server shutdown: %v", err)
+	}
+	<-workerDone
 }
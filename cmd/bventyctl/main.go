@@ -0,0 +1,105 @@
+// Command bventyctl is the operator CLI: user and role management, vendor
+// moderation and schema checks, against the database in the usual config.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/bventy/backend/internal/config"
+	"github.com/bventy/backend/internal/db"
+)
+
+const usage = `Usage: bventyctl [-o table|json] <command> [flags] [args]
+
+Commands:
+  users list [-role ROLE] [-limit N]
+                          List users, newest first
+  users create -email EMAIL -name NAME [-role ROLE] [-password PASSWORD]
+                          Create a user; -role super_admin for the first admin
+  users set-role USER ROLE
+                          Change a user's role (user, staff, admin, super_admin)
+  users reset-password [-password PASSWORD] USER
+                          Set a new password
+  users revoke-sessions USER
+                          Log the user out everywhere
+  vendors pending         List vendors waiting for approval, oldest first
+  vendors approve VENDOR  Verify a vendor and publish vendor.verified
+  vendors reject VENDOR   Reject a vendor
+  schema verify           Compare the live schema with the migrations;
+                          exits 1 on drift
+  schema columns [TABLE]  List a table's columns (default vendor_profiles)
+
+USER is an email or id, VENDOR an id or slug. When -password is left out a
+random one is generated and printed once. set-role, reset-password and
+revoke-sessions invalidate the user's existing tokens.
+`
+
+// command runs one subcommand and returns what to print.
+type command func(ctx context.Context, args []string) (result, error)
+
+var commands = map[string]command{
+	"users list":            usersList,
+	"users create":          usersCreate,
+	"users set-role":        usersSetRole,
+	"users reset-password":  usersResetPassword,
+	"users revoke-sessions": usersRevokeSessions,
+	"vendors pending":       vendorsPending,
+	"vendors approve":       vendorsApprove,
+	"vendors reject":        vendorsReject,
+	"schema verify":         schemaVerify,
+	"schema columns":        schemaColumns,
+}
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	format := flag.String("o", "table", "output format: table or json")
+	flag.Parse()
+	if *format != "table" && *format != "json" {
+		log.Fatalf("Unknown output format %q", *format)
+	}
+
+	args := flag.Args()
+	if len(args) < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	name := args[0] + " " + args[1]
+	run, ok := commands[name]
+	if !ok {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := config.LoadConfig()
+	db.Connect(cfg)
+	defer db.Pool.Close()
+
+	res, err := run(context.Background(), args[2:])
+	if err != nil {
+		db.Pool.Close()
+		log.Fatalf("%s: %v", name, err)
+	}
+	if err := res.print(os.Stdout, *format); err != nil {
+		log.Fatalf("Failed to print output: %v", err)
+	}
+	if res.failed {
+		db.Pool.Close()
+		os.Exit(1)
+	}
+}
+
+// parse parses a subcommand's flags and checks it got want positional
+// arguments, returning them.
+func parse(fs *flag.FlagSet, args []string, want ...string) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != len(want) {
+		return nil, fmt.Errorf("want %d argument(s): %v", len(want), want)
+	}
+	return fs.Args(), nil
+}
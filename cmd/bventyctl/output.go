@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// result is a command's output: columns and rows for -o table, value for
+// -o json. failed makes the command exit 1 after printing.
+type result struct {
+	columns []string
+	rows    [][]string
+	value   any
+	failed  bool
+}
+
+func (r result) print(w io.Writer, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r.value)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(r.columns, "\t")))
+	for _, row := range r.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// text renders optional values for a table cell.
+func text(s *string) string {
+	if s == nil {
+		return "-"
+	}
+	return *s
+}
+
+func timestamp(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04")
+}
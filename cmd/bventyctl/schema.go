@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/db/migrations"
+	"github.com/bventy/backend/internal/migrate"
+	"github.com/jackc/pgx/v5"
+)
+
+// schemaVerify is migrate verify with bventyctl's output formats.
+func schemaVerify(ctx context.Context, args []string) (result, error) {
+	fs := flag.NewFlagSet("schema verify", flag.ExitOnError)
+	if _, err := parse(fs, args); err != nil {
+		return result{}, err
+	}
+
+	migrator, err := migrate.New(db.Pool, migrations.FS)
+	if err != nil {
+		return result{}, err
+	}
+	drifts, err := migrator.Verify(ctx)
+	if err != nil {
+		return result{}, err
+	}
+
+	res := result{columns: []string{"kind", "name", "problem", "expected", "actual"}, value: drifts, failed: len(drifts) > 0}
+	if drifts == nil {
+		res.value = []migrate.Drift{}
+	}
+	for _, d := range drifts {
+		res.rows = append(res.rows, []string{d.Kind, d.Name, d.Problem, d.Expected, d.Actual})
+	}
+	return res, nil
+}
+
+type column struct {
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Nullable bool    `json:"nullable"`
+	Default  *string `json:"default"`
+}
+
+// schemaColumns lists the columns of a table in the current schema.
+func schemaColumns(ctx context.Context, args []string) (result, error) {
+	fs := flag.NewFlagSet("schema columns", flag.ExitOnError)
+	if err := fs.Parse(args); err != nil {
+		return result{}, err
+	}
+	table := "vendor_profiles"
+	if fs.NArg() > 0 {
+		table = fs.Arg(0)
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT column_name, data_type, is_nullable = 'YES', column_default
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1
+		ORDER BY ordinal_position
+	`, table)
+	if err != nil {
+		return result{}, err
+	}
+	columns, err := pgx.CollectRows(rows, pgx.RowToStructByPos[column])
+	if err != nil {
+		return result{}, err
+	}
+
+	res := result{columns: []string{"column", "type", "nullable", "default"}, value: columns}
+	for _, c := range columns {
+		nullable := "no"
+		if c.Nullable {
+			nullable = "yes"
+		}
+		res.rows = append(res.rows, []string{c.Name, c.Type, nullable, text(c.Default)})
+	}
+	return res, nil
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/webhooks"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var roles = []string{"user", "staff", "admin", "super_admin"}
+
+func validRole(role string) error {
+	for _, r := range roles {
+		if role == r {
+			return nil
+		}
+	}
+	return fmt.Errorf("role must be one of %s", strings.Join(roles, ", "))
+}
+
+type user struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	FullName  string    `json:"full_name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	// Password is only set when bventyctl generated it.
+	Password string `json:"password,omitempty"`
+}
+
+func userResult(users ...user) result {
+	res := result{columns: []string{"id", "email", "name", "role", "created"}, value: users}
+	generated := false
+	for _, u := range users {
+		row := []string{u.ID, u.Email, u.FullName, u.Role, timestamp(u.CreatedAt)}
+		if u.Password != "" {
+			generated = true
+			row = append(row, u.Password)
+		}
+		res.rows = append(res.rows, row)
+	}
+	if generated {
+		res.columns = append(res.columns, "password")
+	}
+	if len(users) == 1 {
+		res.value = users[0]
+	}
+	return res
+}
+
+const userColumns = `id, email, full_name, role, created_at`
+
+func scanUser(row pgx.Row) (user, error) {
+	var u user
+	err := row.Scan(&u.ID, &u.Email, &u.FullName, &u.Role, &u.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return u, errors.New("no such user")
+	}
+	return u, err
+}
+
+// querier is satisfied by *pgxpool.Pool and pgx.Tx.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// updateUser applies set to the live user identified by ref (an email or
+// id) and bumps session_version, so tokens issued before the change stop
+// working.
+func updateUser(ctx context.Context, q querier, ref, set string, args ...any) (user, error) {
+	return scanUser(q.QueryRow(ctx, `
+		UPDATE users SET `+set+`, session_version = session_version + 1
+		WHERE (id::text = $1 OR email = $1) AND deleted_at IS NULL
+		RETURNING `+userColumns,
+		append([]any{ref}, args...)...))
+}
+
+// generatePassword returns a random 26-character password for when none was
+// given.
+func generatePassword() string {
+	return rand.Text()
+}
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+func usersList(ctx context.Context, args []string) (result, error) {
+	fs := flag.NewFlagSet("users list", flag.ExitOnError)
+	role := fs.String("role", "", "only users with this role")
+	limit := fs.Int("limit", 50, "maximum number of users")
+	if _, err := parse(fs, args); err != nil {
+		return result{}, err
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+userColumns+` FROM users
+		WHERE deleted_at IS NULL AND ($1 = '' OR role = $1)
+		ORDER BY created_at DESC LIMIT $2
+	`, *role, *limit)
+	if err != nil {
+		return result{}, err
+	}
+	users, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (user, error) { return scanUser(row) })
+	if err != nil {
+		return result{}, err
+	}
+	res := userResult(users...)
+	res.value = users
+	return res, nil
+}
+
+func usersCreate(ctx context.Context, args []string) (result, error) {
+	fs := flag.NewFlagSet("users create", flag.ExitOnError)
+	email := fs.String("email", "", "email address (required)")
+	name := fs.String("name", "", "full name (required)")
+	role := fs.String("role", "user", "user, staff, admin or super_admin")
+	password := fs.String("password", "", "password; generated when empty")
+	if _, err := parse(fs, args); err != nil {
+		return result{}, err
+	}
+	if *email == "" || *name == "" {
+		return result{}, errors.New("-email and -name are required")
+	}
+	if err := validRole(*role); err != nil {
+		return result{}, err
+	}
+
+	generated := ""
+	if *password == "" {
+		generated = generatePassword()
+		*password = generated
+	}
+	hash, err := hashPassword(*password)
+	if err != nil {
+		return result{}, err
+	}
+
+	u, err := scanUser(db.Pool.QueryRow(ctx, `
+		INSERT INTO users (email, password_hash, full_name, role)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+userColumns,
+		*email, hash, *name, *role))
+	if err != nil {
+		return result{}, err
+	}
+	u.Password = generated
+	return userResult(u), nil
+}
+
+func usersSetRole(ctx context.Context, args []string) (result, error) {
+	fs := flag.NewFlagSet("users set-role", flag.ExitOnError)
+	pos, err := parse(fs, args, "USER", "ROLE")
+	if err != nil {
+		return result{}, err
+	}
+	if err := validRole(pos[1]); err != nil {
+		return result{}, err
+	}
+
+	// The role is baked into tokens, so revoking them applies it at once.
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return result{}, err
+	}
+	defer tx.Rollback(ctx)
+	u, err := updateUser(ctx, tx, pos[0], "role = $2", pos[1])
+	if err != nil {
+		return result{}, err
+	}
+	if err := webhooks.DeactivateAllVendors(ctx, tx, u.ID, u.Role); err != nil {
+		return result{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return result{}, err
+	}
+	return userResult(u), nil
+}
+
+func usersResetPassword(ctx context.Context, args []string) (result, error) {
+	fs := flag.NewFlagSet("users reset-password", flag.ExitOnError)
+	password := fs.String("password", "", "new password; generated when empty")
+	pos, err := parse(fs, args, "USER")
+	if err != nil {
+		return result{}, err
+	}
+
+	generated := ""
+	if *password == "" {
+		generated = generatePassword()
+		*password = generated
+	}
+	hash, err := hashPassword(*password)
+	if err != nil {
+		return result{}, err
+	}
+
+	u, err := updateUser(ctx, db.Pool, pos[0], "password_hash = $2", hash)
+	if err != nil {
+		return result{}, err
+	}
+	u.Password = generated
+	return userResult(u), nil
+}
+
+func usersRevokeSessions(ctx context.Context, args []string) (result, error) {
+	fs := flag.NewFlagSet("users revoke-sessions", flag.ExitOnError)
+	pos, err := parse(fs, args, "USER")
+	if err != nil {
+		return result{}, err
+	}
+
+	var id, email string
+	var version int
+	err = db.Pool.QueryRow(ctx, `
+		UPDATE users SET session_version = session_version + 1
+		WHERE (id::text = $1 OR email = $1) AND deleted_at IS NULL
+		RETURNING id, email, session_version
+	`, pos[0]).Scan(&id, &email, &version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return result{}, errors.New("no such user")
+	}
+	if err != nil {
+		return result{}, err
+	}
+	return result{
+		columns: []string{"id", "email", "session_version"},
+		rows:    [][]string{{id, email, strconv.Itoa(version)}},
+		value:   map[string]any{"id": id, "email": email, "session_version": version},
+	}, nil
+}
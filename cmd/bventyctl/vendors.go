@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"time"
+
+	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/moderation"
+	"github.com/jackc/pgx/v5"
+)
+
+type pendingVendor struct {
+	ID           string    `json:"id"`
+	Slug         string    `json:"slug"`
+	BusinessName string    `json:"business_name"`
+	Category     string    `json:"category"`
+	City         string    `json:"city"`
+	OwnerEmail   string    `json:"owner_email"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func vendorsPending(ctx context.Context, args []string) (result, error) {
+	fs := flag.NewFlagSet("vendors pending", flag.ExitOnError)
+	if _, err := parse(fs, args); err != nil {
+		return result{}, err
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT vp.id, vp.slug, vp.business_name, vp.category, vp.city, u.email, vp.created_at
+		FROM vendor_profiles vp
+		JOIN users u ON u.id = vp.owner_user_id
+		WHERE vp.status = 'pending' AND vp.deleted_at IS NULL AND u.deleted_at IS NULL
+		ORDER BY vp.created_at, vp.id
+	`)
+	if err != nil {
+		return result{}, err
+	}
+	vendors, err := pgx.CollectRows(rows, pgx.RowToStructByPos[pendingVendor])
+	if err != nil {
+		return result{}, err
+	}
+
+	res := result{columns: []string{"id", "slug", "name", "category", "city", "owner", "created"}, value: vendors}
+	for _, v := range vendors {
+		res.rows = append(res.rows, []string{v.ID, v.Slug, v.BusinessName, v.Category, v.City, v.OwnerEmail, timestamp(v.CreatedAt)})
+	}
+	return res, nil
+}
+
+func vendorsApprove(ctx context.Context, args []string) (result, error) {
+	return moderate(ctx, "vendors approve", args, func(id string) error {
+		// No acting user: vendor.verified goes out with an empty verified_by.
+		return moderation.Approve(ctx, db.Pool, id, "")
+	})
+}
+
+func vendorsReject(ctx context.Context, args []string) (result, error) {
+	return moderate(ctx, "vendors reject", args, func(id string) error {
+		return moderation.Reject(ctx, db.Pool, id)
+	})
+}
+
+// moderate resolves the VENDOR argument and runs op on its id. API instances
+// pick the change up once their vendor cache expires.
+func moderate(ctx context.Context, name string, args []string, op func(id string) error) (result, error) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	pos, err := parse(fs, args, "VENDOR")
+	if err != nil {
+		return result{}, err
+	}
+
+	var id, slug string
+	err = db.Pool.QueryRow(ctx,
+		`SELECT id, slug FROM vendor_profiles WHERE (id::text = $1 OR slug = $1) AND deleted_at IS NULL`, pos[0],
+	).Scan(&id, &slug)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return result{}, moderation.ErrNotFound
+	}
+	if err != nil {
+		return result{}, err
+	}
+	if err := op(id); err != nil {
+		return result{}, err
+	}
+
+	var status string
+	if err := db.Pool.QueryRow(ctx, `SELECT status FROM vendor_profiles WHERE id = $1`, id).Scan(&status); err != nil {
+		return result{}, err
+	}
+	return result{
+		columns: []string{"id", "slug", "status"},
+		rows:    [][]string{{id, slug, status}},
+		value:   map[string]string{"id": id, "slug": slug, "status": status},
+	}, nil
+}
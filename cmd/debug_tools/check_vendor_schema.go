@@ -1,47 +0,0 @@
-package main
-
-import (
-	"context"
-	"fmt"
-	"log"
-	"os"
-
-	"github.com/jackc/pgx/v5"
-	"github.com/joho/godotenv"
-)
-
-func main() {
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using system env")
-	}
-
-	dbURL := os.Getenv("DATABASE_URL")
-	if dbURL == "" {
-		log.Fatal("DATABASE_URL is not set")
-	}
-
-	conn, err := pgx.Connect(context.Background(), dbURL)
-	if err != nil {
-		log.Fatalf("Unable to connect to database: %v", err)
-	}
-	defer conn.Close(context.Background())
-
-	rows, err := conn.Query(context.Background(), `
-		SELECT column_name, data_type 
-		FROM information_schema.columns 
-		WHERE table_name = 'vendor_profiles'
-	`)
-	if err != nil {
-		log.Fatalf("Query failed: %v", err)
-	}
-	defer rows.Close()
-
-	fmt.Println("Columns in vendor_profiles:")
-	for rows.Next() {
-		var colName, dataType string
-		if err := rows.Scan(&colName, &dataType); err != nil {
-			log.Fatalf("Scan failed: %v", err)
-		}
-		fmt.Printf("- %s (%s)\n", colName, dataType)
-	}
-}
@@ -1,14 +0,0 @@
-module debug_tools
-
-go 1.25.7
-
-require (
-	github.com/jackc/pgx/v5 v5.8.0
-	github.com/joho/godotenv v1.5.1
-)
-
-require (
-	github.com/jackc/pgpassfile v1.0.0 // indirect
-	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
-	golang.org/x/text v0.29.0 // indirect
-)
@@ -5,12 +5,50 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/bventy/backend/internal/config"
 	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/db/migrations"
+	"github.com/bventy/backend/internal/migrate"
 )
 
+const usage = `Usage: migrate <command> [args]
+
+Commands:
+  up              Apply all pending migrations
+  down            Roll back the most recently applied migration
+  status          List migrations and whether they are applied
+  goto N          Migrate up or down to version N (not below 12; the
+                  migrations up to 012 shipped without down sections)
+  baseline N      Mark migrations up to N as applied without running them
+  create NAME     Write a new empty migration into internal/db/migrations
+  verify          Compare the live schema with a scratch database built
+                  from the migrations (needs CREATEDB); exits 1 on drift
+`
+
+const migrationsDir = "internal/db/migrations"
+
 func main() {
+	if len(os.Args) < 2 {
+		fmt.Print(usage)
+		os.Exit(2)
+	}
+	command, args := os.Args[1], os.Args[2:]
+
+	// create only touches the filesystem, so it needs no database.
+	if command == "create" {
+		if len(args) != 1 {
+			log.Fatal("create requires a NAME")
+		}
+		path, err := migrate.Create(migrationsDir, args[0])
+		if err != nil {
+			log.Fatalf("Failed to create migration: %v", err)
+		}
+		fmt.Println("✅ Created", path)
+		return
+	}
+
 	// Load config
 	cfg := config.LoadConfig()
 
@@ -18,20 +56,97 @@ func main() {
 	db.Connect(cfg)
 	defer db.Pool.Close()
 
-	// Read migration file
-	migrationFile := "internal/db/migrations/010_restore_password_auth.sql"
-	content, err := os.ReadFile(migrationFile)
+	migrator, err := migrate.New(db.Pool, migrations.FS)
 	if err != nil {
-		log.Fatalf("Failed to read migration file: %v", err)
+		log.Fatalf("Failed to load migrations: %v", err)
 	}
 
-	fmt.Println("Running migration:", migrationFile)
+	ctx := context.Background()
 
-	// Execute SQL
-	_, err = db.Pool.Exec(context.Background(), string(content))
-	if err != nil {
-		log.Fatalf("Failed to execute migration: %v", err)
-	}
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("Applied %03d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("Failed to apply migrations: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("✅ Schema is up to date")
+			return
+		}
+		fmt.Printf("✅ Applied %d migration(s)\n", len(applied))
+
+	case "down":
+		m, err := migrator.Down(ctx)
+		if err != nil {
+			log.Fatalf("Failed to roll back: %v", err)
+		}
+		if m == nil {
+			fmt.Println("Nothing to roll back")
+			return
+		}
+		fmt.Printf("✅ Rolled back %03d_%s\n", m.Version, m.Name)
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("Failed to read status: %v", err)
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			note := ""
+			if s.ChecksumMismatch {
+				note = "  ⚠️  checksum mismatch"
+			}
+			if s.Missing {
+				note = "  ⚠️  no file in this build"
+			}
+			fmt.Printf("%03d  %-32s %s%s\n", s.Version, s.Name, state, note)
+		}
 
-	fmt.Println("✅ Migration applied successfully!")
+	case "goto", "baseline":
+		if len(args) != 1 {
+			log.Fatalf("%s requires a version", command)
+		}
+		version, err := strconv.Atoi(args[0])
+		if err != nil {
+			log.Fatalf("Invalid version %q", args[0])
+		}
+		if command == "baseline" {
+			if err := migrator.Baseline(ctx, version); err != nil {
+				log.Fatalf("Failed to baseline: %v", err)
+			}
+			fmt.Printf("✅ Marked migrations up to %03d as applied\n", version)
+			return
+		}
+		if err := migrator.Goto(ctx, version); err != nil {
+			log.Fatalf("Failed to migrate to %d: %v", version, err)
+		}
+		fmt.Printf("✅ Schema at version %03d\n", version)
+
+	case "verify":
+		drifts, err := migrator.Verify(ctx)
+		if err != nil {
+			log.Fatalf("Failed to verify schema: %v", err)
+		}
+		if len(drifts) == 0 {
+			fmt.Println("✅ Live schema matches migrations")
+			return
+		}
+		for _, d := range drifts {
+			fmt.Println(d)
+		}
+		fmt.Printf("❌ %d difference(s) between live schema and migrations\n", len(drifts))
+		db.Pool.Close()
+		os.Exit(1)
+
+	default:
+		fmt.Print(usage)
+		os.Exit(2)
+	}
 }
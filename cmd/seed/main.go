@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bventy/backend/internal/config"
+	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/seed"
+	"github.com/bventy/backend/internal/services"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func main() {
+	seedValue := flag.Int64("seed", 42, "random seed; the same seed produces the same data")
+	users := flag.Int("users", 50, "number of regular users (plus one super_admin and one admin)")
+	vendors := flag.Int("vendors", 30, "number of vendor profiles, owned by the first users")
+	groups := flag.Int("groups", 10, "number of groups")
+	events := flag.Int("events", 40, "number of events")
+	anchor := flag.String("anchor", time.Now().UTC().Format("2006-01-02"), "date the dataset treats as today (YYYY-MM-DD)")
+	password := flag.String("password", "123pass", "password for every seeded user")
+	reset := flag.Bool("reset", false, "truncate seeded tables before inserting")
+	flag.Parse()
+
+	anchorDate, err := time.Parse("2006-01-02", *anchor)
+	if err != nil {
+		log.Fatalf("Invalid -anchor %q: use YYYY-MM-DD", *anchor)
+	}
+
+	// Load config
+	cfg := config.LoadConfig()
+
+	// Connect to DB
+	db.Connect(cfg)
+	defer db.Pool.Close()
+
+	ctx := context.Background()
+
+	if *reset {
+		if err := seed.Reset(ctx, db.Pool); err != nil {
+			log.Fatalf("Failed to reset tables: %v", err)
+		}
+		fmt.Println("Truncated seeded tables")
+	} else {
+		var existing int
+		db.Pool.QueryRow(ctx, "SELECT count(*) FROM users").Scan(&existing)
+		if existing > 0 {
+			log.Fatalf("users already has %d rows; rerun with -reset to replace them", existing)
+		}
+	}
+
+	// Placeholder media always goes to the local backend so seeded data never
+	// touches R2. Run the API with MEDIA_BACKEND=local to serve it.
+	store, err := services.NewLocalMediaStore(cfg.LocalMediaDir, cfg.LocalMediaBaseURL)
+	if err != nil {
+		log.Fatalf("Failed to initialize local media store: %v", err)
+	}
+	urls, err := seed.WritePlaceholders(store)
+	if err != nil {
+		log.Fatalf("Failed to write placeholder media: %v", err)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(*password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Fatalf("Failed to hash password: %v", err)
+	}
+
+	ds := seed.Generate(seed.Options{
+		Seed:    *seedValue,
+		Users:   *users,
+		Vendors: *vendors,
+		Groups:  *groups,
+		Events:  *events,
+		Anchor:  anchorDate,
+	})
+	if err := seed.Insert(ctx, db.Pool, ds, urls, string(hash)); err != nil {
+		log.Fatalf("Failed to insert seed data: %v", err)
+	}
+
+	fmt.Printf("✅ Seeded %d users, %d vendors, %d groups (%d members, %d invites), %d events, %d shortlists\n",
+		len(ds.Users), len(ds.Vendors), len(ds.Groups), len(ds.GroupMembers), len(ds.GroupInvites), len(ds.Events), len(ds.Shortlists))
+	fmt.Printf("   Log in as superadmin@gmail.com / %s\n", *password)
+}
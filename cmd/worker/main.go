@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/bventy/backend/internal/background"
+	"github.com/bventy/backend/internal/config"
+	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/services"
+)
+
+// Runs background jobs and the outbox dispatcher without the HTTP server. Deploy it with
+// EMBEDDED_WORKER=false on the API so the two don't compete for the same
+// CPU; running both is also safe, they share the queue.
+func main() {
+	concurrency := flag.Int("concurrency", 0, "jobs to run at once (default WORKER_CONCURRENCY)")
+	flag.Parse()
+
+	// Step 0: Load config
+	cfg := config.LoadConfig()
+	if *concurrency > 0 {
+		cfg.WorkerConcurrency = *concurrency
+	}
+
+	// Step 1: Connect DB
+	db.Connect(cfg)
+	defer db.Pool.Close()
+
+	// Step 2: Media store for cleanup jobs
+	media, err := services.NewMediaStore(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize media store: %v", err)
+	}
+
+	// Step 3: Run jobs and the outbox dispatcher until SIGINT/SIGTERM; running
+	// jobs are allowed to finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	background.Run(ctx, cfg, db.Pool, media)
+	log.Println("✅ Worker shut down cleanly")
+}
@@ -0,0 +1,25 @@
+// Package analytics keeps aggregate counts derived from domain events.
+package analytics
+
+import (
+	"context"
+
+	"github.com/bventy/backend/internal/outbox"
+	"github.com/jackc/pgx/v5"
+)
+
+// Subscriber is the outbox subscriber name; it is stored with deliveries.
+const Subscriber = "analytics.daily_counts"
+
+// Subscribe counts every domain event per UTC day into event_stats.
+func Subscribe(d *outbox.Dispatcher) {
+	d.SubscribeTx(Subscriber, recordDailyCount)
+}
+
+func recordDailyCount(ctx context.Context, tx pgx.Tx, m outbox.Message) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO event_stats (day, type, count) VALUES ($1, $2, 1)
+		ON CONFLICT (day, type) DO UPDATE SET count = event_stats.count + 1
+	`, m.CreatedAt.UTC().Format("2006-01-02"), m.Type)
+	return err
+}
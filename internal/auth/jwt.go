@@ -11,16 +11,21 @@ import (
 type Claims struct {
 	UserID string `json:"user_id"`
 	Role   string `json:"role"`
+	// SessionVersion is users.session_version when the token was issued;
+	// the token stops working once that moves on.
+	SessionVersion int `json:"sv"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID string, role string, cfg *config.Config) (string, error) {
+func GenerateToken(userID string, role string, sessionVersion int, cfg *config.Config) (string, error) {
 	expirationTime := time.Now().Add(24 * time.Hour)
 	claims := &Claims{
-		UserID: userID,
-		Role:   role,
+		UserID:         userID,
+		Role:           role,
+		SessionVersion: sessionVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "bventy-backend",
 		},
 	}
@@ -0,0 +1,82 @@
+// Package background runs everything that happens outside a request: the job
+// worker, the outbox dispatcher and periodic cleanup. cmd/api embeds it and cmd/worker runs it
+// standalone, so both always register the same handlers and subscribers.
+package background
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bventy/backend/internal/analytics"
+	"github.com/bventy/backend/internal/config"
+	"github.com/bventy/backend/internal/idempotency"
+	"github.com/bventy/backend/internal/jobs"
+	"github.com/bventy/backend/internal/notifications"
+	"github.com/bventy/backend/internal/outbox"
+	"github.com/bventy/backend/internal/services"
+	"github.com/bventy/backend/internal/trash"
+	"github.com/bventy/backend/internal/webhooks"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Run blocks until ctx is cancelled and running jobs have finished.
+func Run(ctx context.Context, cfg *config.Config, pool *pgxpool.Pool, media services.MediaStore) {
+	worker := jobs.NewWorker(pool, jobs.Options{Concurrency: cfg.WorkerConcurrency})
+	jobs.RegisterMedia(worker, media)
+
+	dispatcher := outbox.NewDispatcher(pool)
+	analytics.Subscribe(dispatcher)
+	notifications.Subscribe(dispatcher)
+	webhooks.Subscribe(dispatcher)
+	dispatcher.Register(worker)
+	webhooks.Register(worker, pool, webhooks.NewClient(cfg.WebhooksAllowPrivate))
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		dispatcher.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		worker.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		cleanup(ctx, cfg, pool)
+	}()
+	wg.Wait()
+}
+
+// cleanup deletes expired rows that nothing else removes, once an hour:
+// idempotency keys, and soft-deleted rows past cfg.TrashRetention.
+func cleanup(ctx context.Context, cfg *config.Config, pool *pgxpool.Pool) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		if n, err := idempotency.Prune(ctx, pool, cfg.IdempotencyTTL); err != nil {
+			if ctx.Err() == nil {
+				log.Printf("⚠️  Pruning idempotency keys failed: %v", err)
+			}
+		} else if n > 0 {
+			log.Printf("Pruned %d expired idempotency key(s)", n)
+		}
+		if cfg.TrashRetention > 0 {
+			purged, err := trash.Purge(ctx, pool, cfg.TrashRetention)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("⚠️  Purging deleted rows failed: %v", err)
+			}
+			for kind, n := range purged {
+				log.Printf("Purged %d deleted %s", n, kind)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
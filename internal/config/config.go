@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -21,6 +23,59 @@ type Config struct {
 	R2Bucket          string
 	R2Endpoint        string
 	R2PublicBaseURL   string
+	R2PrivateBucket   string // private files such as verification documents
+	MigrateOnStartup  bool
+	MediaBackend      string
+	LocalMediaDir     string
+	LocalMediaBaseURL string
+	// LocalPrivateDir holds private uploads such as verification documents
+	// when MEDIA_BACKEND=local. It is not served statically; the API serves
+	// its files under LocalPrivateURL to signed URLs only.
+	LocalPrivateDir string
+	LocalPrivateURL string
+	// PrivateMediaSecret signs local private-media URLs. It is separate from
+	// JWTSecret so that neither key can be used to forge the other's tokens.
+	PrivateMediaSecret string
+	// DocumentURLTTL is how long a signed URL to a verification document
+	// stays valid.
+	DocumentURLTTL time.Duration
+	// LegacyRoutesSunset is when the unversioned route aliases go away; it is
+	// advertised in their Sunset header. From then on, edits through them
+	// also need If-Match, like their /v1 twins.
+	LegacyRoutesSunset time.Time
+	Timeouts           Timeouts
+	// EmbeddedWorker runs the background job worker inside the API process.
+	// Turn it off when cmd/worker is deployed on its own.
+	EmbeddedWorker    bool
+	WorkerConcurrency int
+	// WebhooksAllowHTTP lets webhook subscriptions use plain http URLs, for
+	// local development only.
+	WebhooksAllowHTTP bool
+	// WebhooksAllowPrivate lets webhooks reach loopback and private network
+	// addresses, for local development only.
+	WebhooksAllowPrivate bool
+	// FlagsCacheTTL is how long feature flags are cached per instance.
+	FlagsCacheTTL time.Duration
+	// VendorCacheTTL is how long public vendor responses are cached per
+	// instance; writes on the same instance invalidate them at once.
+	VendorCacheTTL time.Duration
+	// PublicCacheControl is sent with cacheable public responses for
+	// browsers and the CDN.
+	PublicCacheControl string
+	// IdempotencyTTL is how long the first response to an Idempotency-Key is
+	// kept for replay.
+	IdempotencyTTL time.Duration
+	// TrashRetention is how long soft-deleted users, vendors, groups and
+	// events can be restored before they and their media are purged.
+	TrashRetention time.Duration
+}
+
+// Timeouts bound each kind of operation a handler performs. They are derived
+// from the request context, so a client disconnect cancels the work early.
+type Timeouts struct {
+	Query time.Duration // reads
+	Write time.Duration // inserts, updates and transactions
+	Media time.Duration // R2 uploads and deletes
 }
 
 func LoadConfig() *Config {
@@ -30,19 +85,44 @@ func LoadConfig() *Config {
 	}
 
 	return &Config{
-		DBUser:            getEnv("DB_USER", "postgres"),
-		DBPassword:        getEnv("DB_PASSWORD", ""),
-		DBName:            getEnv("DB_NAME", "postgres"),
-		DBHost:            getEnv("DB_HOST", "localhost"),
-		DBPort:            getEnv("DB_PORT", "5432"),
-		DatabaseURL:       getEnv("DATABASE_URL", ""),
-		JWTSecret:         getEnv("JWT_SECRET", "dev_secret_do_not_use_in_prod"),
-		ServerPort:        getEnv("SERVER_PORT", "8080"),
-		R2AccessKeyID:     getEnv("R2_ACCESS_KEY_ID", ""),
-		R2SecretAccessKey: getEnv("R2_SECRET_ACCESS_KEY", ""),
-		R2Bucket:          getEnv("R2_BUCKET", ""),
-		R2Endpoint:        getEnv("R2_ENDPOINT", ""),
-		R2PublicBaseURL:   getEnv("R2_PUBLIC_BASE_URL", ""),
+		DBUser:             getEnv("DB_USER", "postgres"),
+		DBPassword:         getEnv("DB_PASSWORD", ""),
+		DBName:             getEnv("DB_NAME", "postgres"),
+		DBHost:             getEnv("DB_HOST", "localhost"),
+		DBPort:             getEnv("DB_PORT", "5432"),
+		DatabaseURL:        getEnv("DATABASE_URL", ""),
+		JWTSecret:          getEnv("JWT_SECRET", "dev_secret_do_not_use_in_prod"),
+		ServerPort:         getEnv("SERVER_PORT", "8080"),
+		R2AccessKeyID:      getEnv("R2_ACCESS_KEY_ID", ""),
+		R2SecretAccessKey:  getEnv("R2_SECRET_ACCESS_KEY", ""),
+		R2Bucket:           getEnv("R2_BUCKET", ""),
+		R2Endpoint:         getEnv("R2_ENDPOINT", ""),
+		R2PublicBaseURL:    getEnv("R2_PUBLIC_BASE_URL", ""),
+		R2PrivateBucket:    getEnv("R2_PRIVATE_BUCKET", ""),
+		MigrateOnStartup:   getEnv("MIGRATE_ON_STARTUP", "false") == "true",
+		MediaBackend:       getEnv("MEDIA_BACKEND", "r2"),
+		LocalMediaDir:      getEnv("LOCAL_MEDIA_DIR", "./media"),
+		LocalMediaBaseURL:  getEnv("LOCAL_MEDIA_BASE_URL", "http://localhost:8082/media"),
+		LocalPrivateDir:    getEnv("LOCAL_PRIVATE_MEDIA_DIR", "./media-private"),
+		LocalPrivateURL:    getEnv("LOCAL_PRIVATE_MEDIA_BASE_URL", "http://localhost:8082/private-media"),
+		PrivateMediaSecret: getEnv("PRIVATE_MEDIA_SECRET", "dev_private_media_secret_do_not_use_in_prod"),
+		DocumentURLTTL:     getDuration("DOCUMENT_URL_TTL", "5m"),
+		LegacyRoutesSunset: getDate("LEGACY_ROUTES_SUNSET", "2027-04-30"),
+		Timeouts: Timeouts{
+			Query: getDuration("DB_QUERY_TIMEOUT", "5s"),
+			Write: getDuration("DB_WRITE_TIMEOUT", "10s"),
+			Media: getDuration("MEDIA_TIMEOUT", "60s"),
+		},
+		EmbeddedWorker:       getEnv("EMBEDDED_WORKER", "true") == "true",
+		WorkerConcurrency:    getInt("WORKER_CONCURRENCY", 4),
+		WebhooksAllowHTTP:    getEnv("WEBHOOKS_ALLOW_HTTP", "false") == "true",
+		WebhooksAllowPrivate: getEnv("WEBHOOKS_ALLOW_PRIVATE_NETWORKS", "false") == "true",
+		FlagsCacheTTL:        getDuration("FLAGS_CACHE_TTL", "30s"),
+		VendorCacheTTL:       getDuration("VENDOR_CACHE_TTL", "60s"),
+		PublicCacheControl: getEnv("PUBLIC_CACHE_CONTROL",
+			"public, max-age=60, s-maxage=300, stale-while-revalidate=600"),
+		IdempotencyTTL: getDuration("IDEMPOTENCY_KEY_TTL", "24h"),
+		TrashRetention: getDuration("TRASH_RETENTION", "720h"),
 	}
 }
 
@@ -52,3 +132,39 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getDate reads a YYYY-MM-DD variable, falling back on a malformed value.
+func getDate(key, fallback string) time.Time {
+	value := getEnv(key, fallback)
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		log.Printf("⚠️  Warning: %s=%q is not YYYY-MM-DD, using %s", key, value, fallback)
+		date, _ = time.Parse("2006-01-02", fallback)
+	}
+	return date
+}
+
+// getInt reads an integer, falling back on a malformed value.
+func getInt(key string, fallback int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("⚠️  Warning: %s=%q is not an integer, using %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
+
+// getDuration reads a Go duration such as "5s", falling back on a malformed value.
+func getDuration(key, fallback string) time.Duration {
+	value := getEnv(key, fallback)
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("⚠️  Warning: %s=%q is not a duration, using %s", key, value, fallback)
+		d, _ = time.ParseDuration(fallback)
+	}
+	return d
+}
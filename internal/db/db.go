@@ -38,5 +38,6 @@ func Connect(cfg *config.Config) {
 		log.Fatal("❌ DB ping failed:", err)
 	}
 
-	fmt.Println("✅ Connected to PostgreSQL successfully!")
+	// stderr, so commands printing JSON keep stdout clean
+	log.Println("✅ Connected to PostgreSQL successfully!")
 }
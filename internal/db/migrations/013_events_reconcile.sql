@@ -0,0 +1,26 @@
+-- 13. Align events with EventHandler
+-- 001 created "date"; the handlers (and the live DB) use event_date and
+-- cover_image_url, and seed.sql writes a status column.
+
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'events' AND column_name = 'date'
+    ) AND NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'events' AND column_name = 'event_date'
+    ) THEN
+        ALTER TABLE events RENAME COLUMN "date" TO event_date;
+    END IF;
+END $$;
+
+ALTER TABLE events
+ADD COLUMN IF NOT EXISTS cover_image_url TEXT,
+ADD COLUMN IF NOT EXISTS status TEXT DEFAULT 'planning';
+
+-- migrate:down
+ALTER TABLE events
+DROP COLUMN IF EXISTS status,
+DROP COLUMN IF EXISTS cover_image_url;
+ALTER TABLE events RENAME COLUMN event_date TO "date";
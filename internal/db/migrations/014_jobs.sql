@@ -0,0 +1,27 @@
+-- 14. Background jobs
+-- Workers claim pending rows with FOR UPDATE SKIP LOCKED. Failed jobs go back
+-- to pending with a later run_at until max_attempts, then become 'dead'.
+CREATE TABLE "jobs" (
+    "id" bigserial NOT NULL,
+    "kind" text NOT NULL,
+    "payload" jsonb NOT NULL DEFAULT '{}',
+    "status" text NOT NULL DEFAULT 'pending',
+    "attempts" int NOT NULL DEFAULT 0,
+    "max_attempts" int NOT NULL DEFAULT 10,
+    "run_at" timestamptz NOT NULL DEFAULT now(),
+    "last_error" text,
+    "locked_by" text,
+    "locked_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT "jobs_pkey" PRIMARY KEY ("id"),
+    CONSTRAINT "jobs_status_check" CHECK (status IN ('pending', 'running', 'done', 'dead'))
+);
+
+-- Claim order; only pending rows are ever scanned by workers.
+CREATE INDEX "jobs_pending_run_at_idx" ON "jobs" ("run_at", "id") WHERE status = 'pending';
+-- Lease recovery for workers that died mid-job.
+CREATE INDEX "jobs_running_locked_at_idx" ON "jobs" ("locked_at") WHERE status = 'running';
+
+-- migrate:down
+DROP TABLE IF EXISTS jobs;
@@ -0,0 +1,38 @@
+-- 15. Transactional outbox
+-- Handlers insert domain events here in the same transaction as the change
+-- they describe. The dispatcher fans each row out to subscribers as jobs and
+-- stamps dispatched_at.
+CREATE TABLE "outbox" (
+    "id" bigserial NOT NULL,
+    "type" text NOT NULL,
+    "aggregate_id" text NOT NULL,
+    "payload" jsonb NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "dispatched_at" timestamptz,
+    CONSTRAINT "outbox_pkey" PRIMARY KEY ("id")
+);
+
+CREATE INDEX "outbox_undispatched_idx" ON "outbox" ("id") WHERE dispatched_at IS NULL;
+
+-- One row per (subscriber, event) that has been handled, so a redelivered
+-- event is skipped.
+CREATE TABLE "outbox_consumed" (
+    "subscriber" text NOT NULL,
+    "event_id" bigint NOT NULL,
+    "consumed_at" timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT "outbox_consumed_pkey" PRIMARY KEY ("subscriber", "event_id"),
+    CONSTRAINT "outbox_consumed_event_id_fkey" FOREIGN KEY ("event_id") REFERENCES "outbox" ("id") ON DELETE CASCADE
+);
+
+-- Daily domain event counts kept by the analytics subscriber.
+CREATE TABLE "event_stats" (
+    "day" date NOT NULL,
+    "type" text NOT NULL,
+    "count" int NOT NULL DEFAULT 0,
+    CONSTRAINT "event_stats_pkey" PRIMARY KEY ("day", "type")
+);
+
+-- migrate:down
+DROP TABLE IF EXISTS event_stats;
+DROP TABLE IF EXISTS outbox_consumed;
+DROP TABLE IF EXISTS outbox;
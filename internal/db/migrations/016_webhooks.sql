@@ -0,0 +1,47 @@
+-- 16. Outgoing webhooks
+-- A subscription receives events about vendors its owner owns, or about every
+-- vendor (and non-vendor events) when all_vendors is set, which only admins
+-- may do.
+CREATE TABLE "webhook_subscriptions" (
+    "id" uuid DEFAULT uuid_generate_v4() NOT NULL,
+    "owner_user_id" uuid NOT NULL,
+    "url" text NOT NULL,
+    "secret" text NOT NULL,
+    "event_types" text[] NOT NULL,
+    "all_vendors" boolean NOT NULL DEFAULT false,
+    "active" boolean NOT NULL DEFAULT true,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT "webhook_subscriptions_pkey" PRIMARY KEY ("id"),
+    CONSTRAINT "webhook_subscriptions_owner_user_id_fkey" FOREIGN KEY (owner_user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX "webhook_subscriptions_owner_user_id_idx" ON "webhook_subscriptions" ("owner_user_id");
+
+-- One row per attempt to get an event to a subscription; replays add a row
+-- pointing at the original. body is exactly what was (or will be) signed and
+-- sent.
+CREATE TABLE "webhook_deliveries" (
+    "id" uuid DEFAULT uuid_generate_v4() NOT NULL,
+    "subscription_id" uuid NOT NULL,
+    "event_id" bigint NOT NULL,
+    "event_type" text NOT NULL,
+    "body" jsonb NOT NULL,
+    "status" text NOT NULL DEFAULT 'pending',
+    "attempts" int NOT NULL DEFAULT 0,
+    "response_status" int,
+    "last_error" text,
+    "replay_of" uuid,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "delivered_at" timestamptz,
+    CONSTRAINT "webhook_deliveries_pkey" PRIMARY KEY ("id"),
+    CONSTRAINT "webhook_deliveries_subscription_id_fkey" FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    CONSTRAINT "webhook_deliveries_replay_of_fkey" FOREIGN KEY (replay_of) REFERENCES webhook_deliveries(id) ON DELETE SET NULL,
+    CONSTRAINT "webhook_deliveries_status_check" CHECK (status IN ('pending', 'succeeded', 'failed'))
+);
+
+CREATE INDEX "webhook_deliveries_subscription_id_created_at_idx" ON "webhook_deliveries" ("subscription_id", "created_at" DESC);
+
+-- migrate:down
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
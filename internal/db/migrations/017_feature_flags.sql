@@ -0,0 +1,19 @@
+-- 17. Feature flags
+-- A flag is off for everyone while enabled is false. Otherwise it is on for
+-- the listed users and roles, plus rollout_percent of all other users
+-- (100 also covers anonymous requests).
+CREATE TABLE "feature_flags" (
+    "key" text NOT NULL,
+    "description" text NOT NULL DEFAULT '',
+    "enabled" boolean NOT NULL DEFAULT false,
+    "roles" text[] NOT NULL DEFAULT '{}',
+    "user_ids" uuid[] NOT NULL DEFAULT '{}',
+    "rollout_percent" int NOT NULL DEFAULT 0,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT "feature_flags_pkey" PRIMARY KEY ("key"),
+    CONSTRAINT "feature_flags_rollout_percent_check" CHECK (rollout_percent BETWEEN 0 AND 100)
+);
+
+-- migrate:down
+DROP TABLE IF EXISTS feature_flags;
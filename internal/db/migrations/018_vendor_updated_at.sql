@@ -0,0 +1,11 @@
+-- 18. Vendor freshness
+-- vendor_profiles.updated_at backs ETag/Last-Modified on the public vendor
+-- endpoints, so every write that changes a public vendor response bumps it.
+UPDATE vendor_profiles SET updated_at = COALESCE(updated_at, created_at, now()) WHERE updated_at IS NULL;
+ALTER TABLE vendor_profiles ALTER COLUMN updated_at SET NOT NULL;
+
+CREATE INDEX idx_vendor_updated_at ON vendor_profiles USING btree (updated_at);
+
+-- migrate:down
+DROP INDEX IF EXISTS idx_vendor_updated_at;
+ALTER TABLE vendor_profiles ALTER COLUMN updated_at DROP NOT NULL;
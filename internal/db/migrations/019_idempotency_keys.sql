@@ -0,0 +1,21 @@
+-- 19. Idempotency keys
+-- The first response to a request carrying an Idempotency-Key, replayed to
+-- retries from the same user. status_code is NULL while the first request is
+-- still running.
+CREATE TABLE "idempotency_keys" (
+    "user_id" uuid NOT NULL,
+    "key" text NOT NULL,
+    "fingerprint" text NOT NULL,
+    "status_code" int,
+    "content_type" text,
+    "body" bytea,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "completed_at" timestamptz,
+    CONSTRAINT "idempotency_keys_pkey" PRIMARY KEY ("user_id", "key"),
+    CONSTRAINT "idempotency_keys_user_id_fkey" FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX "idempotency_keys_created_at_idx" ON "idempotency_keys" ("created_at");
+
+-- migrate:down
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,10 @@
+-- 20. Row versions
+-- Bumped by every profile edit and served as the ETag that PUT/PATCH must
+-- send back in If-Match, so concurrent edits cannot silently overwrite each
+-- other.
+ALTER TABLE users ADD COLUMN "version" int NOT NULL DEFAULT 1;
+ALTER TABLE vendor_profiles ADD COLUMN "version" int NOT NULL DEFAULT 1;
+
+-- migrate:down
+ALTER TABLE vendor_profiles DROP COLUMN IF EXISTS "version";
+ALTER TABLE users DROP COLUMN IF EXISTS "version";
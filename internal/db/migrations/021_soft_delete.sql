@@ -0,0 +1,24 @@
+-- 21. Soft delete
+-- Deleted users, vendors, groups and events keep their rows, stamped with
+-- deleted_at, until the purge job removes them after the retention period.
+-- Rows deleted along with a parent share its deleted_at, which is how restore
+-- finds them again.
+ALTER TABLE users ADD COLUMN deleted_at timestamptz;
+ALTER TABLE vendor_profiles ADD COLUMN deleted_at timestamptz;
+ALTER TABLE groups ADD COLUMN deleted_at timestamptz;
+ALTER TABLE events ADD COLUMN deleted_at timestamptz;
+
+CREATE INDEX idx_users_deleted_at ON users USING btree (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_vendor_deleted_at ON vendor_profiles USING btree (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_groups_deleted_at ON groups USING btree (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_events_deleted_at ON events USING btree (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- migrate:down
+DROP INDEX IF EXISTS idx_events_deleted_at;
+DROP INDEX IF EXISTS idx_groups_deleted_at;
+DROP INDEX IF EXISTS idx_vendor_deleted_at;
+DROP INDEX IF EXISTS idx_users_deleted_at;
+ALTER TABLE events DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE groups DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE vendor_profiles DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
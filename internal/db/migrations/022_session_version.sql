@@ -0,0 +1,7 @@
+-- 22. Session version
+-- Copied into every token at login. Bumping it (bventyctl users
+-- revoke-sessions, or a password reset) makes every earlier token invalid.
+ALTER TABLE users ADD COLUMN session_version int NOT NULL DEFAULT 0;
+
+-- migrate:down
+ALTER TABLE users DROP COLUMN IF EXISTS session_version;
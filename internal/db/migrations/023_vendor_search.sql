@@ -0,0 +1,31 @@
+-- 23. Vendor search
+-- A typical price range in whole rupees, which vendors set themselves, and a
+-- weighted full-text vector over name, category and bio for GET /vendors?q=.
+-- Shortlists are counted per vendor to sort by popularity.
+ALTER TABLE vendor_profiles
+    ADD COLUMN price_min int CONSTRAINT vendor_profiles_price_min_check CHECK (price_min >= 0),
+    ADD COLUMN price_max int,
+    ADD CONSTRAINT vendor_profiles_price_range_check CHECK (price_max >= price_min),
+    ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(business_name, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(category, '')), 'B') ||
+        setweight(to_tsvector('english', coalesce(bio, '')), 'C')
+    ) STORED;
+
+CREATE INDEX idx_vendor_search ON vendor_profiles USING gin (search_vector);
+CREATE INDEX idx_vendor_category_lower ON vendor_profiles USING btree (lower(category));
+CREATE INDEX idx_vendor_city_lower ON vendor_profiles USING btree (lower(city));
+CREATE INDEX idx_vendor_created_at ON vendor_profiles USING btree (created_at);
+CREATE INDEX idx_shortlist_vendor ON event_shortlisted_vendors USING btree (vendor_id);
+
+-- migrate:down
+DROP INDEX IF EXISTS idx_shortlist_vendor;
+DROP INDEX IF EXISTS idx_vendor_created_at;
+DROP INDEX IF EXISTS idx_vendor_city_lower;
+DROP INDEX IF EXISTS idx_vendor_category_lower;
+DROP INDEX IF EXISTS idx_vendor_search;
+ALTER TABLE vendor_profiles
+    DROP COLUMN IF EXISTS search_vector,
+    DROP CONSTRAINT IF EXISTS vendor_profiles_price_range_check,
+    DROP COLUMN IF EXISTS price_max,
+    DROP COLUMN IF EXISTS price_min;
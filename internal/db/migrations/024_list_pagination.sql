@@ -0,0 +1,39 @@
+-- 24. Keyset pagination
+-- Lists page by their sort columns plus the id, so those columns must never be
+-- NULL, and each list gets an index matching its order.
+UPDATE users SET created_at = COALESCE(updated_at, now()) WHERE created_at IS NULL;
+ALTER TABLE users ALTER COLUMN created_at SET NOT NULL;
+UPDATE vendor_profiles SET created_at = updated_at WHERE created_at IS NULL;
+ALTER TABLE vendor_profiles ALTER COLUMN created_at SET NOT NULL;
+UPDATE event_shortlisted_vendors SET created_at = now() WHERE created_at IS NULL;
+ALTER TABLE event_shortlisted_vendors ALTER COLUMN created_at SET NOT NULL;
+
+-- GET /admin/users
+CREATE INDEX idx_users_created_at_id ON users USING btree (created_at, id) WHERE deleted_at IS NULL;
+-- GET /admin/vendors, with and without ?status=, and search sorted by newest
+DROP INDEX IF EXISTS idx_vendor_created_at;
+CREATE INDEX idx_vendor_created_at_id ON vendor_profiles USING btree (created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_vendor_status_created_at_id ON vendor_profiles USING btree (status, created_at, id) WHERE deleted_at IS NULL;
+-- GET /vendors in name order
+CREATE INDEX idx_vendor_name_id ON vendor_profiles USING btree (business_name, id) WHERE status = 'verified' AND deleted_at IS NULL;
+-- GET /events, through either organiser column
+CREATE INDEX idx_events_organizer_user_date ON events USING btree (organizer_user_id, event_date, id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_events_organizer_group_date ON events USING btree (organizer_group_id, event_date, id) WHERE deleted_at IS NULL;
+-- GET /groups/my
+CREATE INDEX idx_group_members_user ON group_members USING btree (user_id);
+-- GET /events/:id/shortlist
+CREATE INDEX idx_shortlist_event_created_at ON event_shortlisted_vendors USING btree (event_id, created_at, vendor_id);
+
+-- migrate:down
+DROP INDEX IF EXISTS idx_shortlist_event_created_at;
+DROP INDEX IF EXISTS idx_group_members_user;
+DROP INDEX IF EXISTS idx_events_organizer_group_date;
+DROP INDEX IF EXISTS idx_events_organizer_user_date;
+DROP INDEX IF EXISTS idx_vendor_name_id;
+DROP INDEX IF EXISTS idx_vendor_status_created_at_id;
+DROP INDEX IF EXISTS idx_vendor_created_at_id;
+CREATE INDEX idx_vendor_created_at ON vendor_profiles USING btree (created_at);
+DROP INDEX IF EXISTS idx_users_created_at_id;
+ALTER TABLE event_shortlisted_vendors ALTER COLUMN created_at DROP NOT NULL;
+ALTER TABLE vendor_profiles ALTER COLUMN created_at DROP NOT NULL;
+ALTER TABLE users ALTER COLUMN created_at DROP NOT NULL;
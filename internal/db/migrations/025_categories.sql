@@ -0,0 +1,81 @@
+-- 25. Category taxonomy
+-- Categories replace the free-text vendor_profiles.category. They nest one
+-- level deep (the handlers keep it that way) and aliases, stored lowercase,
+-- let clients keep sending the names vendors used to type. A vendor belongs
+-- to any number of categories through vendor_categories; category_id is the
+-- main one, and category keeps its name for readers that predate this.
+CREATE TABLE "categories" (
+    "id" uuid DEFAULT uuid_generate_v4() NOT NULL,
+    "parent_id" uuid,
+    "slug" text NOT NULL,
+    "name" text NOT NULL,
+    "icon" text,
+    "sort_order" int NOT NULL DEFAULT 0,
+    "aliases" text[] NOT NULL DEFAULT '{}',
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT "categories_pkey" PRIMARY KEY ("id"),
+    CONSTRAINT "categories_slug_key" UNIQUE ("slug"),
+    CONSTRAINT "categories_parent_id_fkey" FOREIGN KEY (parent_id) REFERENCES categories(id) ON DELETE RESTRICT,
+    CONSTRAINT "categories_parent_id_check" CHECK (parent_id <> id)
+);
+
+CREATE INDEX idx_categories_parent ON categories USING btree (parent_id, sort_order);
+
+CREATE TABLE "vendor_categories" (
+    "vendor_id" uuid NOT NULL,
+    "category_id" uuid NOT NULL,
+    CONSTRAINT "vendor_categories_pkey" PRIMARY KEY ("vendor_id", "category_id"),
+    CONSTRAINT "vendor_categories_vendor_id_fkey" FOREIGN KEY (vendor_id) REFERENCES vendor_profiles(id) ON DELETE CASCADE,
+    CONSTRAINT "vendor_categories_category_id_fkey" FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE RESTRICT
+);
+
+CREATE INDEX idx_vendor_categories_category ON vendor_categories USING btree (category_id);
+
+ALTER TABLE vendor_profiles
+    ADD COLUMN category_id uuid CONSTRAINT vendor_profiles_category_id_fkey REFERENCES categories(id) ON DELETE RESTRICT;
+
+-- The categories the seed data and the web client's picker use, with the
+-- spellings seen in production as aliases.
+INSERT INTO categories (slug, name, sort_order, aliases) VALUES
+    ('photography', 'Photography', 10, '{photographer,photographers,"photo & video","photo and video",videography,videographer}'),
+    ('venue', 'Venue', 20, '{venues,banquet,"banquet hall",lawn}'),
+    ('catering', 'Catering', 30, '{caterer,caterers,food}'),
+    ('decor', 'Decor', 40, '{decoration,decorations,decorator,decorators}'),
+    ('florist', 'Florist', 50, '{florists,flowers,floral}'),
+    ('makeup', 'Makeup', 60, '{"makeup artist",make-up,"make up",mua}'),
+    ('dj', 'DJ', 70, '{djs,"dj & sound",sound,music}'),
+    ('lighting', 'Lighting', 80, '{lights}'),
+    ('transport', 'Transport', 90, '{transportation,cars,"car rental"}'),
+    ('stationery', 'Stationery', 100, '{invitations,"invitation cards"}');
+
+-- Any other value becomes a category of its own, for admins to merge or
+-- rename later.
+INSERT INTO categories (slug, name, sort_order)
+SELECT DISTINCT ON (slug) slug, name, 1000
+FROM (
+    SELECT trim(both '-' from regexp_replace(lower(trim(category)), '[^a-z0-9]+', '-', 'g')) AS slug, trim(category) AS name
+    FROM vendor_profiles
+) v
+WHERE slug <> ''
+  AND NOT EXISTS (
+    SELECT 1 FROM categories c
+    WHERE c.slug = v.slug OR lower(c.name) = lower(v.name) OR lower(v.name) = ANY (c.aliases)
+  )
+ORDER BY slug, name
+ON CONFLICT (slug) DO NOTHING;
+
+UPDATE vendor_profiles vp
+SET category_id = c.id, category = c.name
+FROM categories c
+WHERE lower(trim(vp.category)) IN (c.slug, lower(c.name))
+   OR lower(trim(vp.category)) = ANY (c.aliases)
+   OR trim(both '-' from regexp_replace(lower(trim(vp.category)), '[^a-z0-9]+', '-', 'g')) = c.slug;
+
+INSERT INTO vendor_categories (vendor_id, category_id)
+SELECT id, category_id FROM vendor_profiles WHERE category_id IS NOT NULL;
+
+-- migrate:down
+ALTER TABLE vendor_profiles DROP COLUMN IF EXISTS category_id;
+DROP TABLE IF EXISTS vendor_categories;
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,97 @@
+-- 26. Locations
+-- A reference list of cities with their coordinates. The handlers match typed
+-- cities against the name or an alias (stored lowercase), store the canonical
+-- name and link the row through location_id; cities not on the list are kept
+-- as typed. Vendors and events may also pin exact coordinates, which radius
+-- search prefers over their city's.
+CREATE TABLE "locations" (
+    "id" uuid DEFAULT uuid_generate_v4() NOT NULL,
+    "city" text NOT NULL,
+    "state" text NOT NULL,
+    "aliases" text[] NOT NULL DEFAULT '{}',
+    "latitude" double precision NOT NULL,
+    "longitude" double precision NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT "locations_pkey" PRIMARY KEY ("id"),
+    CONSTRAINT "locations_city_state_key" UNIQUE ("city", "state"),
+    CONSTRAINT "locations_coordinates_check" CHECK (latitude BETWEEN -90 AND 90 AND longitude BETWEEN -180 AND 180)
+);
+
+CREATE INDEX idx_locations_city_lower ON locations USING btree (lower(city));
+
+INSERT INTO locations (city, state, aliases, latitude, longitude) VALUES
+    ('Pune', 'Maharashtra', '{poona}', 18.5204, 73.8567),
+    ('Mumbai', 'Maharashtra', '{bombay}', 19.0760, 72.8777),
+    ('Navi Mumbai', 'Maharashtra', '{"new bombay"}', 19.0330, 73.0297),
+    ('Thane', 'Maharashtra', '{}', 19.2183, 72.9781),
+    ('Nagpur', 'Maharashtra', '{}', 21.1458, 79.0882),
+    ('Nashik', 'Maharashtra', '{nasik}', 19.9975, 73.7898),
+    ('Bengaluru', 'Karnataka', '{bangalore,blr}', 12.9716, 77.5946),
+    ('Mysuru', 'Karnataka', '{mysore}', 12.2958, 76.6394),
+    ('Delhi', 'Delhi', '{"new delhi"}', 28.6139, 77.2090),
+    ('Gurugram', 'Haryana', '{gurgaon}', 28.4595, 77.0266),
+    ('Noida', 'Uttar Pradesh', '{}', 28.5355, 77.3910),
+    ('Lucknow', 'Uttar Pradesh', '{}', 26.8467, 80.9462),
+    ('Hyderabad', 'Telangana', '{secunderabad}', 17.3850, 78.4867),
+    ('Visakhapatnam', 'Andhra Pradesh', '{vizag}', 17.6868, 83.2185),
+    ('Chennai', 'Tamil Nadu', '{madras}', 13.0827, 80.2707),
+    ('Coimbatore', 'Tamil Nadu', '{}', 11.0168, 76.9558),
+    ('Kochi', 'Kerala', '{cochin,ernakulam}', 9.9312, 76.2673),
+    ('Kolkata', 'West Bengal', '{calcutta}', 22.5726, 88.3639),
+    ('Ahmedabad', 'Gujarat', '{amdavad}', 23.0225, 72.5714),
+    ('Surat', 'Gujarat', '{}', 21.1702, 72.8311),
+    ('Vadodara', 'Gujarat', '{baroda}', 22.3072, 73.1812),
+    ('Jaipur', 'Rajasthan', '{}', 26.9124, 75.7873),
+    ('Udaipur', 'Rajasthan', '{}', 24.5854, 73.7125),
+    ('Indore', 'Madhya Pradesh', '{}', 22.7196, 75.8577),
+    ('Bhopal', 'Madhya Pradesh', '{}', 23.2599, 77.4126),
+    ('Chandigarh', 'Chandigarh', '{}', 30.7333, 76.7794),
+    ('Goa', 'Goa', '{panaji,panjim}', 15.4909, 73.8278);
+
+ALTER TABLE users
+    ADD COLUMN location_id uuid CONSTRAINT users_location_id_fkey REFERENCES locations(id) ON DELETE SET NULL;
+ALTER TABLE groups
+    ADD COLUMN location_id uuid CONSTRAINT groups_location_id_fkey REFERENCES locations(id) ON DELETE SET NULL;
+ALTER TABLE vendor_profiles
+    ADD COLUMN location_id uuid CONSTRAINT vendor_profiles_location_id_fkey REFERENCES locations(id) ON DELETE SET NULL,
+    ADD COLUMN latitude double precision,
+    ADD COLUMN longitude double precision,
+    ADD CONSTRAINT vendor_profiles_coordinates_check CHECK (
+        (latitude IS NULL) = (longitude IS NULL) AND latitude BETWEEN -90 AND 90 AND longitude BETWEEN -180 AND 180
+    );
+ALTER TABLE events
+    ADD COLUMN location_id uuid CONSTRAINT events_location_id_fkey REFERENCES locations(id) ON DELETE SET NULL,
+    ADD COLUMN latitude double precision,
+    ADD COLUMN longitude double precision,
+    ADD CONSTRAINT events_coordinates_check CHECK (
+        (latitude IS NULL) = (longitude IS NULL) AND latitude BETWEEN -90 AND 90 AND longitude BETWEEN -180 AND 180
+    );
+
+UPDATE users t SET location_id = l.id, city = l.city FROM locations l
+WHERE lower(trim(t.city)) = lower(l.city) OR lower(trim(t.city)) = ANY (l.aliases);
+UPDATE groups t SET location_id = l.id, city = l.city FROM locations l
+WHERE lower(trim(t.city)) = lower(l.city) OR lower(trim(t.city)) = ANY (l.aliases);
+UPDATE vendor_profiles t SET location_id = l.id, city = l.city FROM locations l
+WHERE lower(trim(t.city)) = lower(l.city) OR lower(trim(t.city)) = ANY (l.aliases);
+UPDATE events t SET location_id = l.id, city = l.city FROM locations l
+WHERE lower(trim(t.city)) = lower(l.city) OR lower(trim(t.city)) = ANY (l.aliases);
+
+CREATE INDEX idx_vendor_location ON vendor_profiles USING btree (location_id);
+CREATE INDEX idx_events_location ON events USING btree (location_id);
+
+-- migrate:down
+DROP INDEX IF EXISTS idx_events_location;
+DROP INDEX IF EXISTS idx_vendor_location;
+ALTER TABLE events
+    DROP CONSTRAINT IF EXISTS events_coordinates_check,
+    DROP COLUMN IF EXISTS longitude,
+    DROP COLUMN IF EXISTS latitude,
+    DROP COLUMN IF EXISTS location_id;
+ALTER TABLE vendor_profiles
+    DROP CONSTRAINT IF EXISTS vendor_profiles_coordinates_check,
+    DROP COLUMN IF EXISTS longitude,
+    DROP COLUMN IF EXISTS latitude,
+    DROP COLUMN IF EXISTS location_id;
+ALTER TABLE groups DROP COLUMN IF EXISTS location_id;
+ALTER TABLE users DROP COLUMN IF EXISTS location_id;
+DROP TABLE IF EXISTS locations;
@@ -0,0 +1,29 @@
+-- 27. Vendor packages
+-- Priced offers a vendor lists on its page: a fixed price (price_max NULL) or
+-- a range, per event, guest, hour or day. Prices are whole units of currency.
+-- A vendor's "starting from" price is derived from these and price_min when
+-- read, over rupee prices only.
+CREATE TABLE "vendor_packages" (
+    "id" uuid DEFAULT uuid_generate_v4() NOT NULL,
+    "vendor_id" uuid NOT NULL,
+    "name" text NOT NULL,
+    "description" text NOT NULL DEFAULT '',
+    "inclusions" text[] NOT NULL DEFAULT '{}',
+    "price_min" int NOT NULL,
+    "price_max" int,
+    "unit" text NOT NULL DEFAULT 'per_event',
+    "currency" text NOT NULL DEFAULT 'INR',
+    "sort_order" int NOT NULL DEFAULT 0,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT "vendor_packages_pkey" PRIMARY KEY ("id"),
+    CONSTRAINT "vendor_packages_vendor_id_fkey" FOREIGN KEY (vendor_id) REFERENCES vendor_profiles(id) ON DELETE CASCADE,
+    CONSTRAINT "vendor_packages_price_check" CHECK (price_min >= 0 AND price_max >= price_min),
+    CONSTRAINT "vendor_packages_unit_check" CHECK (unit IN ('per_event', 'per_guest', 'per_hour', 'per_day')),
+    CONSTRAINT "vendor_packages_currency_check" CHECK (currency ~ '^[A-Z]{3}$')
+);
+
+CREATE INDEX idx_vendor_packages_vendor ON vendor_packages USING btree (vendor_id, sort_order);
+
+-- migrate:down
+DROP TABLE IF EXISTS vendor_packages;
@@ -0,0 +1,26 @@
+-- 28. Vendor availability
+-- Vendors take up to daily_capacity events a day. A vendor_availability row
+-- records a day that differs: blocked outright, or with bookings and an
+-- optional capacity of its own. A vendor is unavailable on a day it blocked
+-- or whose bookings reach its capacity; days without a row are free.
+ALTER TABLE vendor_profiles
+    ADD COLUMN daily_capacity int NOT NULL DEFAULT 1 CONSTRAINT vendor_profiles_daily_capacity_check CHECK (daily_capacity >= 1);
+
+CREATE TABLE "vendor_availability" (
+    "vendor_id" uuid NOT NULL,
+    "date" date NOT NULL,
+    "blocked" boolean NOT NULL DEFAULT false,
+    "booked" int NOT NULL DEFAULT 0,
+    "capacity" int,
+    "note" text NOT NULL DEFAULT '',
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT "vendor_availability_pkey" PRIMARY KEY ("vendor_id", "date"),
+    CONSTRAINT "vendor_availability_vendor_id_fkey" FOREIGN KEY (vendor_id) REFERENCES vendor_profiles(id) ON DELETE CASCADE,
+    CONSTRAINT "vendor_availability_counts_check" CHECK (booked >= 0 AND capacity >= 1)
+);
+
+CREATE INDEX idx_vendor_availability_date ON vendor_availability USING btree (date);
+
+-- migrate:down
+DROP TABLE IF EXISTS vendor_availability;
+ALTER TABLE vendor_profiles DROP COLUMN IF EXISTS daily_capacity;
@@ -0,0 +1,33 @@
+-- 29. Vendor reviews
+-- Organisers rate a vendor their event shortlisted, once the event is over;
+-- one review per event and vendor by each organiser. edited_at marks the one
+-- edit a review allows. The vendor may reply publicly. Admins hide reviews,
+-- which drops them from listings and averages, or flag them for a second look.
+CREATE TABLE "vendor_reviews" (
+    "id" uuid DEFAULT uuid_generate_v4() NOT NULL,
+    "vendor_id" uuid NOT NULL,
+    "event_id" uuid NOT NULL,
+    "author_user_id" uuid NOT NULL,
+    "rating" smallint NOT NULL,
+    "body" text NOT NULL DEFAULT '',
+    "edited_at" timestamptz,
+    "reply" text,
+    "replied_at" timestamptz,
+    "hidden" boolean NOT NULL DEFAULT false,
+    "flagged" boolean NOT NULL DEFAULT false,
+    "moderation_note" text NOT NULL DEFAULT '',
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT "vendor_reviews_pkey" PRIMARY KEY ("id"),
+    CONSTRAINT "vendor_reviews_vendor_id_fkey" FOREIGN KEY (vendor_id) REFERENCES vendor_profiles(id) ON DELETE CASCADE,
+    CONSTRAINT "vendor_reviews_event_id_fkey" FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE,
+    CONSTRAINT "vendor_reviews_author_user_id_fkey" FOREIGN KEY (author_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT "vendor_reviews_event_vendor_author_key" UNIQUE ("event_id", "vendor_id", "author_user_id"),
+    CONSTRAINT "vendor_reviews_rating_check" CHECK (rating BETWEEN 1 AND 5)
+);
+
+CREATE INDEX idx_vendor_reviews_vendor ON vendor_reviews USING btree (vendor_id, created_at) WHERE NOT hidden;
+CREATE INDEX idx_vendor_reviews_flagged ON vendor_reviews USING btree (created_at) WHERE flagged;
+
+-- migrate:down
+DROP TABLE IF EXISTS vendor_reviews;
@@ -0,0 +1,32 @@
+-- 30. Vendor verification documents
+-- Business proof a vendor uploads for KYC: one current file per kind. Files
+-- live under the media store's private prefix and are only reachable through
+-- short-lived signed URLs, so object_key is a storage key, not a URL. Admins
+-- accept or reject each document with a note; approving a vendor requires the
+-- mandatory kinds (see moderation.RequiredDocuments) to be accepted.
+CREATE TABLE "vendor_documents" (
+    "id" uuid DEFAULT uuid_generate_v4() NOT NULL,
+    "vendor_id" uuid NOT NULL,
+    "kind" text NOT NULL,
+    "object_key" text NOT NULL,
+    "filename" text NOT NULL,
+    "content_type" text NOT NULL,
+    "size_bytes" bigint NOT NULL,
+    "status" text NOT NULL DEFAULT 'pending',
+    "review_note" text NOT NULL DEFAULT '',
+    "reviewed_by" uuid,
+    "reviewed_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT "vendor_documents_pkey" PRIMARY KEY ("id"),
+    CONSTRAINT "vendor_documents_vendor_id_fkey" FOREIGN KEY (vendor_id) REFERENCES vendor_profiles(id) ON DELETE CASCADE,
+    CONSTRAINT "vendor_documents_reviewed_by_fkey" FOREIGN KEY (reviewed_by) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT "vendor_documents_vendor_kind_key" UNIQUE ("vendor_id", "kind"),
+    CONSTRAINT "vendor_documents_kind_check" CHECK (kind IN ('gst_certificate', 'identity', 'address_proof', 'other')),
+    CONSTRAINT "vendor_documents_status_check" CHECK (status IN ('pending', 'accepted', 'rejected'))
+);
+
+CREATE INDEX idx_vendor_documents_pending ON vendor_documents USING btree (created_at) WHERE status = 'pending';
+
+-- migrate:down
+DROP TABLE IF EXISTS vendor_documents;
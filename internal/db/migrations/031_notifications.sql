@@ -0,0 +1,21 @@
+-- 31. Notifications
+-- In-app notifications written by the notifications outbox subscriber: a
+-- vendor owner hears when the vendor is verified or shortlisted. One row per
+-- (user, event), so a redelivered event cannot notify twice.
+CREATE TABLE "notifications" (
+    "id" uuid DEFAULT uuid_generate_v4() NOT NULL,
+    "user_id" uuid NOT NULL,
+    "type" text NOT NULL,
+    "event_id" bigint NOT NULL,
+    "data" jsonb NOT NULL,
+    "read_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT "notifications_pkey" PRIMARY KEY ("id"),
+    CONSTRAINT "notifications_user_id_fkey" FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT "notifications_user_event_key" UNIQUE ("user_id", "event_id")
+);
+
+CREATE INDEX idx_notifications_user ON notifications USING btree (user_id, created_at DESC);
+
+-- migrate:down
+DROP TABLE IF EXISTS notifications;
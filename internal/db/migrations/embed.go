@@ -0,0 +1,8 @@
+// Package migrations embeds the versioned SQL migrations so the API and the
+// migrate command run exactly the files that were built into the binary.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
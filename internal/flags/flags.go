@@ -0,0 +1,146 @@
+// Package flags evaluates runtime feature flags stored in the feature_flags
+// table. Flags are cached in memory and reloaded every TTL, so a change made
+// on one instance reaches the others within that window.
+package flags
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Flag struct {
+	Key            string    `json:"key"`
+	Description    string    `json:"description"`
+	Enabled        bool      `json:"enabled" doc:"Kill switch: when false the flag is off for everyone"`
+	Roles          []string  `json:"roles" doc:"Roles that always get the flag"`
+	UserIDs        []string  `json:"user_ids" doc:"Users that always get the flag"`
+	RolloutPercent int       `json:"rollout_percent" doc:"Share of other users, 0-100; 100 includes anonymous requests"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Subject is who a flag is evaluated for. Both fields are empty for
+// anonymous requests.
+type Subject struct {
+	UserID string
+	Role   string
+}
+
+// EnabledFor reports whether f is on for s.
+func (f Flag) EnabledFor(s Subject) bool {
+	switch {
+	case !f.Enabled:
+		return false
+	case f.RolloutPercent >= 100:
+		return true
+	case s.UserID != "" && slices.Contains(f.UserIDs, s.UserID):
+		return true
+	case s.Role != "" && slices.Contains(f.Roles, s.Role):
+		return true
+	case s.UserID != "" && f.RolloutPercent > 0:
+		return Bucket(f.Key, s.UserID) < f.RolloutPercent
+	}
+	return false
+}
+
+// Bucket places userID in 0-99 for key. It is stable, so raising a rollout
+// percentage only ever adds users, and independent across flags.
+func Bucket(key, userID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	h.Write([]byte{':'})
+	h.Write([]byte(userID))
+	return int(h.Sum32() % 100)
+}
+
+// Loader fetches every flag.
+type Loader func(ctx context.Context) ([]Flag, error)
+
+// FromPool loads flags from the feature_flags table.
+func FromPool(pool *pgxpool.Pool) Loader {
+	return func(ctx context.Context) ([]Flag, error) {
+		rows, err := pool.Query(ctx, `
+			SELECT key, description, enabled, roles, user_ids::text[], rollout_percent, updated_at
+			FROM feature_flags ORDER BY key
+		`)
+		if err != nil {
+			return nil, err
+		}
+		return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Flag, error) {
+			var f Flag
+			err := row.Scan(&f.Key, &f.Description, &f.Enabled, &f.Roles, &f.UserIDs, &f.RolloutPercent, &f.UpdatedAt)
+			return f, err
+		})
+	}
+}
+
+// Store caches flags from a Loader.
+type Store struct {
+	load Loader
+	ttl  time.Duration
+
+	mu       sync.RWMutex
+	flags    map[string]Flag
+	loadedAt time.Time
+}
+
+func NewStore(load Loader, ttl time.Duration) *Store {
+	return &Store{load: load, ttl: ttl}
+}
+
+// Enabled reports whether the flag key is on for s. Unknown flags are off. If
+// reloading fails the previous snapshot is used for another TTL.
+func (s *Store) Enabled(ctx context.Context, key string, subject Subject) bool {
+	f, ok := s.snapshot(ctx)[key]
+	return ok && f.EnabledFor(subject)
+}
+
+// Evaluate returns every flag's value for subject.
+func (s *Store) Evaluate(ctx context.Context, subject Subject) map[string]bool {
+	out := map[string]bool{}
+	for key, f := range s.snapshot(ctx) {
+		out[key] = f.EnabledFor(subject)
+	}
+	return out
+}
+
+// Invalidate forces the next lookup to reload, e.g. after an admin change.
+func (s *Store) Invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+func (s *Store) snapshot(ctx context.Context) map[string]Flag {
+	s.mu.RLock()
+	flags, fresh := s.flags, time.Since(s.loadedAt) < s.ttl
+	s.mu.RUnlock()
+	if fresh {
+		return flags
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.loadedAt) < s.ttl { // another request reloaded meanwhile
+		return s.flags
+	}
+	list, err := s.load(ctx)
+	if err != nil {
+		// Try again after another TTL rather than on every request.
+		log.Printf("⚠️  Reloading feature flags failed, keeping %d cached: %v", len(s.flags), err)
+		s.loadedAt = time.Now()
+		return s.flags
+	}
+	s.flags = make(map[string]Flag, len(list))
+	for _, f := range list {
+		s.flags[f.Key] = f
+	}
+	s.loadedAt = time.Now()
+	return s.flags
+}
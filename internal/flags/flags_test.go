@@ -0,0 +1,100 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEnabledFor(t *testing.T) {
+	alice := Subject{UserID: "11111111-1111-1111-1111-111111111111", Role: "user"}
+	admin := Subject{UserID: "22222222-2222-2222-2222-222222222222", Role: "admin"}
+	anon := Subject{}
+
+	cases := []struct {
+		name string
+		flag Flag
+		want map[Subject]bool
+	}{
+		{"kill switch beats everything",
+			Flag{Key: "k", Enabled: false, RolloutPercent: 100, Roles: []string{"admin"}, UserIDs: []string{alice.UserID}},
+			map[Subject]bool{alice: false, admin: false, anon: false}},
+		{"global on",
+			Flag{Key: "k", Enabled: true, RolloutPercent: 100},
+			map[Subject]bool{alice: true, admin: true, anon: true}},
+		{"enabled but untargeted is off",
+			Flag{Key: "k", Enabled: true},
+			map[Subject]bool{alice: false, admin: false, anon: false}},
+		{"role",
+			Flag{Key: "k", Enabled: true, Roles: []string{"admin"}},
+			map[Subject]bool{alice: false, admin: true, anon: false}},
+		{"user",
+			Flag{Key: "k", Enabled: true, UserIDs: []string{alice.UserID}},
+			map[Subject]bool{alice: true, admin: false, anon: false}},
+	}
+	for _, tc := range cases {
+		for subject, want := range tc.want {
+			if got := tc.flag.EnabledFor(subject); got != want {
+				t.Errorf("%s: EnabledFor(%+v) = %v, want %v", tc.name, subject, got, want)
+			}
+		}
+	}
+}
+
+func TestRolloutIsStableAndProportional(t *testing.T) {
+	f := Flag{Key: "new-search", Enabled: true, RolloutPercent: 30}
+	on := 0
+	for i := 0; i < 10000; i++ {
+		s := Subject{UserID: time.Unix(int64(i), 0).String()}
+		got := f.EnabledFor(s)
+		if got != f.EnabledFor(s) {
+			t.Fatal("rollout is not stable for a user")
+		}
+		if got {
+			on++
+			// Raising the percentage never drops a user.
+			if !(Flag{Key: f.Key, Enabled: true, RolloutPercent: 60}).EnabledFor(s) {
+				t.Fatal("user dropped when rollout grew")
+			}
+		}
+	}
+	if on < 2700 || on > 3300 {
+		t.Fatalf("30%% rollout enabled %d of 10000 users", on)
+	}
+	if f.EnabledFor(Subject{}) {
+		t.Fatal("partial rollout enabled for an anonymous request")
+	}
+}
+
+func TestStoreCachesAndInvalidates(t *testing.T) {
+	ctx := context.Background()
+	loads := 0
+	current := []Flag{{Key: "a", Enabled: true, RolloutPercent: 100}}
+	var loadErr error
+	s := NewStore(func(ctx context.Context) ([]Flag, error) {
+		loads++
+		return current, loadErr
+	}, time.Hour)
+
+	if !s.Enabled(ctx, "a", Subject{}) || s.Enabled(ctx, "missing", Subject{}) {
+		t.Fatal("unexpected evaluation")
+	}
+	current = []Flag{{Key: "a", Enabled: false}}
+	if !s.Enabled(ctx, "a", Subject{}) || loads != 1 {
+		t.Fatalf("expected cached value after %d loads", loads)
+	}
+
+	s.Invalidate()
+	if s.Enabled(ctx, "a", Subject{}) || loads != 2 {
+		t.Fatalf("invalidate did not reload (%d loads)", loads)
+	}
+
+	// A failed reload keeps serving the last snapshot.
+	loadErr = errors.New("db down")
+	current = nil
+	s.Invalidate()
+	if got := s.Evaluate(ctx, Subject{}); len(got) != 1 || got["a"] {
+		t.Fatalf("stale snapshot not kept: %v", got)
+	}
+}
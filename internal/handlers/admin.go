@@ -2,21 +2,79 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"time"
 
+	"github.com/bventy/backend/internal/config"
 	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/httpcache"
+	"github.com/bventy/backend/internal/moderation"
+	"github.com/bventy/backend/internal/services"
+	"github.com/bventy/backend/internal/webhooks"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-type AdminHandler struct{}
+type AdminHandler struct {
+	Config *config.Config
+	// MediaService signs links to vendors' verification documents.
+	MediaService services.MediaStore
+	// VendorCache is invalidated when a vendor is approved or rejected.
+	VendorCache *httpcache.Cache
+}
+
+func NewAdminHandler(cfg *config.Config, media services.MediaStore, vendorCache *httpcache.Cache) *AdminHandler {
+	return &AdminHandler{Config: cfg, MediaService: media, VendorCache: vendorCache}
+}
+
+type AdminVendor struct {
+	ID                     string    `json:"id"`
+	BusinessName           string    `json:"business_name"`
+	UserID                 string    `json:"user_id"`
+	City                   string    `json:"city"`
+	Category               string    `json:"category"`
+	PrimaryProfileImageURL *string   `json:"primary_profile_image_url"`
+	CreatedAt              time.Time `json:"created_at"`
+}
+
+// AdminVendorPage is one page of GET /admin/vendors, newest first.
+type AdminVendorPage struct {
+	Vendors    []AdminVendor `json:"vendors"`
+	NextCursor *string       `json:"next_cursor"`
+}
 
-func NewAdminHandler() *AdminHandler {
-	return &AdminHandler{}
+type AdminUser struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	FullName  string    `json:"full_name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
+// AdminUserPage is one page of GET /admin/users, newest first.
+type AdminUserPage struct {
+	Users      []AdminUser `json:"users"`
+	NextCursor *string     `json:"next_cursor"`
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required" doc:"user, staff, admin or super_admin"`
+}
+
+var adminVendorOrder = keyset{{expr: "vp.created_at", typ: "timestamp", desc: true}, {expr: "vp.id", typ: "uuid", desc: true}}
+
 // Vendor Moderation
 func (h *AdminHandler) GetVendors(c *gin.Context) {
 	status := c.Query("status")
+	p, ok := parsePage(c, adminVendorOrder)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
+
 	query := `
 		SELECT 
 			vp.id, 
@@ -24,108 +82,130 @@ func (h *AdminHandler) GetVendors(c *gin.Context) {
 			vp.owner_user_id, 
 			vp.city,
 			vp.category,
-			u.profile_image_url
+			u.profile_image_url,
+			vp.created_at
 		FROM vendor_profiles vp
 		JOIN users u ON vp.owner_user_id = u.id
+		WHERE vp.deleted_at IS NULL
 	`
 
 	args := []interface{}{}
 	if status != "" {
-		query += " WHERE vp.status = $1"
+		query += " AND vp.status = $1"
 		args = append(args, status)
 	}
 
-	rows, err := db.Pool.Query(context.Background(), query, args...)
+	query, args = p.query(query, adminVendorOrder, args)
+	rows, err := db.Pool.Query(ctx, query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vendors"})
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch vendors")
 		return
 	}
 	defer rows.Close()
 
-	var vendors []gin.H
+	// Return empty list instead of null
+	vendors := []AdminVendor{}
 	for rows.Next() {
-		var id, businessName, ownerID, city, category string
-		var profileImageURL *string
-
-		if err := rows.Scan(&id, &businessName, &ownerID, &city, &category, &profileImageURL); err != nil {
+		var v AdminVendor
+		if err := rows.Scan(&v.ID, &v.BusinessName, &v.UserID, &v.City, &v.Category, &v.PrimaryProfileImageURL, &v.CreatedAt); err != nil {
 			continue
 		}
-
-		vendors = append(vendors, gin.H{
-			"id":                        id,
-			"business_name":             businessName,
-			"user_id":                   ownerID,
-			"city":                      city,
-			"category":                  category,
-			"primary_profile_image_url": profileImageURL,
-		})
+		vendors = append(vendors, v)
 	}
-
-	// Return empty list instead of null
-	if vendors == nil {
-		vendors = []gin.H{}
+	if err := rows.Err(); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch vendors")
+		return
 	}
 
-	c.JSON(http.StatusOK, vendors)
+	vendors, next := trim(p, vendors, func(v AdminVendor) []string { return []string{cursorTime(v.CreatedAt), v.ID} })
+	if p.all() {
+		c.JSON(http.StatusOK, vendors)
+		return
+	}
+	c.JSON(http.StatusOK, AdminVendorPage{Vendors: vendors, NextCursor: next})
 }
 
 func (h *AdminHandler) VerifyVendor(c *gin.Context) { // Mapped to Approve
+	h.moderate(c, "Vendor verified successfully", func(ctx context.Context, vendorID string) error {
+		return moderation.Approve(ctx, db.Pool, vendorID, c.GetString("userID"))
+	})
+}
+
+func (h *AdminHandler) RejectVendor(c *gin.Context) {
+	h.moderate(c, "Vendor rejected successfully", func(ctx context.Context, vendorID string) error {
+		return moderation.Reject(ctx, db.Pool, vendorID)
+	})
+}
+
+// moderate runs an approve or reject on the vendor in the path.
+func (h *AdminHandler) moderate(c *gin.Context, done string, op func(ctx context.Context, vendorID string) error) {
 	vendorID := c.Param("id")
-	query := `UPDATE vendor_profiles SET status = 'verified' WHERE id = $1 RETURNING id`
-	var id string
-	err := db.Pool.QueryRow(context.Background(), query, vendorID).Scan(&id)
-	if err != nil {
+	if _, err := uuid.Parse(vendorID); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Vendor not found or already processed"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Vendor verified successfully"})
-}
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
 
-func (h *AdminHandler) RejectVendor(c *gin.Context) {
-	vendorID := c.Param("id")
-	query := `UPDATE vendor_profiles SET status = 'rejected' WHERE id = $1 RETURNING id`
-	var id string
-	err := db.Pool.QueryRow(context.Background(), query, vendorID).Scan(&id)
-	if err != nil {
+	err := op(ctx, vendorID)
+	var missing *moderation.MissingDocumentsError
+	if errors.Is(err, moderation.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Vendor not found or already processed"})
 		return
 	}
+	if errors.As(err, &missing) {
+		c.JSON(http.StatusConflict, MissingDocumentsResponse{Error: "Required documents have not been accepted", Missing: missing.Missing})
+		return
+	}
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to update vendor")
+		return
+	}
+	h.VendorCache.Invalidate()
 
-	c.JSON(http.StatusOK, gin.H{"message": "Vendor rejected successfully"})
+	c.JSON(http.StatusOK, MessageResponse{Message: done})
 }
 
+var adminUserOrder = keyset{{expr: "created_at", typ: "timestamp", desc: true}, {expr: "id", typ: "uuid", desc: true}}
+
 // User Management
 func (h *AdminHandler) GetUsers(c *gin.Context) {
-	query := `SELECT id, email, full_name, role, created_at FROM users`
-	rows, err := db.Pool.Query(context.Background(), query)
+	p, ok := parsePage(c, adminUserOrder)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
+
+	query, args := p.query(`SELECT id, email, full_name, role, created_at FROM users WHERE deleted_at IS NULL`, adminUserOrder, nil)
+	rows, err := db.Pool.Query(ctx, query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch users")
 		return
 	}
 	defer rows.Close()
 
-	var users []gin.H
+	users := []AdminUser{}
 	for rows.Next() {
-		var id, email, fullName, role string
-		var createdAt interface{}
-		if err := rows.Scan(&id, &email, &fullName, &role, &createdAt); err != nil {
+		var u AdminUser
+		if err := rows.Scan(&u.ID, &u.Email, &u.FullName, &u.Role, &u.CreatedAt); err != nil {
 			continue
 		}
-		users = append(users, gin.H{
-			"id":         id,
-			"email":      email,
-			"full_name":  fullName,
-			"role":       role,
-			"created_at": createdAt,
-		})
+		users = append(users, u)
 	}
-
-	if users == nil {
-		users = []gin.H{}
+	if err := rows.Err(); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch users")
+		return
 	}
 
-	c.JSON(http.StatusOK, users)
+	users, next := trim(p, users, func(u AdminUser) []string { return []string{cursorTime(u.CreatedAt), u.ID} })
+	if p.all() {
+		c.JSON(http.StatusOK, users)
+		return
+	}
+	c.JSON(http.StatusOK, AdminUserPage{Users: users, NextCursor: next})
 }
 
 func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
@@ -136,9 +216,7 @@ func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
 	// The requirement "Include: ... Validate role"
 
 	userID := c.Param("id")
-	var input struct {
-		Role string `json:"role" binding:"required"`
-	}
+	var input UpdateRoleRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 		return
@@ -150,20 +228,40 @@ func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
 		return
 	}
 
-	query := `UPDATE users SET role = $1 WHERE id = $2 RETURNING id`
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to update role")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// Bump session_version like bventyctl users set-role: the role is in the
+	// JWT, so existing tokens must stop working.
+	query := `UPDATE users SET role = $1, session_version = session_version + 1 WHERE id = $2 AND deleted_at IS NULL RETURNING id`
 	var id string
-	err := db.Pool.QueryRow(context.Background(), query, input.Role, userID).Scan(&id)
+	err = tx.QueryRow(ctx, query, input.Role, userID).Scan(&id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		fail(c, err, http.StatusNotFound, "User not found")
+		return
+	}
+	if err := webhooks.DeactivateAllVendors(ctx, tx, id, input.Role); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to update role")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to update role")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "User role updated successfully"})
+	c.JSON(http.StatusOK, MessageResponse{Message: "User role updated successfully"})
 }
 
 // Stats (Legacy mapping for dashboard stats)
 func (h *AdminHandler) GetStats(c *gin.Context) {
 	// Re-route or reuse the overview logic
-	metricsHandler := NewAdminMetricsHandler()
+	metricsHandler := NewAdminMetricsHandler(h.Config)
 	metricsHandler.GetAdminMetricsOverview(c)
 }
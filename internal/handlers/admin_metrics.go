@@ -1,18 +1,84 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
 	"time"
 
+	"github.com/bventy/backend/internal/config"
 	"github.com/bventy/backend/internal/db"
 	"github.com/gin-gonic/gin"
 )
 
-type AdminMetricsHandler struct{}
+// AdminMetricsHandler ignores individual query errors so one failing count
+// doesn't blank the dashboard, but still reports a timeout rather than
+// returning zeros.
+type AdminMetricsHandler struct {
+	Config *config.Config
+}
+
+func NewAdminMetricsHandler(cfg *config.Config) *AdminMetricsHandler {
+	return &AdminMetricsHandler{Config: cfg}
+}
+
+type MetricsOverview struct {
+	TotalUsers      int `json:"total_users"`
+	TotalVendors    int `json:"total_vendors"`
+	VerifiedVendors int `json:"verified_vendors"`
+	PendingVendors  int `json:"pending_vendors"`
+	TotalEvents     int `json:"total_events"`
+	PublishedEvents int `json:"published_events"`
+	CompletedEvents int `json:"completed_events"`
+	TotalGroups     int `json:"total_groups"`
+}
+
+type DailyCount struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+type MetricsGrowth struct {
+	UserSignupsByDay   []DailyCount `json:"user_signups_by_day"`
+	VendorSignupsByDay []DailyCount `json:"vendor_signups_by_day"`
+	EventsCreatedByDay []DailyCount `json:"events_created_by_day"`
+}
+
+type StatusCount struct {
+	Status string `json:"status"`
+	Count  int    `json:"count"`
+}
+
+type CityCount struct {
+	City  string `json:"city"`
+	Count int    `json:"count"`
+}
+
+type MetricsEvents struct {
+	EventsByStatus   []StatusCount `json:"events_by_status"`
+	EventsByCity     []CityCount   `json:"events_by_city"`
+	AverageBudgetMin float64       `json:"average_budget_min"`
+	AverageBudgetMax float64       `json:"average_budget_max"`
+}
+
+type VendorShortlistCount struct {
+	VendorID       string `json:"vendor_id"`
+	BusinessName   string `json:"business_name"`
+	City           string `json:"city"`
+	Category       string `json:"category"`
+	ShortlistCount int    `json:"shortlist_count"`
+}
+
+type InactiveVendor struct {
+	VendorID     string    `json:"vendor_id"`
+	BusinessName string    `json:"business_name"`
+	City         string    `json:"city"`
+	Category     string    `json:"category"`
+	CreatedAt    time.Time `json:"created_at"`
+}
 
-func NewAdminMetricsHandler() *AdminMetricsHandler {
-	return &AdminMetricsHandler{}
+type MetricsVendors struct {
+	VendorsWithMostShortlists []VendorShortlistCount `json:"vendors_with_most_shortlists"`
+	InactiveVendors           []InactiveVendor       `json:"inactive_vendors"`
+	TopViewedVendors          []VendorShortlistCount `json:"top_viewed_vendors" doc:"Always empty; views are not tracked yet."`
 }
 
 // 1. Overview Endpoint
@@ -20,75 +86,73 @@ func (h *AdminMetricsHandler) GetAdminMetricsOverview(c *gin.Context) {
 	var totalUsers, totalGroups, totalEvents, publishedEvents, completedEvents int
 	var totalVendors, verifiedVendors, pendingVendors int
 
-	ctx := context.Background()
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
 
 	// Users
-	db.Pool.QueryRow(ctx, "SELECT count(*) FROM users").Scan(&totalUsers)
+	db.Pool.QueryRow(ctx, "SELECT count(*) FROM users WHERE deleted_at IS NULL").Scan(&totalUsers)
 
 	// Groups
-	db.Pool.QueryRow(ctx, "SELECT count(*) FROM groups").Scan(&totalGroups)
+	db.Pool.QueryRow(ctx, "SELECT count(*) FROM groups WHERE deleted_at IS NULL").Scan(&totalGroups)
 
 	// Vendors
-	db.Pool.QueryRow(ctx, "SELECT count(*) FROM vendor_profiles").Scan(&totalVendors)
-	db.Pool.QueryRow(ctx, "SELECT count(*) FROM vendor_profiles WHERE status = 'verified'").Scan(&verifiedVendors)
-	db.Pool.QueryRow(ctx, "SELECT count(*) FROM vendor_profiles WHERE status = 'pending'").Scan(&pendingVendors)
+	db.Pool.QueryRow(ctx, "SELECT count(*) FROM vendor_profiles WHERE deleted_at IS NULL").Scan(&totalVendors)
+	db.Pool.QueryRow(ctx, "SELECT count(*) FROM vendor_profiles WHERE status = 'verified' AND deleted_at IS NULL").Scan(&verifiedVendors)
+	db.Pool.QueryRow(ctx, "SELECT count(*) FROM vendor_profiles WHERE status = 'pending' AND deleted_at IS NULL").Scan(&pendingVendors)
 
 	// Events
-	db.Pool.QueryRow(ctx, "SELECT count(*) FROM events").Scan(&totalEvents)
+	db.Pool.QueryRow(ctx, "SELECT count(*) FROM events WHERE deleted_at IS NULL").Scan(&totalEvents)
 	// Completed events (date is in the past)
-	db.Pool.QueryRow(ctx, "SELECT count(*) FROM events WHERE event_date < CURRENT_DATE").Scan(&completedEvents)
+	db.Pool.QueryRow(ctx, "SELECT count(*) FROM events WHERE event_date < CURRENT_DATE AND deleted_at IS NULL").Scan(&completedEvents)
 	// Published events (upcoming/today)
-	db.Pool.QueryRow(ctx, "SELECT count(*) FROM events WHERE event_date >= CURRENT_DATE").Scan(&publishedEvents)
-
-	c.JSON(http.StatusOK, gin.H{
-		"total_users":      totalUsers,
-		"total_vendors":    totalVendors,
-		"verified_vendors": verifiedVendors,
-		"pending_vendors":  pendingVendors,
-		"total_events":     totalEvents,
-		"published_events": publishedEvents,
-		"completed_events": completedEvents,
-		"total_groups":     totalGroups,
+	db.Pool.QueryRow(ctx, "SELECT count(*) FROM events WHERE event_date >= CURRENT_DATE AND deleted_at IS NULL").Scan(&publishedEvents)
+
+	if timedOut(c, ctx.Err()) {
+		return
+	}
+
+	c.JSON(http.StatusOK, MetricsOverview{
+		TotalUsers:      totalUsers,
+		TotalVendors:    totalVendors,
+		VerifiedVendors: verifiedVendors,
+		PendingVendors:  pendingVendors,
+		TotalEvents:     totalEvents,
+		PublishedEvents: publishedEvents,
+		CompletedEvents: completedEvents,
+		TotalGroups:     totalGroups,
 	})
 }
 
 // 2. Growth Endpoint
 func (h *AdminMetricsHandler) GetAdminMetricsGrowth(c *gin.Context) {
-	ctx := context.Background()
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
 
 	// Get dates for the last 30 days
 	thirtyDaysAgo := time.Now().AddDate(0, 0, -30)
 
-	type dailyStat struct {
-		Date  string `json:"date"`
-		Count int    `json:"count"`
-	}
-
-	fetchGrowthData := func(query string, args ...interface{}) []dailyStat {
+	fetchGrowthData := func(query string, args ...interface{}) []DailyCount {
+		stats := []DailyCount{}
 		rows, err := db.Pool.Query(ctx, query, args...)
 		if err != nil {
-			return []dailyStat{}
+			return stats
 		}
 		defer rows.Close()
 
-		var stats []dailyStat
 		for rows.Next() {
 			var date time.Time
 			var count int
 			if err := rows.Scan(&date, &count); err == nil {
-				stats = append(stats, dailyStat{Date: date.Format("2006-01-02"), Count: count})
+				stats = append(stats, DailyCount{Date: date.Format("2006-01-02"), Count: count})
 			}
 		}
-		if stats == nil {
-			stats = []dailyStat{}
-		}
 		return stats
 	}
 
 	userSignupsQuery := `
 		SELECT DATE(created_at) as date, count(*) as count
 		FROM users
-		WHERE created_at >= $1
+		WHERE created_at >= $1 AND deleted_at IS NULL
 		GROUP BY DATE(created_at)
 		ORDER BY DATE(created_at) ASC
 	`
@@ -97,7 +161,7 @@ func (h *AdminMetricsHandler) GetAdminMetricsGrowth(c *gin.Context) {
 	vendorSignupsQuery := `
 		SELECT DATE(created_at) as date, count(*) as count
 		FROM vendor_profiles
-		WHERE created_at >= $1
+		WHERE created_at >= $1 AND deleted_at IS NULL
 		GROUP BY DATE(created_at)
 		ORDER BY DATE(created_at) ASC
 	`
@@ -106,137 +170,130 @@ func (h *AdminMetricsHandler) GetAdminMetricsGrowth(c *gin.Context) {
 	eventsCreatedQuery := `
 		SELECT DATE(created_at) as date, count(*) as count
 		FROM events
-		WHERE created_at >= $1
+		WHERE created_at >= $1 AND deleted_at IS NULL
 		GROUP BY DATE(created_at)
 		ORDER BY DATE(created_at) ASC
 	`
 	eventsCreated := fetchGrowthData(eventsCreatedQuery, thirtyDaysAgo)
 
-	c.JSON(http.StatusOK, gin.H{
-		"user_signups_by_day":   userSignups,
-		"vendor_signups_by_day": vendorSignups,
-		"events_created_by_day": eventsCreated,
+	if timedOut(c, ctx.Err()) {
+		return
+	}
+
+	c.JSON(http.StatusOK, MetricsGrowth{
+		UserSignupsByDay:   userSignups,
+		VendorSignupsByDay: vendorSignups,
+		EventsCreatedByDay: eventsCreated,
 	})
 }
 
 // 3. Events Endpoint
 func (h *AdminMetricsHandler) GetAdminMetricsEvents(c *gin.Context) {
-	ctx := context.Background()
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
 
 	// Events by status (Upcoming vs Completed)
 	var eventsUpcoming, eventsCompleted int
-	db.Pool.QueryRow(ctx, "SELECT count(*) FROM events WHERE event_date >= CURRENT_DATE").Scan(&eventsUpcoming)
-	db.Pool.QueryRow(ctx, "SELECT count(*) FROM events WHERE event_date < CURRENT_DATE").Scan(&eventsCompleted)
+	db.Pool.QueryRow(ctx, "SELECT count(*) FROM events WHERE event_date >= CURRENT_DATE AND deleted_at IS NULL").Scan(&eventsUpcoming)
+	db.Pool.QueryRow(ctx, "SELECT count(*) FROM events WHERE event_date < CURRENT_DATE AND deleted_at IS NULL").Scan(&eventsCompleted)
 
-	eventsByStatusList := []gin.H{
-		{"status": "Upcoming", "count": eventsUpcoming},
-		{"status": "Completed", "count": eventsCompleted},
+	eventsByStatusList := []StatusCount{
+		{Status: "Upcoming", Count: eventsUpcoming},
+		{Status: "Completed", Count: eventsCompleted},
 	}
 
 	// Events by city
 	eventsByCityQuery := `
-		SELECT city, count(*) as count
-		FROM events
-		GROUP BY city
+		SELECT COALESCE(l.city, e.city) AS city, count(*) as count
+		FROM events e
+		LEFT JOIN locations l ON l.id = e.location_id
+		WHERE e.deleted_at IS NULL
+		GROUP BY 1
 		ORDER BY count DESC
 	`
 	rows, _ := db.Pool.Query(ctx, eventsByCityQuery)
-	var eventsByCity []gin.H
+	eventsByCity := []CityCount{}
 	if rows != nil {
 		defer rows.Close()
 		for rows.Next() {
-			var city string
-			var count int
-			if err := rows.Scan(&city, &count); err == nil {
-				eventsByCity = append(eventsByCity, gin.H{"city": city, "count": count})
+			var cc CityCount
+			if err := rows.Scan(&cc.City, &cc.Count); err == nil {
+				eventsByCity = append(eventsByCity, cc)
 			}
 		}
 	}
-	if eventsByCity == nil {
-		eventsByCity = []gin.H{}
-	}
 
 	// Average Budgets
 	var avgBudgetMin, avgBudgetMax float64
-	db.Pool.QueryRow(ctx, "SELECT COALESCE(AVG(budget_min), 0) FROM events").Scan(&avgBudgetMin)
-	db.Pool.QueryRow(ctx, "SELECT COALESCE(AVG(budget_max), 0) FROM events").Scan(&avgBudgetMax)
-
-	c.JSON(http.StatusOK, gin.H{
-		"events_by_status":   eventsByStatusList,
-		"events_by_city":     eventsByCity,
-		"average_budget_min": avgBudgetMin,
-		"average_budget_max": avgBudgetMax,
+	db.Pool.QueryRow(ctx, "SELECT COALESCE(AVG(budget_min), 0) FROM events WHERE deleted_at IS NULL").Scan(&avgBudgetMin)
+	db.Pool.QueryRow(ctx, "SELECT COALESCE(AVG(budget_max), 0) FROM events WHERE deleted_at IS NULL").Scan(&avgBudgetMax)
+
+	if timedOut(c, ctx.Err()) {
+		return
+	}
+
+	c.JSON(http.StatusOK, MetricsEvents{
+		EventsByStatus:   eventsByStatusList,
+		EventsByCity:     eventsByCity,
+		AverageBudgetMin: avgBudgetMin,
+		AverageBudgetMax: avgBudgetMax,
 	})
 }
 
 // 4. Vendors Endpoint
 func (h *AdminMetricsHandler) GetAdminMetricsVendors(c *gin.Context) {
-	ctx := context.Background()
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
 
 	// Most Shortlisted Vendors
 	mostShortlistsQuery := `
 		SELECT vp.id, vp.business_name, vp.city, vp.category, COUNT(esv.event_id) as shortlist_count
 		FROM vendor_profiles vp
 		JOIN event_shortlisted_vendors esv ON vp.id = esv.vendor_id
+		WHERE vp.deleted_at IS NULL
 		GROUP BY vp.id, vp.business_name, vp.city, vp.category
 		ORDER BY shortlist_count DESC
 		LIMIT 10
 	`
 
 	rows, _ := db.Pool.Query(ctx, mostShortlistsQuery)
-	var mostShortlisted []gin.H
+	mostShortlisted := []VendorShortlistCount{}
 	if rows != nil {
 		defer rows.Close()
 		for rows.Next() {
-			var id, name, city, category string
-			var count int
-			if err := rows.Scan(&id, &name, &city, &category, &count); err == nil {
-				mostShortlisted = append(mostShortlisted, gin.H{
-					"vendor_id":       id,
-					"business_name":   name,
-					"city":            city,
-					"category":        category,
-					"shortlist_count": count,
-				})
+			var v VendorShortlistCount
+			if err := rows.Scan(&v.VendorID, &v.BusinessName, &v.City, &v.Category, &v.ShortlistCount); err == nil {
+				mostShortlisted = append(mostShortlisted, v)
 			}
 		}
 	}
-	if mostShortlisted == nil {
-		mostShortlisted = []gin.H{}
-	}
 
 	// Inactive Vendors (Pending for > 30 days)
 	inactiveVendorsQuery := `
 		SELECT id, business_name, city, category, created_at
 		FROM vendor_profiles
-		WHERE status = 'pending' AND created_at < CURRENT_DATE - INTERVAL '30 days'
+		WHERE status = 'pending' AND created_at < CURRENT_DATE - INTERVAL '30 days' AND deleted_at IS NULL
 		ORDER BY created_at ASC
 	`
 	rowsInactive, _ := db.Pool.Query(ctx, inactiveVendorsQuery)
-	var inactiveVendors []gin.H
+	inactiveVendors := []InactiveVendor{}
 	if rowsInactive != nil {
 		defer rowsInactive.Close()
 		for rowsInactive.Next() {
-			var id, name, city, category string
-			var createdAt time.Time
-			if err := rowsInactive.Scan(&id, &name, &city, &category, &createdAt); err == nil {
-				inactiveVendors = append(inactiveVendors, gin.H{
-					"vendor_id":     id,
-					"business_name": name,
-					"city":          city,
-					"category":      category,
-					"created_at":    createdAt,
-				})
+			var v InactiveVendor
+			if err := rowsInactive.Scan(&v.VendorID, &v.BusinessName, &v.City, &v.Category, &v.CreatedAt); err == nil {
+				inactiveVendors = append(inactiveVendors, v)
 			}
 		}
 	}
-	if inactiveVendors == nil {
-		inactiveVendors = []gin.H{}
+
+	if timedOut(c, ctx.Err()) {
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"vendors_with_most_shortlists": mostShortlisted,
-		"inactive_vendors":             inactiveVendors,
-		"top_viewed_vendors":           []gin.H{}, // Empty array since no view tracking exists
+	c.JSON(http.StatusOK, MetricsVendors{
+		VendorsWithMostShortlists: mostShortlisted,
+		InactiveVendors:           inactiveVendors,
+		TopViewedVendors:          []VendorShortlistCount{}, // Empty array since no view tracking exists
 	})
 }
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -27,6 +26,22 @@ type SignupRequest struct {
 	Phone    string `json:"phone"`
 }
 
+type AuthUser struct {
+	ID       string `json:"id"`
+	Email    string `json:"email"`
+	FullName string `json:"full_name"`
+	Role     string `json:"role"`
+}
+
+// SignupResponse carries a token and the new user. If the token cannot be
+// issued the account still exists and only UserID is set.
+type SignupResponse struct {
+	Message string    `json:"message"`
+	Token   string    `json:"token,omitempty"`
+	User    *AuthUser `json:"user,omitempty"`
+	UserID  string    `json:"user_id,omitempty"`
+}
+
 func (h *AuthHandler) Signup(c *gin.Context) {
 	var req SignupRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -40,6 +55,9 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 		return
 	}
 
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
 	var userID string
 	// Handle empty username as NULL
 	var usernameArg interface{} = req.Username
@@ -53,7 +71,7 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id
 	`
-	err = db.Pool.QueryRow(context.Background(), query, 
+	err = db.Pool.QueryRow(ctx, query,
 		req.Email, 
 		string(hashedPassword), 
 		req.FullName, 
@@ -62,24 +80,24 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 	).Scan(&userID)
 
 	if err != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "User already exists or valid constraint failed"})
+		fail(c, err, http.StatusConflict, "User already exists or valid constraint failed")
 		return
 	}
 
-	token, err := auth.GenerateToken(userID, "user", h.Config)
+	token, err := auth.GenerateToken(userID, "user", 0, h.Config)
 	if err != nil {
-		c.JSON(http.StatusCreated, gin.H{"message": "User created, please login", "user_id": userID})
+		c.JSON(http.StatusCreated, SignupResponse{Message: "User created, please login", UserID: userID})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "User created successfully",
-		"token":   token,
-		"user": gin.H{
-			"id":        userID,
-			"email":     req.Email,
-			"full_name": req.FullName,
-			"role":      "user",
+	c.JSON(http.StatusCreated, SignupResponse{
+		Message: "User created successfully",
+		Token:   token,
+		User: &AuthUser{
+			ID:       userID,
+			Email:    req.Email,
+			FullName: req.FullName,
+			Role:     "user",
 		},
 	})
 }
@@ -89,6 +107,13 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type LoginResponse struct {
+	Token    string `json:"token"`
+	Role     string `json:"role"`
+	UserID   string `json:"user_id"`
+	FullName string `json:"full_name"`
+}
+
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -96,11 +121,15 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
+
 	var userID, role, passwordHash, fullName string
-	query := `SELECT id, role, password_hash, full_name FROM users WHERE email = $1`
-	err := db.Pool.QueryRow(context.Background(), query, req.Email).Scan(&userID, &role, &passwordHash, &fullName)
+	var sessionVersion int
+	query := `SELECT id, role, password_hash, full_name, session_version FROM users WHERE email = $1 AND deleted_at IS NULL`
+	err := db.Pool.QueryRow(ctx, query, req.Email).Scan(&userID, &role, &passwordHash, &fullName, &sessionVersion)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		fail(c, err, http.StatusUnauthorized, "Invalid credentials")
 		return
 	}
 
@@ -110,16 +139,16 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := auth.GenerateToken(userID, role, h.Config)
+	token, err := auth.GenerateToken(userID, role, sessionVersion, h.Config)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"token":     token,
-		"role":      role,
-		"user_id":   userID,
-		"full_name": fullName,
+	c.JSON(http.StatusOK, LoginResponse{
+		Token:    token,
+		Role:     role,
+		UserID:   userID,
+		FullName: fullName,
 	})
 }
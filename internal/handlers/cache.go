@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bventy/backend/internal/httpcache"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// serveCached writes e with validators and the public Cache-Control, or a
+// bare 304 when the client already has it.
+func serveCached(c *gin.Context, e httpcache.Entry, cacheControl string) {
+	c.Header("ETag", e.ETag)
+	if !e.LastModified.IsZero() {
+		c.Header("Last-Modified", e.LastModified.Format(http.TimeFormat))
+	}
+	if cacheControl != "" {
+		c.Header("Cache-Control", cacheControl)
+	}
+	if httpcache.NotModified(c.Request, e) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", e.Body)
+}
+
+// touchVendor bumps updated_at on vendorID, which the public vendor endpoints
+// serve as Last-Modified. Run it in the transaction making the change and
+// invalidate the vendor cache after committing.
+func touchVendor(ctx context.Context, tx pgx.Tx, vendorID string) error {
+	_, err := tx.Exec(ctx, `UPDATE vendor_profiles SET updated_at = now() WHERE id = $1`, vendorID)
+	return err
+}
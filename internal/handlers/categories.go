@@ -0,0 +1,430 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/bventy/backend/internal/config"
+	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/httpcache"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// CategoryHandler serves the vendor category taxonomy and lets admins edit it.
+type CategoryHandler struct {
+	Config *config.Config
+	// VendorCache is invalidated when an edit shows on vendor pages.
+	VendorCache *httpcache.Cache
+}
+
+func NewCategoryHandler(cfg *config.Config, vendorCache *httpcache.Cache) *CategoryHandler {
+	return &CategoryHandler{Config: cfg, VendorCache: vendorCache}
+}
+
+// Category is a node of the taxonomy. Categories nest one level deep, so only
+// top-level ones have children.
+type Category struct {
+	ID        string     `json:"id"`
+	ParentID  *string    `json:"parent_id"`
+	Slug      string     `json:"slug"`
+	Name      string     `json:"name"`
+	Icon      *string    `json:"icon"`
+	SortOrder int        `json:"sort_order"`
+	Aliases   []string   `json:"aliases" doc:"Other spellings vendors may send, lowercase"`
+	Children  []Category `json:"children,omitempty"`
+}
+
+type CreateCategoryRequest struct {
+	Name      string   `json:"name" binding:"required,max=80"`
+	Slug      string   `json:"slug" doc:"Derived from name when empty"`
+	ParentID  *string  `json:"parent_id" binding:"omitempty,uuid" doc:"A top-level category"`
+	Icon      *string  `json:"icon" binding:"omitempty,max=200" doc:"Icon name or URL, for clients"`
+	SortOrder int      `json:"sort_order"`
+	Aliases   []string `json:"aliases" doc:"Other spellings vendors may send, matched case-insensitively"`
+}
+
+// CategoryPatch documents the PATCH /admin/categories/:id body, read as a
+// merge patch through categoryPatchFields.
+type CategoryPatch struct {
+	Name      *string  `json:"name"`
+	Slug      *string  `json:"slug" doc:"Vendors keep the category; search links using the old slug stop matching"`
+	ParentID  *string  `json:"parent_id" doc:"A top-level category; null makes it top-level"`
+	Icon      *string  `json:"icon" doc:"null clears it"`
+	SortOrder *int     `json:"sort_order"`
+	Aliases   []string `json:"aliases" doc:"Replaces the list, null empties it"`
+}
+
+var categoryPatchFields = map[string]patchField{
+	"name":       {column: "name", parse: textField(true, 80)},
+	"slug":       {column: "slug", parse: slugField},
+	"parent_id":  {column: "parent_id", clear: nullValue, parse: uuidField},
+	"icon":       {column: "icon", clear: nullValue, parse: nullableText(200)},
+	"sort_order": {column: "sort_order", parse: sortOrderField},
+	"aliases":    {column: "aliases", clear: []string{}, parse: aliasesField},
+}
+
+var (
+	categorySlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	nonSlugChars        = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// categorySlug derives a slug from a category name, as migration 025 did for
+// the free-text categories.
+func categorySlug(name string) string {
+	return strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+var errBadSlug = errors.New("must be lowercase letters and digits separated by hyphens, at most 64 characters")
+
+func validSlug(s string) bool {
+	return len(s) <= 64 && categorySlugPattern.MatchString(s)
+}
+
+func slugField(raw json.RawMessage) (any, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil || !validSlug(s) {
+		return nil, errBadSlug
+	}
+	return s, nil
+}
+
+func uuidField(raw json.RawMessage) (any, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, errors.New("must be a string")
+	}
+	if _, err := uuid.Parse(s); err != nil {
+		return nil, errors.New("must be a UUID")
+	}
+	return s, nil
+}
+
+func sortOrderField(raw json.RawMessage) (any, error) {
+	var n int32
+	if err := json.Unmarshal(raw, &n); err != nil {
+		return nil, errors.New("must be a whole number")
+	}
+	return n, nil
+}
+
+func aliasesField(raw json.RawMessage) (any, error) {
+	var aliases []string
+	if err := json.Unmarshal(raw, &aliases); err != nil {
+		return nil, errors.New("must be an array of strings")
+	}
+	return normalizeAliases(aliases)
+}
+
+// normalizeAliases lowercases and trims aliases, which are matched against
+// lower(input).
+func normalizeAliases(aliases []string) ([]string, error) {
+	if len(aliases) > 20 {
+		return nil, errors.New("at most 20 aliases")
+	}
+	out := make([]string, 0, len(aliases))
+	for _, a := range aliases {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a == "" || len([]rune(a)) > 80 {
+			return nil, errors.New("aliases must be 1-80 characters")
+		}
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+const categoryColumns = `id, parent_id, slug, name, icon, sort_order, aliases`
+
+func scanCategory(row pgx.Row) (Category, error) {
+	var cat Category
+	err := row.Scan(&cat.ID, &cat.ParentID, &cat.Slug, &cat.Name, &cat.Icon, &cat.SortOrder, &cat.Aliases)
+	return cat, err
+}
+
+// ListCategories returns the taxonomy as a tree, each level in sort order.
+func (h *CategoryHandler) ListCategories(c *gin.Context) {
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+categoryColumns+` FROM categories
+		ORDER BY parent_id IS NOT NULL, sort_order, name`)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch categories")
+		return
+	}
+	all, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Category, error) { return scanCategory(row) })
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch categories")
+		return
+	}
+
+	// Top-level categories come first, so every parent is placed before
+	// its children.
+	tree := []Category{}
+	index := map[string]int{}
+	for _, cat := range all {
+		if cat.ParentID == nil {
+			index[cat.ID] = len(tree)
+			tree = append(tree, cat)
+		} else if i, ok := index[*cat.ParentID]; ok {
+			tree[i].Children = append(tree[i].Children, cat)
+		}
+	}
+	c.JSON(http.StatusOK, tree)
+}
+
+// categoryWriteFailed answers the errors Postgres raises for a bad category
+// write and reports whether it did.
+func categoryWriteFailed(c *gin.Context, err error) bool {
+	var pgErr *pgconn.PgError
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, pgx.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+	case errors.As(err, &pgErr) && pgErr.ConstraintName == "categories_slug_key":
+		c.JSON(http.StatusConflict, gin.H{"error": "A category with this slug already exists"})
+	case errors.As(err, &pgErr) && pgErr.ConstraintName == "categories_parent_id_fkey":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown parent category"})
+	case errors.As(err, &pgErr) && pgErr.ConstraintName == "categories_parent_id_check":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A category cannot be its own parent"})
+	default:
+		fail(c, err, http.StatusInternalServerError, "Failed to save category")
+	}
+	return true
+}
+
+// checkNesting answers 400 and returns false when the write in tx left a
+// category under one that is not top-level.
+func checkNesting(c *gin.Context, ctx context.Context, tx pgx.Tx) bool {
+	var nested bool
+	err := tx.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM categories c JOIN categories p ON p.id = c.parent_id
+			WHERE p.parent_id IS NOT NULL
+		)`).Scan(&nested)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to save category")
+		return false
+	}
+	if nested {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Categories nest one level deep: the parent must be top-level and have no parent itself"})
+		return false
+	}
+	return true
+}
+
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	var req CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Slug == "" {
+		req.Slug = categorySlug(req.Name)
+	}
+	if !validSlug(req.Slug) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "slug " + errBadSlug.Error()})
+		return
+	}
+	aliases, err := normalizeAliases(req.Aliases)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	cat, err := scanCategory(tx.QueryRow(ctx, `
+		INSERT INTO categories (parent_id, slug, name, icon, sort_order, aliases)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+categoryColumns,
+		req.ParentID, req.Slug, req.Name, req.Icon, req.SortOrder, aliases))
+	if categoryWriteFailed(c, err) || !checkNesting(c, ctx, tx) {
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+
+	c.JSON(http.StatusCreated, cat)
+}
+
+// PatchCategory applies a merge patch to a category. Renaming one renames it
+// on every vendor whose main category it is.
+func (h *CategoryHandler) PatchCategory(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+	patch, ok := bindMergePatch(c, categoryPatchFields, 2)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	sets := append(patch.sets, "updated_at = now()")
+	cat, err := scanCategory(tx.QueryRow(ctx, `
+		UPDATE categories SET `+strings.Join(sets, ", ")+`
+		WHERE id = $1
+		RETURNING `+categoryColumns,
+		append([]any{id}, patch.args...)...))
+	if categoryWriteFailed(c, err) || !checkNesting(c, ctx, tx) {
+		return
+	}
+
+	// Vendor pages show the category, so they change with it.
+	_, err = tx.Exec(ctx, `
+		UPDATE vendor_profiles
+		SET category = CASE WHEN category_id = $1 THEN $2 ELSE category END, updated_at = now()
+		WHERE id IN (SELECT vendor_id FROM vendor_categories WHERE category_id = $1)`, id, cat.Name)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to save category")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+	h.VendorCache.Invalidate()
+
+	c.JSON(http.StatusOK, cat)
+}
+
+// DeleteCategory deletes a category nothing uses. Vendors and subcategories
+// have to be moved off it first.
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM categories WHERE id = $1`, id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Category still has vendors or subcategories"})
+		return
+	}
+	if err != nil || tag.RowsAffected() == 0 {
+		fail(c, err, http.StatusNotFound, "Category not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Category deleted"})
+}
+
+// maxVendorCategories caps the categories a vendor lists besides its main one.
+const maxVendorCategories = 5
+
+// vendorCategory is a category resolved for a vendor write.
+type vendorCategory struct {
+	id, name string
+}
+
+// resolveCategories looks up each ref by slug, name or alias, ignoring case,
+// so clients can keep sending what vendors used to type. It answers 400 and
+// returns false when a ref matches no category.
+func resolveCategories(c *gin.Context, ctx context.Context, refs []string) ([]vendorCategory, bool) {
+	cats := make([]vendorCategory, 0, len(refs))
+	for _, ref := range refs {
+		var cat vendorCategory
+		err := db.Pool.QueryRow(ctx, `
+			SELECT id, name FROM categories
+			WHERE slug = lower($1) OR lower(name) = lower($1) OR lower($1) = ANY (aliases)
+			ORDER BY slug = lower($1) DESC, lower(name) = lower($1) DESC
+			LIMIT 1`, strings.TrimSpace(ref)).Scan(&cat.id, &cat.name)
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown category: " + ref + "; see GET /v1/categories"})
+			return nil, false
+		}
+		if err != nil {
+			fail(c, err, http.StatusInternalServerError, "Failed to look up categories")
+			return nil, false
+		}
+		cats = append(cats, cat)
+	}
+	return cats, true
+}
+
+// setVendorCategories makes main, when set, the vendor's main category in
+// place of the old one. Non-nil others replace the rest of its categories;
+// nil keeps them.
+func setVendorCategories(ctx context.Context, tx pgx.Tx, vendorID string, main *vendorCategory, others []vendorCategory) error {
+	if main != nil {
+		_, err := tx.Exec(ctx, `
+			DELETE FROM vendor_categories vc USING vendor_profiles vp
+			WHERE vp.id = $1 AND vc.vendor_id = vp.id AND vc.category_id = vp.category_id`, vendorID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `UPDATE vendor_profiles SET category_id = $2, category = $3 WHERE id = $1`, vendorID, main.id, main.name)
+		if err != nil {
+			return err
+		}
+	}
+	ids := []string{}
+	if others != nil {
+		if _, err := tx.Exec(ctx, `DELETE FROM vendor_categories WHERE vendor_id = $1`, vendorID); err != nil {
+			return err
+		}
+		for _, cat := range others {
+			ids = append(ids, cat.id)
+		}
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO vendor_categories (vendor_id, category_id)
+		SELECT $1, id FROM categories
+		WHERE id = ANY ($2::uuid[]) OR id = (SELECT category_id FROM vendor_profiles WHERE id = $1)
+		ON CONFLICT DO NOTHING`, vendorID, ids)
+	return err
+}
+
+func categoriesField(raw json.RawMessage) (any, error) {
+	var refs []string
+	if err := json.Unmarshal(raw, &refs); err != nil {
+		return nil, errors.New("must be an array of category slugs")
+	}
+	if len(refs) > maxVendorCategories {
+		return nil, fmt.Errorf("at most %d categories", maxVendorCategories)
+	}
+	return refs, nil
+}
+
+// vendorCategorySlugs selects the slugs of vendor vp's categories, its main
+// one first.
+const vendorCategorySlugs = `ARRAY(
+	SELECT c.slug FROM vendor_categories vc JOIN categories c ON c.id = vc.category_id
+	WHERE vc.vendor_id = vp.id
+	ORDER BY c.id IS NOT DISTINCT FROM vp.category_id DESC, c.sort_order, c.slug)`
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withDeadline derives the context for one operation from the request, so a
+// client disconnect or an expired deadline cancels the query or upload and
+// releases its pool connection. A zero d means no deadline of its own.
+func withDeadline(c *gin.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(c.Request.Context())
+	}
+	return context.WithTimeout(c.Request.Context(), d)
+}
+
+// timedOut answers 504 when err is an expired deadline and 503 when the
+// request was cancelled, and reports whether it wrote a response.
+func timedOut(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": "Request timed out"})
+	case errors.Is(err, context.Canceled):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Request cancelled"})
+	default:
+		return false
+	}
+	return true
+}
+
+// fail writes an error response for a failed operation. Timeouts and
+// cancellations take precedence over status so that a slow query is not
+// reported as, say, "not found".
+func fail(c *gin.Context, err error, status int, message string) {
+	if timedOut(c, err) {
+		return
+	}
+	c.JSON(status, gin.H{"error": message})
+}
@@ -1,30 +1,98 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
 	"time"
 
+	"github.com/bventy/backend/internal/config"
 	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/outbox"
+	"github.com/bventy/backend/internal/trash"
 	"github.com/gin-gonic/gin"
 	pgx "github.com/jackc/pgx/v5"
 )
 
-type EventHandler struct{}
+type EventHandler struct {
+	Config *config.Config
+}
 
-func NewEventHandler() *EventHandler {
-	return &EventHandler{}
+func NewEventHandler(cfg *config.Config) *EventHandler {
+	return &EventHandler{Config: cfg}
 }
 
 type CreateEventRequest struct {
-	Title            string  `json:"title" binding:"required"`
-	City             string  `json:"city" binding:"required"`
-	EventType        string  `json:"event_type"`
-	Date             string  `json:"event_date" binding:"required"` // ISO string
-	BudgetMin        *int    `json:"budget_min"`
-	BudgetMax        *int    `json:"budget_max"`
-	OrganizerGroupID *string `json:"organizer_group_id"` // Optional
-	CoverImageURL    *string `json:"cover_image_url"`    // Optional
+	Title            string   `json:"title" binding:"required"`
+	City             string   `json:"city" binding:"required"`
+	EventType        string   `json:"event_type"`
+	Date             string   `json:"event_date" binding:"required"` // ISO string
+	BudgetMin        *int     `json:"budget_min"`
+	BudgetMax        *int     `json:"budget_max"`
+	OrganizerGroupID *string  `json:"organizer_group_id"` // Optional
+	CoverImageURL    *string  `json:"cover_image_url"`    // Optional
+	Latitude         *float64 `json:"latitude" binding:"omitempty,min=-90,max=90" doc:"Exact venue location; set both or neither"`
+	Longitude        *float64 `json:"longitude" binding:"omitempty,min=-180,max=180"`
+}
+
+type CreateEventResponse struct {
+	Message string `json:"message"`
+	EventID string `json:"event_id"`
+}
+
+type EventSummary struct {
+	ID            string  `json:"id"`
+	Title         string  `json:"title"`
+	City          string  `json:"city"`
+	Date          string  `json:"date" deprecated:"true" doc:"Same as event_date; kept for older clients."`
+	EventDate     string  `json:"event_date" doc:"YYYY-MM-DD"`
+	EventType     string  `json:"event_type"`
+	BudgetMin     *int    `json:"budget_min"`
+	BudgetMax     *int    `json:"budget_max"`
+	CoverImageURL *string `json:"cover_image_url"`
+}
+
+// EventPage is one page of GET /events, latest event date first.
+type EventPage struct {
+	Events     []EventSummary `json:"events"`
+	NextCursor *string        `json:"next_cursor"`
+}
+
+type EventVendor struct {
+	ID           string `json:"id"`
+	BusinessName string `json:"business_name"`
+	Slug         string `json:"slug"`
+	Category     string `json:"category"`
+	City         string `json:"city"`
+	Unavailable  bool   `json:"unavailable" doc:"Blocked or fully booked on the event date"`
+}
+
+type EventDetail struct {
+	ID               string        `json:"id"`
+	Title            string        `json:"title"`
+	City             string        `json:"city"`
+	EventDate        string        `json:"event_date" doc:"YYYY-MM-DD"`
+	EventType        string        `json:"event_type"`
+	BudgetMin        *int          `json:"budget_min"`
+	BudgetMax        *int          `json:"budget_max"`
+	CoverImageURL    *string       `json:"cover_image_url"`
+	Latitude         *float64      `json:"latitude"`
+	Longitude        *float64      `json:"longitude"`
+	OrganizerUserID  *string       `json:"organizer_user_id"`
+	OrganizerGroupID *string       `json:"organizer_group_id"`
+	Shortlist        []EventVendor `json:"shortlist"`
+}
+
+type ShortlistedVendor struct {
+	ID            string    `json:"id"`
+	BusinessName  string    `json:"business_name"`
+	Category      string    `json:"category"`
+	ShortlistedAt time.Time `json:"shortlisted_at"`
+}
+
+// ShortlistPage is one page of GET /events/:id/shortlist, in the order the
+// vendors were shortlisted.
+type ShortlistPage struct {
+	Vendors    []ShortlistedVendor `json:"vendors"`
+	NextCursor *string             `json:"next_cursor"`
 }
 
 func (h *EventHandler) CreateEvent(c *gin.Context) {
@@ -49,6 +117,20 @@ func (h *EventHandler) CreateEvent(c *gin.Context) {
 			return
 		}
 	}
+	if !validCoordinates(req.Latitude, req.Longitude) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "latitude and longitude must be set together"})
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	city, locationID, err := resolveCity(ctx, req.City)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to create event")
+		return
+	}
+	req.City = city
 
 	var organizerUserID interface{} = userID
 	var organizerGroupID interface{} = nil
@@ -59,62 +141,106 @@ func (h *EventHandler) CreateEvent(c *gin.Context) {
 		organizerGroupID = *req.OrganizerGroupID
 
 		var isMember int
-		queryCheck := `SELECT 1 FROM group_members WHERE group_id=$1 AND user_id=$2`
-		err := db.Pool.QueryRow(context.Background(), queryCheck, organizerGroupID, userID).Scan(&isMember)
+		queryCheck := `
+			SELECT 1 FROM group_members gm JOIN groups g ON g.id = gm.group_id
+			WHERE gm.group_id=$1 AND gm.user_id=$2 AND g.deleted_at IS NULL
+		`
+		err := db.Pool.QueryRow(ctx, queryCheck, organizerGroupID, userID).Scan(&isMember)
 
 		if err == pgx.ErrNoRows {
 			c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this group"})
 			return
 		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error checking membership"})
+			fail(c, err, http.StatusInternalServerError, "Database error checking membership")
 			return
 		}
 	}
 
 	// Updated query to include cover_image_url and CORRECT column name event_date
 	query := `
-		INSERT INTO events (title, city, event_type, event_date, budget_min, budget_max, organizer_user_id, organizer_group_id, cover_image_url)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO events (title, city, event_type, event_date, budget_min, budget_max, organizer_user_id, organizer_group_id, cover_image_url,
+			location_id, latitude, longitude)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id
 	`
 
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
 	var eventID string
-	err = db.Pool.QueryRow(context.Background(), query,
+	err = tx.QueryRow(ctx, query,
 		req.Title, req.City, req.EventType, eventDate, req.BudgetMin, req.BudgetMax, organizerUserID, organizerGroupID, req.CoverImageURL,
+		locationID, req.Latitude, req.Longitude,
 	).Scan(&eventID)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create event: " + err.Error()})
+		fail(c, err, http.StatusInternalServerError, "Failed to create event: "+err.Error())
+		return
+	}
+
+	created := outbox.EventCreated{
+		EventID: eventID, Title: req.Title, City: req.City, EventType: req.EventType, EventDate: eventDate,
+		OrganizerGroupID: req.OrganizerGroupID, CreatedBy: userID.(string),
+	}
+	if req.OrganizerGroupID == nil {
+		created.OrganizerUserID = &created.CreatedBy
+	}
+	if err := outbox.Publish(ctx, tx, created); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to create event")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Event created successfully", "event_id": eventID})
+	c.JSON(http.StatusCreated, CreateEventResponse{Message: "Event created successfully", EventID: eventID})
 }
 
+var eventOrder = keyset{{expr: "e.event_date", typ: "timestamp", desc: true}, {expr: "e.id", typ: "uuid", desc: true}}
+
 func (h *EventHandler) ListMyEvents(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
+	p, ok := parsePage(c, eventOrder)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
 
 	// Logic: Events where I am the organizer_user_id OR organizer_group_id matches a group I am a member of.
 	query := `
 		SELECT e.id, e.title, e.city, e.event_date, e.event_type, e.budget_min, e.budget_max, e.cover_image_url
 		FROM events e
 		LEFT JOIN group_members gm ON e.organizer_group_id = gm.group_id AND gm.user_id = $1
-		WHERE e.organizer_user_id = $1 OR gm.user_id IS NOT NULL
+		WHERE (e.organizer_user_id = $1 OR gm.user_id IS NOT NULL) AND e.deleted_at IS NULL
 	`
+	query, args := p.query(query, eventOrder, []any{userID})
 
-	rows, err := db.Pool.Query(context.Background(), query, userID)
+	rows, err := db.Pool.Query(ctx, query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch events")
 		return
 	}
 	defer rows.Close()
 
-	var events []gin.H
+	// The date is kept next to each summary, which only has the day, for
+	// the cursor.
+	type row struct {
+		event EventSummary
+		date  time.Time
+	}
+	found := []row{}
 	for rows.Next() {
 		var id, title, city, eventType string
 		var date time.Time
@@ -124,39 +250,55 @@ func (h *EventHandler) ListMyEvents(c *gin.Context) {
 		if err := rows.Scan(&id, &title, &city, &date, &eventType, &budgetMin, &budgetMax, &coverImageURL); err != nil {
 			continue
 		}
-		events = append(events, gin.H{
-			"id":              id,
-			"title":           title,
-			"city":            city,
-			"date":            date.Format("2006-01-02"), // Frontend expects 'date' or mapping? Frontend likely expects 'event_date' or 'date'. I'll keep date for now but logic below might need update.
-			"event_date":      date.Format("2006-01-02"), // duplicated for safety
-			"event_type":      eventType,
-			"budget_min":      budgetMin,
-			"budget_max":      budgetMax,
-			"cover_image_url": coverImageURL,
-		})
+		found = append(found, row{date: date, event: EventSummary{
+			ID:            id,
+			Title:         title,
+			City:          city,
+			Date:          date.Format("2006-01-02"),
+			EventDate:     date.Format("2006-01-02"),
+			EventType:     eventType,
+			BudgetMin:     budgetMin,
+			BudgetMax:     budgetMax,
+			CoverImageURL: coverImageURL,
+		}})
+	}
+	if err := rows.Err(); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch events")
+		return
 	}
 
-	c.JSON(http.StatusOK, events)
+	found, next := trim(p, found, func(r row) []string { return []string{cursorTime(r.date), r.event.ID} })
+	events := make([]EventSummary, len(found))
+	for i, r := range found {
+		events[i] = r.event
+	}
+	if p.all() {
+		c.JSON(http.StatusOK, events)
+		return
+	}
+	c.JSON(http.StatusOK, EventPage{Events: events, NextCursor: next})
 }
 
 func (h *EventHandler) GetEventById(c *gin.Context) {
 	eventID := c.Param("id")
 
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
+
 	query := `
-		SELECT id, title, city, event_date, event_type, budget_min, budget_max, cover_image_url, organizer_user_id, organizer_group_id
+		SELECT id, title, city, event_date, event_type, budget_min, budget_max, cover_image_url, latitude, longitude, organizer_user_id, organizer_group_id
 		FROM events
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	var event gin.H
 	var id, title, city, eventType string
 	var date time.Time
 	var budgetMin, budgetMax *int
 	var coverImageURL, organizerUserID, organizerGroupID *string
+	var latitude, longitude *float64
 
-	err := db.Pool.QueryRow(context.Background(), query, eventID).Scan(
-		&id, &title, &city, &date, &eventType, &budgetMin, &budgetMax, &coverImageURL, &organizerUserID, &organizerGroupID,
+	err := db.Pool.QueryRow(ctx, query, eventID).Scan(
+		&id, &title, &city, &date, &eventType, &budgetMin, &budgetMax, &coverImageURL, &latitude, &longitude, &organizerUserID, &organizerGroupID,
 	)
 
 	if err == pgx.ErrNoRows {
@@ -164,31 +306,25 @@ func (h *EventHandler) GetEventById(c *gin.Context) {
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		fail(c, err, http.StatusInternalServerError, "Database error")
 		return
 	}
 
 	// Fetch shortlisted vendors
 	shortlistQuery := `
-		SELECT v.id, v.business_name, v.slug, v.category, v.city
+		SELECT v.id, v.business_name, v.slug, v.category, v.city, ` + vendorBusy("v", "$2") + `
 		FROM event_shortlisted_vendors esv
 		JOIN vendor_profiles v ON esv.vendor_id = v.id
-		WHERE esv.event_id = $1
+		WHERE esv.event_id = $1 AND v.deleted_at IS NULL
 	`
-	rows, err := db.Pool.Query(context.Background(), shortlistQuery, eventID)
-	var shortlist []gin.H = []gin.H{} // Initialize as empty slice
+	rows, err := db.Pool.Query(ctx, shortlistQuery, eventID, date)
+	shortlist := []EventVendor{}
 	if err == nil {
 		defer rows.Close()
 		for rows.Next() {
-			var vid, vname, vslug, vcat, vcity string
-			if err := rows.Scan(&vid, &vname, &vslug, &vcat, &vcity); err == nil {
-				shortlist = append(shortlist, gin.H{
-					"id":            vid,
-					"business_name": vname,
-					"slug":          vslug,
-					"category":      vcat,
-					"city":          vcity,
-				})
+			var v EventVendor
+			if err := rows.Scan(&v.ID, &v.BusinessName, &v.Slug, &v.Category, &v.City, &v.Unavailable); err == nil {
+				shortlist = append(shortlist, v)
 			}
 		}
 	} else {
@@ -196,22 +332,26 @@ func (h *EventHandler) GetEventById(c *gin.Context) {
 		// For now, simple logging or ignoring if table empty is fine, but Query shouldn't fail unless DB issue.
 		// If DB issue, maybe we should return 500. But let's proceed with empty list to show event at least.
 	}
-
-	event = gin.H{
-		"id":                 id,
-		"title":              title,
-		"city":               city,
-		"event_date":         date.Format("2006-01-02"),
-		"event_type":         eventType,
-		"budget_min":         budgetMin,
-		"budget_max":         budgetMax,
-		"cover_image_url":    coverImageURL,
-		"organizer_user_id":  organizerUserID,
-		"organizer_group_id": organizerGroupID,
-		"shortlist":          shortlist,
+	// ...unless we ran out of time, in which case the list is incomplete.
+	if timedOut(c, ctx.Err()) {
+		return
 	}
 
-	c.JSON(http.StatusOK, event)
+	c.JSON(http.StatusOK, EventDetail{
+		ID:               id,
+		Title:            title,
+		City:             city,
+		EventDate:        date.Format("2006-01-02"),
+		EventType:        eventType,
+		BudgetMin:        budgetMin,
+		BudgetMax:        budgetMax,
+		CoverImageURL:    coverImageURL,
+		Latitude:         latitude,
+		Longitude:        longitude,
+		OrganizerUserID:  organizerUserID,
+		OrganizerGroupID: organizerGroupID,
+		Shortlist:        shortlist,
+	})
 }
 
 func (h *EventHandler) ShortlistVendor(c *gin.Context) {
@@ -225,41 +365,145 @@ func (h *EventHandler) ShortlistVendor(c *gin.Context) {
 	// Simply insert safely (ON CONFLICT DO NOTHING) would be nice, but table doesn't have unique constraint explicitly named in migration, but PK is (event_id, vendor_id).
 	// So plain insert fails on duplicate.
 
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var live bool
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM events WHERE id = $1 AND deleted_at IS NULL)
+		   AND EXISTS (SELECT 1 FROM vendor_profiles WHERE id = $2 AND deleted_at IS NULL)
+	`, eventID, vendorID).Scan(&live)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to shortlist vendor")
+		return
+	}
+	if !live {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event or vendor not found"})
+		return
+	}
+
 	query := `INSERT INTO event_shortlisted_vendors (event_id, vendor_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
-	_, err := db.Pool.Exec(context.Background(), query, eventID, vendorID)
+	tag, err := tx.Exec(ctx, query, eventID, vendorID)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to shortlist vendor"})
+		fail(c, err, http.StatusInternalServerError, "Failed to shortlist vendor")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Vendor shortlisted"})
+	// Re-shortlisting is a no-op and publishes nothing
+	if tag.RowsAffected() > 0 {
+		err = outbox.Publish(ctx, tx, outbox.VendorShortlisted{
+			EventID: eventID, VendorID: vendorID, ShortlistedBy: c.GetString("userID"),
+		})
+		if err != nil {
+			fail(c, err, http.StatusInternalServerError, "Failed to shortlist vendor")
+			return
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Vendor shortlisted"})
 }
 
+var shortlistOrder = keyset{{expr: "esv.created_at", typ: "timestamp"}, {expr: "esv.vendor_id", typ: "uuid"}}
+
 func (h *EventHandler) GetShortlistedVendors(c *gin.Context) {
 	eventID := c.Param("id")
+	p, ok := parsePage(c, shortlistOrder)
+	if !ok {
+		return
+	}
 
-	query := `
-		SELECT v.id, v.business_name, v.category 
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
+
+	// A deleted event keeps its shortlist rows for a restore, but they are
+	// not served in the meantime.
+	var live bool
+	err := db.Pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM events WHERE id = $1 AND deleted_at IS NULL)", eventID).Scan(&live)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch shortlisted vendors")
+		return
+	}
+	if !live {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	query, args := p.query(`
+		SELECT v.id, v.business_name, v.category, esv.created_at
 		FROM event_shortlisted_vendors esv
 		JOIN vendor_profiles v ON esv.vendor_id = v.id
-		WHERE esv.event_id = $1
-	`
-	rows, err := db.Pool.Query(context.Background(), query, eventID)
+		WHERE esv.event_id = $1 AND v.deleted_at IS NULL
+	`, shortlistOrder, []any{eventID})
+	rows, err := db.Pool.Query(ctx, query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shortlisted vendors"})
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch shortlisted vendors")
 		return
 	}
 	defer rows.Close()
 
-	var vendors []gin.H
+	vendors := []ShortlistedVendor{}
 	for rows.Next() {
-		var id, name, cat string
-		if err := rows.Scan(&id, &name, &cat); err != nil {
+		var v ShortlistedVendor
+		if err := rows.Scan(&v.ID, &v.BusinessName, &v.Category, &v.ShortlistedAt); err != nil {
 			continue
 		}
-		vendors = append(vendors, gin.H{"id": id, "business_name": name, "category": cat})
+		vendors = append(vendors, v)
+	}
+	if err := rows.Err(); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch shortlisted vendors")
+		return
+	}
+
+	vendors, next := trim(p, vendors, func(v ShortlistedVendor) []string { return []string{cursorTime(v.ShortlistedAt), v.ID} })
+	if p.all() {
+		c.JSON(http.StatusOK, vendors)
+		return
+	}
+	c.JSON(http.StatusOK, ShortlistPage{Vendors: vendors, NextCursor: next})
+}
+
+// DeleteEvent deletes an event. Its organiser may do it, or for a group's
+// event the group's owner or a manager.
+func (h *EventHandler) DeleteEvent(c *gin.Context) {
+	eventID := c.Param("id")
+	userID := c.GetString("userID")
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	var allowed bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COALESCE(e.organizer_user_id = $2, false) OR EXISTS (
+			SELECT 1 FROM group_members gm
+			WHERE gm.group_id = e.organizer_group_id AND gm.user_id = $2 AND gm.role IN ('owner', 'manager')
+		)
+		FROM events e
+		WHERE e.id = $1 AND e.deleted_at IS NULL
+	`, eventID, userID).Scan(&allowed)
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Event not found")
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the event's organiser can delete it"})
+		return
+	}
+
+	if !softDelete(c, ctx, trash.Events, eventID) {
+		return
 	}
 
-	c.JSON(http.StatusOK, vendors)
+	c.JSON(http.StatusOK, MessageResponse{Message: "Event deleted"})
 }
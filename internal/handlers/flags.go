@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+
+	"github.com/bventy/backend/internal/config"
+	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/flags"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type FlagHandler struct {
+	Config *config.Config
+	Flags  *flags.Store
+}
+
+func NewFlagHandler(cfg *config.Config, store *flags.Store) *FlagHandler {
+	return &FlagHandler{Config: cfg, Flags: store}
+}
+
+type PutFlagRequest struct {
+	Description    string   `json:"description"`
+	Enabled        bool     `json:"enabled"`
+	Roles          []string `json:"roles" doc:"user, staff, admin or super_admin"`
+	UserIDs        []string `json:"user_ids"`
+	RolloutPercent int      `json:"rollout_percent" doc:"0-100; 100 turns the flag on for everyone, including anonymous requests"`
+}
+
+type PatchFlagRequest struct {
+	Description    *string  `json:"description"`
+	Enabled        *bool    `json:"enabled"`
+	Roles          []string `json:"roles" doc:"Replaces the list when present"`
+	UserIDs        []string `json:"user_ids" doc:"Replaces the list when present"`
+	RolloutPercent *int     `json:"rollout_percent"`
+}
+
+var flagKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+var knownRoles = []string{"user", "staff", "admin", "super_admin"}
+
+// validateTargeting checks the parts of a flag that Postgres would otherwise
+// reject with an unhelpful error, or accept silently.
+func validateTargeting(roles, userIDs []string, rollout *int) string {
+	for _, r := range roles {
+		if !slices.Contains(knownRoles, r) {
+			return "Unknown role: " + r
+		}
+	}
+	for _, id := range userIDs {
+		if _, err := uuid.Parse(id); err != nil {
+			return "Invalid user id: " + id
+		}
+	}
+	if rollout != nil && (*rollout < 0 || *rollout > 100) {
+		return "rollout_percent must be between 0 and 100"
+	}
+	return ""
+}
+
+const flagColumns = `key, description, enabled, roles, user_ids::text[], rollout_percent, updated_at`
+
+func scanFlag(row pgx.Row) (flags.Flag, error) {
+	var f flags.Flag
+	err := row.Scan(&f.Key, &f.Description, &f.Enabled, &f.Roles, &f.UserIDs, &f.RolloutPercent, &f.UpdatedAt)
+	return f, err
+}
+
+// GetMyFlags evaluates every flag for the caller, so clients can hide
+// unfinished UI the same way the API hides unfinished routes.
+func (h *FlagHandler) GetMyFlags(c *gin.Context) {
+	subject := flags.Subject{UserID: c.GetString("userID"), Role: c.GetString("role")}
+	c.JSON(http.StatusOK, h.Flags.Evaluate(c.Request.Context(), subject))
+}
+
+func (h *FlagHandler) ListFlags(c *gin.Context) {
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
+
+	list, err := flags.FromPool(db.Pool)(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch flags")
+		return
+	}
+	if list == nil {
+		list = []flags.Flag{}
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// PutFlag creates or replaces a flag.
+func (h *FlagHandler) PutFlag(c *gin.Context) {
+	key := c.Param("key")
+	if !flagKeyPattern.MatchString(key) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Flag keys are lowercase letters, digits, '.', '_' and '-'"})
+		return
+	}
+	var req PutFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := validateTargeting(req.Roles, req.UserIDs, &req.RolloutPercent); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if req.Roles == nil {
+		req.Roles = []string{}
+	}
+	if req.UserIDs == nil {
+		req.UserIDs = []string{}
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	f, err := scanFlag(db.Pool.QueryRow(ctx, `
+		INSERT INTO feature_flags (key, description, enabled, roles, user_ids, rollout_percent)
+		VALUES ($1, $2, $3, $4, $5::uuid[], $6)
+		ON CONFLICT (key) DO UPDATE SET
+			description = EXCLUDED.description, enabled = EXCLUDED.enabled, roles = EXCLUDED.roles,
+			user_ids = EXCLUDED.user_ids, rollout_percent = EXCLUDED.rollout_percent, updated_at = now()
+		RETURNING `+flagColumns,
+		key, req.Description, req.Enabled, req.Roles, req.UserIDs, req.RolloutPercent))
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to save flag")
+		return
+	}
+	h.Flags.Invalidate()
+
+	c.JSON(http.StatusOK, f)
+}
+
+// PatchFlag changes only the given fields, e.g. {"enabled": false} to switch
+// a flag off everywhere.
+func (h *FlagHandler) PatchFlag(c *gin.Context) {
+	var req PatchFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := validateTargeting(req.Roles, req.UserIDs, req.RolloutPercent); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	f, err := scanFlag(db.Pool.QueryRow(ctx, `
+		UPDATE feature_flags SET
+			description = COALESCE($2, description),
+			enabled = COALESCE($3, enabled),
+			roles = COALESCE($4, roles),
+			user_ids = COALESCE($5::uuid[], user_ids),
+			rollout_percent = COALESCE($6, rollout_percent),
+			updated_at = now()
+		WHERE key = $1
+		RETURNING `+flagColumns,
+		c.Param("key"), req.Description, req.Enabled, req.Roles, req.UserIDs, req.RolloutPercent))
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Flag not found")
+		return
+	}
+	h.Flags.Invalidate()
+
+	c.JSON(http.StatusOK, f)
+}
+
+func (h *FlagHandler) DeleteFlag(c *gin.Context) {
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM feature_flags WHERE key = $1`, c.Param("key"))
+	if err != nil || tag.RowsAffected() == 0 {
+		fail(c, err, http.StatusNotFound, "Flag not found")
+		return
+	}
+	h.Flags.Invalidate()
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Flag deleted"})
+}
@@ -1,18 +1,22 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/bventy/backend/internal/config"
 	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/outbox"
+	"github.com/bventy/backend/internal/trash"
 )
 
-type GroupHandler struct{}
+type GroupHandler struct {
+	Config *config.Config
+}
 
-func NewGroupHandler() *GroupHandler {
-	return &GroupHandler{}
+func NewGroupHandler(cfg *config.Config) *GroupHandler {
+	return &GroupHandler{Config: cfg}
 }
 
 type CreateGroupRequest struct {
@@ -21,6 +25,20 @@ type CreateGroupRequest struct {
 	Description string `json:"description"`
 }
 
+type CreateGroupResponse struct {
+	Message string `json:"message"`
+	GroupID string `json:"group_id"`
+	Slug    string `json:"slug"`
+}
+
+type GroupSummary struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	City string `json:"city"`
+	Role string `json:"role"`
+}
+
 func (h *GroupHandler) CreateGroup(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -34,29 +52,38 @@ func (h *GroupHandler) CreateGroup(c *gin.Context) {
 		return
 	}
 
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	city, locationID, err := resolveCity(ctx, req.City)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to create group")
+		return
+	}
+	req.City = city
 	slug := generateSlug(req.Name, req.City)
 
 	// Transaction to create group AND add owner as member
-	tx, err := db.Pool.Begin(context.Background())
+	tx, err := db.Pool.Begin(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
 		return
 	}
-	defer tx.Rollback(context.Background())
+	defer tx.Rollback(ctx)
 
 	var groupID string
 	queryGroup := `
-		INSERT INTO groups (name, slug, city, description, owner_user_id)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO groups (name, slug, city, description, owner_user_id, location_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id
 	`
-	err = tx.QueryRow(context.Background(), queryGroup, req.Name, slug, req.City, req.Description, userID).Scan(&groupID)
+	err = tx.QueryRow(ctx, queryGroup, req.Name, slug, req.City, req.Description, userID, locationID).Scan(&groupID)
 	if err != nil {
 		if strings.Contains(err.Error(), "unique constraint") {
 			c.JSON(http.StatusConflict, gin.H{"error": "Group name/slug unavailable"})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create group"})
+		fail(c, err, http.StatusInternalServerError, "Failed to create group")
 		return
 	}
 
@@ -65,54 +92,107 @@ func (h *GroupHandler) CreateGroup(c *gin.Context) {
 		INSERT INTO group_members (group_id, user_id, role)
 		VALUES ($1, $2, 'owner')
 	`
-	_, err = tx.Exec(context.Background(), queryMember, groupID, userID)
+	_, err = tx.Exec(ctx, queryMember, groupID, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add owner member"})
+		fail(c, err, http.StatusInternalServerError, "Failed to add owner member")
 		return
 	}
 
-	if err := tx.Commit(context.Background()); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+	err = outbox.Publish(ctx, tx, outbox.GroupCreated{
+		GroupID: groupID, Name: req.Name, Slug: slug, City: req.City, OwnerUserID: userID.(string),
+	})
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to create group")
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Group created successfully", "group_id": groupID, "slug": slug})
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateGroupResponse{Message: "Group created successfully", GroupID: groupID, Slug: slug})
 }
 
+// GroupPage is one page of GET /groups/my, by name.
+type GroupPage struct {
+	Groups     []GroupSummary `json:"groups"`
+	NextCursor *string        `json:"next_cursor"`
+}
+
+var groupOrder = keyset{{expr: "g.name", typ: "text"}, {expr: "g.id", typ: "uuid"}}
+
 func (h *GroupHandler) ListMyGroups(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
+	p, ok := parsePage(c, groupOrder)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
 
 	query := `
 		SELECT g.id, g.name, g.slug, g.city, gm.role
 		FROM groups g
 		JOIN group_members gm ON g.id = gm.group_id
-		WHERE gm.user_id = $1
+		WHERE gm.user_id = $1 AND g.deleted_at IS NULL
 	`
-	rows, err := db.Pool.Query(context.Background(), query, userID)
+	query, args := p.query(query, groupOrder, []any{userID})
+	rows, err := db.Pool.Query(ctx, query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch groups"})
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch groups")
 		return
 	}
 	defer rows.Close()
 
-	var groups []gin.H
+	groups := []GroupSummary{}
 	for rows.Next() {
-		var id, name, slug, city, role string
-		if err := rows.Scan(&id, &name, &slug, &city, &role); err != nil {
+		var g GroupSummary
+		if err := rows.Scan(&g.ID, &g.Name, &g.Slug, &g.City, &g.Role); err != nil {
 			continue
 		}
-		groups = append(groups, gin.H{
-			"id":   id,
-			"name": name,
-			"slug": slug,
-			"city": city,
-			"role": role,
-		})
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch groups")
+		return
+	}
+
+	groups, next := trim(p, groups, func(g GroupSummary) []string { return []string{g.Name, g.ID} })
+	if p.all() {
+		c.JSON(http.StatusOK, groups)
+		return
+	}
+	c.JSON(http.StatusOK, GroupPage{Groups: groups, NextCursor: next})
+}
+
+// DeleteGroup deletes a group and its events. Only the owner may do it.
+func (h *GroupHandler) DeleteGroup(c *gin.Context) {
+	groupID := c.Param("id")
+	userID := c.GetString("userID")
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	var ownerID string
+	err := db.Pool.QueryRow(ctx, "SELECT owner_user_id FROM groups WHERE id = $1 AND deleted_at IS NULL", groupID).Scan(&ownerID)
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Group not found")
+		return
+	}
+	if ownerID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the group owner can delete it"})
+		return
+	}
+
+	if !softDelete(c, ctx, trash.Groups, groupID) {
+		return
 	}
 
-	c.JSON(http.StatusOK, groups)
+	c.JSON(http.StatusOK, MessageResponse{Message: "Group deleted"})
 }
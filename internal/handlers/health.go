@@ -1,27 +1,40 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/bventy/backend/internal/db"
 )
 
+type HealthResponse struct {
+	Status   string `json:"status"`
+	Message  string `json:"message"`
+	Database string `json:"database,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// healthCheckTimeout is short so probes fail fast when the pool is saturated.
+const healthCheckTimeout = 2 * time.Second
+
 func HealthCheck(c *gin.Context) {
-	err := db.Pool.Ping(context.Background())
+	ctx, cancel := withDeadline(c, healthCheckTimeout)
+	defer cancel()
+
+	err := db.Pool.Ping(ctx)
 	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"status":  "error",
-			"message": "Database connection failed",
-			"error":   err.Error(),
+		c.JSON(http.StatusServiceUnavailable, HealthResponse{
+			Status:  "error",
+			Message: "Database connection failed",
+			Error:   err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "ok",
-		"message": "bventy backend operational",
-		"database": "connected",
+	c.JSON(http.StatusOK, HealthResponse{
+		Status:   "ok",
+		Message:  "bventy backend operational",
+		Database: "connected",
 	})
 }
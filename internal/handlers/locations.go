@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bventy/backend/internal/config"
+	"github.com/bventy/backend/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type LocationHandler struct {
+	Config *config.Config
+}
+
+func NewLocationHandler(cfg *config.Config) *LocationHandler {
+	return &LocationHandler{Config: cfg}
+}
+
+// Location is a city on the reference list that typed cities are matched
+// against.
+type Location struct {
+	ID        string   `json:"id"`
+	City      string   `json:"city"`
+	State     string   `json:"state"`
+	Aliases   []string `json:"aliases" doc:"Other spellings that resolve to this city, lowercase"`
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+}
+
+// ListLocations lists the reference cities by name, for city pickers. q keeps
+// those whose name or an alias starts with it.
+func (h *LocationHandler) ListLocations(c *gin.Context) {
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
+
+	q := strings.ToLower(strings.TrimSpace(c.Query("q")))
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, city, state, aliases, latitude, longitude FROM locations
+		WHERE $1 = '' OR starts_with(lower(city), $1) OR EXISTS (SELECT 1 FROM unnest(aliases) a WHERE starts_with(a, $1))
+		ORDER BY city, state`, q)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch locations")
+		return
+	}
+	locations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[Location])
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch locations")
+		return
+	}
+
+	c.JSON(http.StatusOK, locations)
+}
+
+// resolveCity matches city against the locations list by name or alias,
+// ignoring case. It returns the canonical name and the location's id, or the
+// trimmed input and nil for a city not on the list.
+func resolveCity(ctx context.Context, city string) (string, *string, error) {
+	city = strings.TrimSpace(city)
+	if city == "" {
+		return city, nil, nil
+	}
+	var name, id string
+	err := db.Pool.QueryRow(ctx, `
+		SELECT city, id FROM locations
+		WHERE lower(city) = lower($1) OR lower($1) = ANY (aliases)
+		ORDER BY lower(city) = lower($1) DESC, city, state
+		LIMIT 1`, city).Scan(&name, &id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return city, nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return name, &id, nil
+}
+
+// setPatchCity stores the city a merge patch names, if any, resolved through
+// resolveCity. The patch's city field must have no column.
+func setPatchCity(ctx context.Context, p *mergePatch) error {
+	v, ok := p.values["city"]
+	if !ok {
+		return nil
+	}
+	city, ok := v.(string)
+	if !ok {
+		// Cleared
+		p.set("city", nullValue)
+		p.set("location_id", nullValue)
+		return nil
+	}
+	name, locationID, err := resolveCity(ctx, city)
+	if err != nil {
+		return err
+	}
+	p.set("city", name)
+	p.set("location_id", locationID)
+	return nil
+}
+
+// coordinateField parses a latitude or longitude between min and max degrees.
+func coordinateField(min, max float64) func(json.RawMessage) (any, error) {
+	return func(raw json.RawMessage) (any, error) {
+		var f float64
+		if err := json.Unmarshal(raw, &f); err != nil || f < min || f > max {
+			return nil, fmt.Errorf("must be a number of degrees between %g and %g", min, max)
+		}
+		return f, nil
+	}
+}
+
+// validCoordinates reports whether latitude and longitude are both set or both
+// left out; their ranges are checked by binding.
+func validCoordinates(latitude, longitude *float64) bool {
+	return (latitude == nil) == (longitude == nil)
+}
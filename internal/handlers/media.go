@@ -11,20 +11,16 @@ import (
 )
 
 type MediaHandler struct {
-	Service *services.MediaService
+	Config  *config.Config
+	Service services.MediaStore
 }
 
-func NewMediaHandler(cfg *config.Config) *MediaHandler {
-	svc, err := services.NewMediaService(cfg)
-	if err != nil {
-		// Log error but don't panic? Or panic if media service is critical?
-		// For now, we'll just print/log it and maybe return nil, but `NewMediaService` error means config issue.
-		// Let's panic to fail fast if config is bad, or just log.
-		// Ideally routes.go handles the error. But here we are in NewMediaHandler.
-		// Let's assume valid config for MVP or panic.
-		panic("Failed to initialize MediaService: " + err.Error())
-	}
-	return &MediaHandler{Service: svc}
+func NewMediaHandler(cfg *config.Config, media services.MediaStore) *MediaHandler {
+	return &MediaHandler{Config: cfg, Service: media}
+}
+
+type MediaUploadResponse struct {
+	URL string `json:"url"`
 }
 
 func (h *MediaHandler) Upload(c *gin.Context) {
@@ -55,13 +51,13 @@ func (h *MediaHandler) Upload(c *gin.Context) {
 	}
 
 	// Upload
-	url, err := h.Service.UploadFile(file, header.Filename, header.Header.Get("Content-Type"), "uploads")
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Media)
+	defer cancel()
+	url, err := h.Service.UploadFile(ctx, file, header.Filename, header.Header.Get("Content-Type"), "uploads")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload file"})
+		fail(c, err, http.StatusInternalServerError, "Failed to upload file")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"url": url,
-	})
+	c.JSON(http.StatusOK, MediaUploadResponse{URL: url})
 }
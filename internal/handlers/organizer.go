@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -39,7 +38,7 @@ func (h *OrganizerHandler) OnboardOrganizer(c *gin.Context) {
 	`
 
 	var organizerID string
-	err := db.Pool.QueryRow(context.Background(), query, userID, req.DisplayName, req.City).Scan(&organizerID)
+	err := db.Pool.QueryRow(c.Request.Context(), query, userID, req.DisplayName, req.City).Scan(&organizerID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to onboard organizer: " + err.Error()})
 		return
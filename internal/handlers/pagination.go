@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bventy/backend/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Lists are paginated by keyset: ?limit= picks the page size and ?cursor=,
+// the next_cursor of the previous page, where it starts. A cursor holds the
+// sort key values of the last row it saw, so pages stay stable while rows are
+// added or removed.
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// sortKey is one ORDER BY term of a paginated list. expr must never be NULL.
+type sortKey struct {
+	expr string
+	// typ is the Postgres type of expr: text, uuid, timestamp, timestamptz,
+	// bigint or real. A timestamptz column must say so: cast to timestamp, the
+	// cursor's offset is dropped and the value read in the session time zone.
+	typ  string
+	desc bool
+}
+
+// keyset is the full ordering of a list. Its last key must be unique, so every
+// row has a position of its own.
+type keyset []sortKey
+
+func (k keyset) orderBy() string {
+	terms := make([]string, len(k))
+	for i, key := range k {
+		terms[i] = key.expr
+		if key.desc {
+			terms[i] += " DESC"
+		}
+	}
+	return strings.Join(terms, ", ")
+}
+
+// after returns the condition for rows that sort after the cursor values,
+// with placeholders numbered from first, and its arguments. The values are
+// sent as text and cast in SQL.
+func (k keyset) after(values []string, first int) (string, []any) {
+	var or []string
+	for i, key := range k {
+		var and []string
+		for j := range i {
+			and = append(and, fmt.Sprintf("%s = $%d::text::%s", k[j].expr, first+j, k[j].typ))
+		}
+		op := ">"
+		if key.desc {
+			op = "<"
+		}
+		and = append(and, fmt.Sprintf("%s %s $%d::text::%s", key.expr, op, first+i, key.typ))
+		or = append(or, "("+strings.Join(and, " AND ")+")")
+	}
+	args := make([]any, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return "(" + strings.Join(or, " OR ") + ")", args
+}
+
+// valid reports whether values can be cast to the keys' types, so a tampered
+// cursor gets a 400 rather than a database error.
+func (k keyset) valid(values []string) bool {
+	if len(values) != len(k) {
+		return false
+	}
+	for i, v := range values {
+		var err error
+		switch k[i].typ {
+		case "uuid":
+			_, err = uuid.Parse(v)
+		case "timestamp", "timestamptz":
+			_, err = time.Parse(time.RFC3339Nano, v)
+		case "bigint":
+			_, err = strconv.ParseInt(v, 10, 64)
+		case "real":
+			_, err = strconv.ParseFloat(v, 32)
+		}
+		if err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// page is a parsed ?limit=&cursor=.
+type page struct {
+	// limit is zero for legacy routes, which still get the whole list.
+	limit int
+	// after holds the sort key values of the previous page's last row, or
+	// nil on the first page.
+	after []string
+}
+
+// parsePage reads the page parameters of a list ordered by keys, answering
+// 400 and returning false when they are invalid. Deprecated routes ignore
+// them and keep returning every row, as the deployed web client expects.
+func parsePage(c *gin.Context, keys keyset) (page, bool) {
+	if middleware.IsDeprecated(c) {
+		return page{}, true
+	}
+
+	p := page{limit: defaultPageLimit}
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxPageLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxPageLimit)})
+			return p, false
+		}
+		p.limit = n
+	}
+	if s := c.Query("cursor"); s != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(s)
+		if err == nil {
+			err = json.Unmarshal(raw, &p.after)
+		}
+		if err != nil || !keys.valid(p.after) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return p, false
+		}
+	}
+	return p, true
+}
+
+// all reports whether the whole list is wanted, unpaginated.
+func (p page) all() bool {
+	return p.limit == 0
+}
+
+// query appends the cursor condition and ordering to a list query whose
+// WHERE clause uses args, returning the statement and all its arguments. One
+// row more than the page is fetched, to tell whether another page follows.
+func (p page) query(sql string, keys keyset, args []any) (string, []any) {
+	if p.after != nil {
+		cond, more := keys.after(p.after, len(args)+1)
+		sql += " AND " + cond
+		args = append(args, more...)
+	}
+	sql += " ORDER BY " + keys.orderBy()
+	if !p.all() {
+		sql += " LIMIT " + strconv.Itoa(p.limit+1)
+	}
+	return sql, args
+}
+
+// trim cuts rows, fetched through query, to the page and returns the cursor
+// for the next one, or nil after the last. key returns a row's sort key
+// values; see cursorTime and cursorReal.
+func trim[T any](p page, rows []T, key func(T) []string) ([]T, *string) {
+	if p.all() || len(rows) <= p.limit {
+		return rows, nil
+	}
+	rows = rows[:p.limit]
+	raw, _ := json.Marshal(key(rows[len(rows)-1]))
+	next := base64.RawURLEncoding.EncodeToString(raw)
+	return rows, &next
+}
+
+// cursorTime renders a timestamp sort key exactly.
+func cursorTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// cursorReal renders a real (float4) sort key exactly.
+func cursorReal(f float32) string {
+	return strconv.FormatFloat(float64(f), 'g', -1, 32)
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// patchField is one member an RFC 7396 merge patch may set.
+type patchField struct {
+	// column is the column the value is stored in. Fields without one are
+	// left to the handler, through mergePatch.values.
+	column string
+	// clear is stored when the patch sets the member to null. A nil clear
+	// means the member cannot be cleared.
+	clear any
+	// parse validates a non-null value and returns what to store.
+	parse func(raw json.RawMessage) (any, error)
+}
+
+// mergePatch is a parsed patch: SET assignments and their arguments, numbered
+// after the arguments the caller's WHERE clause uses, plus the values of
+// fields without a column, by name.
+type mergePatch struct {
+	sets   []string
+	args   []any
+	values map[string]any
+	first  int
+}
+
+// set adds an assignment, for handlers storing the values they resolved.
+func (p *mergePatch) set(column string, value any) {
+	p.args = append(p.args, value)
+	p.sets = append(p.sets, fmt.Sprintf("%s = $%d", column, p.first+len(p.args)-1))
+}
+
+// bindMergePatch reads an application/merge-patch+json (or application/json)
+// body. Absent members stay unchanged, null clears, anything else is parsed
+// by its field. It answers 400 or 415 and returns false on a bad patch.
+func bindMergePatch(c *gin.Context, fields map[string]patchField, firstArg int) (mergePatch, bool) {
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	if mediaType != "application/merge-patch+json" && mediaType != "application/json" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Use Content-Type application/merge-patch+json"})
+		return mergePatch{}, false
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.NewDecoder(c.Request.Body).Decode(&members); err != nil || members == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Body must be a JSON object"})
+		return mergePatch{}, false
+	}
+
+	// Sorted, so the same patch always builds the same statement
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	p := mergePatch{values: map[string]any{}, first: firstArg}
+	for _, name := range names {
+		field, ok := fields[name]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown field: " + name})
+			return mergePatch{}, false
+		}
+
+		var value any
+		if raw := members[name]; bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			if field.clear == nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": name + " cannot be cleared"})
+				return mergePatch{}, false
+			}
+			value = field.clear
+		} else {
+			v, err := field.parse(raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": name + ": " + err.Error()})
+				return mergePatch{}, false
+			}
+			value = v
+		}
+
+		if field.column == "" {
+			p.values[name] = value
+			continue
+		}
+		p.set(field.column, value)
+	}
+	return p, true
+}
+
+// nullValue is stored for a cleared nullable column; it is distinct from the
+// nil that marks a field as not clearable.
+var nullValue any = (*string)(nil)
+
+// textField parses a string of at most max characters, rejecting blank ones
+// when required.
+func textField(required bool, max int) func(json.RawMessage) (any, error) {
+	return func(raw json.RawMessage) (any, error) {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, errors.New("must be a string")
+		}
+		s = strings.TrimSpace(s)
+		if required && s == "" {
+			return nil, errors.New("must not be empty")
+		}
+		if len([]rune(s)) > max {
+			return nil, fmt.Errorf("must be at most %d characters", max)
+		}
+		return s, nil
+	}
+}
+
+// nullableText is textField for optional columns that hold NULL rather than
+// an empty string.
+func nullableText(max int) func(json.RawMessage) (any, error) {
+	parse := textField(false, max)
+	return func(raw json.RawMessage) (any, error) {
+		v, err := parse(raw)
+		if v == "" {
+			return nullValue, err
+		}
+		return v, err
+	}
+}
+
+// urlField parses an absolute http(s) URL.
+func urlField(raw json.RawMessage) (any, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, errors.New("must be a string")
+	}
+	if !isHTTPURL(s) {
+		return nil, errors.New("must be an http or https URL")
+	}
+	return s, nil
+}
+
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bventy/backend/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// versionETag is the strong ETag for a row version.
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// requireIfMatch answers 428 and returns false when an edit has no If-Match.
+// Deprecated routes may leave the header out until sunset, because the
+// deployed web client predates it; such writes are logged so the remaining
+// callers can be found. A legacy request that does send If-Match is checked
+// like any other.
+func requireIfMatch(c *gin.Context, sunset time.Time) bool {
+	if c.GetHeader("If-Match") != "" {
+		return true
+	}
+	if middleware.IsDeprecated(c) && (sunset.IsZero() || time.Now().Before(sunset)) {
+		log.Printf("⚠️  Precondition-less write %s %s by %s (%s); If-Match is required from %s",
+			c.Request.Method, c.FullPath(), c.ClientIP(), c.Request.UserAgent(), sunset.Format(time.DateOnly))
+		return true
+	}
+	c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header required; send the ETag from the last GET"})
+	return false
+}
+
+// ifMatches reports whether the request's If-Match, if any, names version. It
+// sets the current ETag and answers 412 when it does not, so the client can
+// reload and retry.
+func ifMatches(c *gin.Context, version int) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return true
+	}
+	current := versionETag(version)
+	for _, tag := range strings.Split(header, ",") {
+		// Strong comparison: weak tags never match.
+		if tag = strings.TrimSpace(tag); tag == "*" || tag == current {
+			return true
+		}
+	}
+	c.Header("ETag", current)
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Profile was changed by another request; reload it and try again"})
+	return false
+}
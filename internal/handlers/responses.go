@@ -0,0 +1,32 @@
+package handlers
+
+// Shared response bodies. Errors are always {"error": "..."}.
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// MissingDocumentsResponse is the 409 from approving a vendor whose required
+// verification documents have not all been accepted.
+type MissingDocumentsResponse struct {
+	Error   string   `json:"error"`
+	Missing []string `json:"missing" doc:"Required document kinds not accepted yet"`
+}
+
+// UploadResponse is returned by every endpoint that stores a file.
+type UploadResponse struct {
+	Message string `json:"message"`
+	URL     string `json:"url"`
+}
+
+// ImageUploadResponse is the 202 from an image upload. The original file is
+// stored and served until a background job replaces it with a WebP copy.
+type ImageUploadResponse struct {
+	Message string `json:"message"`
+	URL     string `json:"url" doc:"The original upload; replaced by the WebP URL once converted"`
+	Status  string `json:"status" doc:"Always pending"`
+}
@@ -0,0 +1,486 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bventy/backend/internal/config"
+	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/httpcache"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ReviewHandler serves vendor reviews: organisers write them, vendors reply
+// and admins moderate them.
+type ReviewHandler struct {
+	Config *config.Config
+	// VendorCache is invalidated when a change shows in a vendor's rating.
+	VendorCache *httpcache.Cache
+}
+
+func NewReviewHandler(cfg *config.Config, vendorCache *httpcache.Cache) *ReviewHandler {
+	return &ReviewHandler{Config: cfg, VendorCache: vendorCache}
+}
+
+type Review struct {
+	ID         string     `json:"id"`
+	VendorID   string     `json:"vendor_id"`
+	EventID    string     `json:"event_id"`
+	AuthorName string     `json:"author_name"`
+	Rating     int        `json:"rating"`
+	Body       string     `json:"body"`
+	EditedAt   *time.Time `json:"edited_at" doc:"Set once the review has had its one edit"`
+	Reply      *string    `json:"reply" doc:"The vendor's public reply"`
+	RepliedAt  *time.Time `json:"replied_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// AdminReview is a review with its moderation state.
+type AdminReview struct {
+	Review
+	AuthorUserID   string `json:"author_user_id"`
+	Hidden         bool   `json:"hidden"`
+	Flagged        bool   `json:"flagged"`
+	ModerationNote string `json:"moderation_note"`
+}
+
+type ReviewPage struct {
+	Reviews    []Review `json:"reviews"`
+	NextCursor *string  `json:"next_cursor"`
+}
+
+type AdminReviewPage struct {
+	Reviews    []AdminReview `json:"reviews"`
+	NextCursor *string       `json:"next_cursor"`
+}
+
+type CreateReviewRequest struct {
+	EventID string `json:"event_id" binding:"required,uuid" doc:"A past event of yours that shortlisted the vendor"`
+	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+	Body    string `json:"body" binding:"max=2000"`
+}
+
+// ReviewPatch documents the PATCH /reviews/:id body, read as a merge patch
+// through reviewPatchFields.
+type ReviewPatch struct {
+	Rating *int    `json:"rating" doc:"1-5"`
+	Body   *string `json:"body" doc:"null clears it"`
+}
+
+var reviewPatchFields = map[string]patchField{
+	"rating": {column: "rating", parse: ratingField},
+	"body":   {column: "body", clear: "", parse: textField(false, 2000)},
+}
+
+type ReviewReplyRequest struct {
+	Reply string `json:"reply" binding:"required,max=2000" doc:"Replaces any earlier reply"`
+}
+
+type ModerateReviewRequest struct {
+	Hidden  *bool   `json:"hidden" doc:"Hidden reviews leave listings and the vendor's rating"`
+	Flagged *bool   `json:"flagged" doc:"Marks the review for a second look"`
+	Note    *string `json:"moderation_note" binding:"omitempty,max=500" doc:"Seen by admins only"`
+}
+
+// vendorRating is the average rating of vendor vp to one decimal, or NULL
+// without reviews, and vendorReviewCount the number of its reviews. Hidden
+// reviews count for neither.
+const (
+	vendorRating      = `(SELECT round(avg(r.rating), 1)::float8 FROM vendor_reviews r WHERE r.vendor_id = vp.id AND NOT r.hidden)`
+	vendorReviewCount = `(SELECT count(*) FROM vendor_reviews r WHERE r.vendor_id = vp.id AND NOT r.hidden)`
+)
+
+func ratingField(raw json.RawMessage) (any, error) {
+	var n int16
+	if err := json.Unmarshal(raw, &n); err != nil || n < 1 || n > 5 {
+		return nil, errors.New("must be a whole number from 1 to 5")
+	}
+	return n, nil
+}
+
+// reviewColumns are read by scanReview, from vendor_reviews r joined to the
+// author's users row u.
+const reviewColumns = `r.id, r.vendor_id, r.event_id, u.full_name, r.rating, r.body, r.edited_at, r.reply, r.replied_at, r.created_at`
+
+const adminReviewColumns = reviewColumns + `, r.author_user_id, r.hidden, r.flagged, r.moderation_note`
+
+func scanReview(row pgx.Row) (Review, error) {
+	var r Review
+	err := row.Scan(&r.ID, &r.VendorID, &r.EventID, &r.AuthorName, &r.Rating, &r.Body, &r.EditedAt, &r.Reply, &r.RepliedAt, &r.CreatedAt)
+	return r, err
+}
+
+func scanAdminReview(row pgx.Row) (AdminReview, error) {
+	var r AdminReview
+	err := row.Scan(&r.ID, &r.VendorID, &r.EventID, &r.AuthorName, &r.Rating, &r.Body, &r.EditedAt, &r.Reply, &r.RepliedAt, &r.CreatedAt,
+		&r.AuthorUserID, &r.Hidden, &r.Flagged, &r.ModerationNote)
+	return r, err
+}
+
+// reviewOrder lists reviews newest first.
+var reviewOrder = keyset{{expr: "r.created_at", typ: "timestamptz", desc: true}, {expr: "r.id", typ: "uuid", desc: true}}
+
+// CreateReview reviews vendor :id for one of the caller's past events that
+// shortlisted it. The caller must have organised the event: created it, or
+// own or manage the group that did.
+func (h *ReviewHandler) CreateReview(c *gin.Context) {
+	vendorID := c.Param("id")
+	userID := c.GetString("userID")
+	if _, err := uuid.Parse(vendorID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vendor not found"})
+		return
+	}
+	var req CreateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	var ownVendor bool
+	err := db.Pool.QueryRow(ctx, `SELECT owner_user_id = $2 FROM vendor_profiles WHERE id = $1 AND deleted_at IS NULL`,
+		vendorID, userID).Scan(&ownVendor)
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Vendor not found")
+		return
+	}
+	if ownVendor {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot review your own vendor profile"})
+		return
+	}
+
+	var past, organiser, shortlisted bool
+	err = db.Pool.QueryRow(ctx, `
+		SELECT e.event_date < CURRENT_DATE,
+			COALESCE(e.organizer_user_id = $3, false) OR EXISTS (
+				SELECT 1 FROM group_members gm
+				WHERE gm.group_id = e.organizer_group_id AND gm.user_id = $3 AND gm.role IN ('owner', 'manager')
+			),
+			EXISTS (SELECT 1 FROM event_shortlisted_vendors esv WHERE esv.event_id = e.id AND esv.vendor_id = $2)
+		FROM events e
+		WHERE e.id = $1 AND e.deleted_at IS NULL
+	`, req.EventID, vendorID, userID).Scan(&past, &organiser, &shortlisted)
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Event not found")
+		return
+	}
+	switch {
+	case !organiser:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the event's organiser can review its vendors"})
+		return
+	case !shortlisted:
+		c.JSON(http.StatusForbidden, gin.H{"error": "The event did not shortlist this vendor"})
+		return
+	case !past:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Vendors can be reviewed once the event date has passed"})
+		return
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	review, err := scanReview(tx.QueryRow(ctx, `
+		WITH r AS (
+			INSERT INTO vendor_reviews (vendor_id, event_id, author_user_id, rating, body)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING *
+		)
+		SELECT `+reviewColumns+` FROM r JOIN users u ON u.id = r.author_user_id`,
+		vendorID, req.EventID, userID, req.Rating, strings.TrimSpace(req.Body)))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.ConstraintName == "vendor_reviews_event_vendor_author_key" {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already reviewed this vendor for this event"})
+		return
+	}
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to save review")
+		return
+	}
+	if err := touchVendor(ctx, tx, vendorID); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to save review")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+	h.VendorCache.Invalidate()
+
+	c.JSON(http.StatusCreated, review)
+}
+
+// EditReview applies a merge patch to the caller's review. Each review may be
+// edited once.
+func (h *ReviewHandler) EditReview(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+	patch, ok := bindMergePatch(c, reviewPatchFields, 2)
+	if !ok {
+		return
+	}
+	if len(patch.sets) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to change"})
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var vendorID string
+	var edited bool
+	err = tx.QueryRow(ctx, `
+		SELECT vendor_id, edited_at IS NOT NULL FROM vendor_reviews
+		WHERE id = $1 AND author_user_id = $2 FOR UPDATE`, id, c.GetString("userID")).Scan(&vendorID, &edited)
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Review not found")
+		return
+	}
+	if edited {
+		c.JSON(http.StatusConflict, gin.H{"error": "A review can only be edited once"})
+		return
+	}
+
+	sets := append(patch.sets, "edited_at = now()", "updated_at = now()")
+	review, err := scanReview(tx.QueryRow(ctx, `
+		WITH r AS (
+			UPDATE vendor_reviews SET `+strings.Join(sets, ", ")+`
+			WHERE id = $1
+			RETURNING *
+		)
+		SELECT `+reviewColumns+` FROM r JOIN users u ON u.id = r.author_user_id`,
+		append([]any{id}, patch.args...)...))
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to save review")
+		return
+	}
+	if err := touchVendor(ctx, tx, vendorID); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to save review")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+	h.VendorCache.Invalidate()
+
+	c.JSON(http.StatusOK, review)
+}
+
+// ReplyToReview sets the public reply of the vendor a review is about. Only
+// the vendor's owner may reply.
+func (h *ReviewHandler) ReplyToReview(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+	var req ReviewReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Reply = strings.TrimSpace(req.Reply)
+	if req.Reply == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reply must not be empty"})
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	review, err := scanReview(tx.QueryRow(ctx, `
+		WITH r AS (
+			UPDATE vendor_reviews r SET reply = $3, replied_at = now(), updated_at = now()
+			FROM vendor_profiles vp
+			WHERE r.id = $1 AND vp.id = r.vendor_id AND vp.owner_user_id = $2 AND vp.deleted_at IS NULL
+			RETURNING r.*
+		)
+		SELECT `+reviewColumns+` FROM r JOIN users u ON u.id = r.author_user_id`,
+		id, c.GetString("userID"), req.Reply))
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Review not found")
+		return
+	}
+	if err := touchVendor(ctx, tx, review.VendorID); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to save reply")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+	h.VendorCache.Invalidate()
+
+	c.JSON(http.StatusOK, review)
+}
+
+// ListVendorReviews lists the visible reviews of a verified vendor, newest
+// first.
+func (h *ReviewHandler) ListVendorReviews(c *gin.Context) {
+	p, ok := parsePage(c, reviewOrder)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
+
+	var vendorID string
+	err := db.Pool.QueryRow(ctx, `SELECT id FROM vendor_profiles WHERE slug = $1 AND status = 'verified' AND deleted_at IS NULL`,
+		c.Param("slug")).Scan(&vendorID)
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Vendor not found")
+		return
+	}
+
+	query, args := p.query(`
+		SELECT `+reviewColumns+`
+		FROM vendor_reviews r JOIN users u ON u.id = r.author_user_id
+		WHERE r.vendor_id = $1 AND NOT r.hidden`, reviewOrder, []any{vendorID})
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch reviews")
+		return
+	}
+	reviews, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Review, error) { return scanReview(row) })
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch reviews")
+		return
+	}
+
+	reviews, next := trim(p, reviews, func(r Review) []string { return []string{cursorTime(r.CreatedAt), r.ID} })
+	c.JSON(http.StatusOK, ReviewPage{Reviews: reviews, NextCursor: next})
+}
+
+// ListReviews lists reviews for moderation, newest first. ?flagged= and
+// ?hidden= (true or false) and ?vendor_id= narrow the list.
+func (h *ReviewHandler) ListReviews(c *gin.Context) {
+	p, ok := parsePage(c, reviewOrder)
+	if !ok {
+		return
+	}
+
+	conds := []string{"true"}
+	var args []any
+	for _, column := range []string{"flagged", "hidden"} {
+		switch c.Query(column) {
+		case "":
+		case "true", "false":
+			args = append(args, c.Query(column) == "true")
+			conds = append(conds, "r."+column+" = $"+strconv.Itoa(len(args)))
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": column + " must be true or false"})
+			return
+		}
+	}
+	if vendorID := c.Query("vendor_id"); vendorID != "" {
+		if _, err := uuid.Parse(vendorID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "vendor_id must be a UUID"})
+			return
+		}
+		args = append(args, vendorID)
+		conds = append(conds, "r.vendor_id = $"+strconv.Itoa(len(args)))
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
+
+	query, args := p.query(`
+		SELECT `+adminReviewColumns+`
+		FROM vendor_reviews r JOIN users u ON u.id = r.author_user_id
+		WHERE `+strings.Join(conds, " AND "), reviewOrder, args)
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch reviews")
+		return
+	}
+	reviews, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (AdminReview, error) { return scanAdminReview(row) })
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch reviews")
+		return
+	}
+
+	reviews, next := trim(p, reviews, func(r AdminReview) []string { return []string{cursorTime(r.CreatedAt), r.ID} })
+	c.JSON(http.StatusOK, AdminReviewPage{Reviews: reviews, NextCursor: next})
+}
+
+// ModerateReview hides, flags or annotates a review. Members left out keep
+// their value.
+func (h *ReviewHandler) ModerateReview(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+	var req ModerateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	review, err := scanAdminReview(tx.QueryRow(ctx, `
+		WITH r AS (
+			UPDATE vendor_reviews
+			SET hidden = COALESCE($2, hidden), flagged = COALESCE($3, flagged),
+			    moderation_note = COALESCE($4, moderation_note), updated_at = now()
+			WHERE id = $1
+			RETURNING *
+		)
+		SELECT `+adminReviewColumns+` FROM r JOIN users u ON u.id = r.author_user_id`,
+		id, req.Hidden, req.Flagged, req.Note))
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Review not found")
+		return
+	}
+	if err := touchVendor(ctx, tx, review.VendorID); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to save review")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+	h.VendorCache.Invalidate()
+
+	c.JSON(http.StatusOK, review)
+}
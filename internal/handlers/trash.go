@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/trash"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// trashLabels name each kind in responses.
+var trashLabels = map[trash.Kind]string{
+	trash.Users:   "User",
+	trash.Vendors: "Vendor",
+	trash.Groups:  "Group",
+	trash.Events:  "Event",
+}
+
+// inTrashTx runs op in a transaction, answering 404 on trash.ErrNotFound and
+// 409 on trash.ErrParentDeleted. It reports whether op committed.
+func inTrashTx(c *gin.Context, ctx context.Context, kind trash.Kind, op func(pgx.Tx) error) bool {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return false
+	}
+	defer tx.Rollback(ctx)
+
+	err = op(tx)
+	switch {
+	case errors.Is(err, trash.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": trashLabels[kind] + " not found"})
+		return false
+	case errors.Is(err, trash.ErrParentDeleted):
+		c.JSON(http.StatusConflict, gin.H{"error": "Restore the user or group it belongs to first"})
+		return false
+	case err != nil:
+		fail(c, err, http.StatusInternalServerError, "Failed to update "+string(kind))
+		return false
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return false
+	}
+	return true
+}
+
+// softDelete moves kind/id to the trash, along with everything under it.
+func softDelete(c *gin.Context, ctx context.Context, kind trash.Kind, id string) bool {
+	return inTrashTx(c, ctx, kind, func(tx pgx.Tx) error {
+		return trash.Delete(ctx, tx, kind, id)
+	})
+}
+
+// DeleteEntity returns the admin handler that soft-deletes one kind by id.
+func (h *AdminHandler) DeleteEntity(kind trash.Kind) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if _, err := uuid.Parse(id); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": trashLabels[kind] + " not found"})
+			return
+		}
+		if kind == trash.Users && id == c.GetString("userID") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Use DELETE /v1/me to delete your own account"})
+			return
+		}
+
+		ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+		defer cancel()
+
+		if !softDelete(c, ctx, kind, id) {
+			return
+		}
+		if kind == trash.Users || kind == trash.Vendors {
+			h.VendorCache.Invalidate()
+		}
+		c.JSON(http.StatusOK, MessageResponse{Message: trashLabels[kind] + " deleted"})
+	}
+}
+
+// RestoreEntity returns the admin handler that brings one kind back from the
+// trash, along with whatever was deleted with it.
+func (h *AdminHandler) RestoreEntity(kind trash.Kind) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if _, err := uuid.Parse(id); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": trashLabels[kind] + " not found"})
+			return
+		}
+
+		ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+		defer cancel()
+
+		ok := inTrashTx(c, ctx, kind, func(tx pgx.Tx) error {
+			return trash.Restore(ctx, tx, kind, id)
+		})
+		if !ok {
+			return
+		}
+		if kind == trash.Users || kind == trash.Vendors {
+			h.VendorCache.Invalidate()
+		}
+		c.JSON(http.StatusOK, MessageResponse{Message: trashLabels[kind] + " restored"})
+	}
+}
+
+// ListTrash lists deleted rows that have not been purged yet, newest first.
+func (h *AdminHandler) ListTrash(c *gin.Context) {
+	kind := trash.Kind(c.Query("kind"))
+	if _, ok := trashLabels[kind]; !ok && kind != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be users, vendors, groups or events"})
+		return
+	}
+	limit := 100
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+			return
+		}
+		limit = n
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
+
+	items, err := trash.List(ctx, db.Pool, kind, limit)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch deleted items")
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
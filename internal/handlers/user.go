@@ -2,68 +2,123 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"regexp"
+	"strings"
 
 	"github.com/bventy/backend/internal/config"
 	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/httpcache"
+	"github.com/bventy/backend/internal/jobs"
 	"github.com/bventy/backend/internal/services"
+	"github.com/bventy/backend/internal/trash"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 )
 
 type UserHandler struct {
 	Config       *config.Config
-	MediaService *services.MediaService
+	MediaService services.MediaStore
+	// VendorCache is invalidated when a vendor owner's name or picture,
+	// shown on the public vendor pages, changes.
+	VendorCache *httpcache.Cache
 }
 
-func NewUserHandler(cfg *config.Config) *UserHandler {
-	svc, _ := services.NewMediaService(cfg) // Ignore error? Or log?
+func NewUserHandler(cfg *config.Config, media services.MediaStore, vendorCache *httpcache.Cache) *UserHandler {
 	return &UserHandler{
 		Config:       cfg,
-		MediaService: svc,
+		MediaService: media,
+		VendorCache:  vendorCache,
 	}
 }
 
+// touchOwnedVendor bumps updated_at on the vendor profile userID owns, if
+// any, after a change to the owner details it shows.
+func touchOwnedVendor(ctx context.Context, tx pgx.Tx, userID any) error {
+	_, err := tx.Exec(ctx, `UPDATE vendor_profiles SET updated_at = now() WHERE owner_user_id = $1 AND deleted_at IS NULL`, userID)
+	return err
+}
+
+type MeGroup struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	Role string `json:"role"`
+}
+
+type MeResponse struct {
+	ID                  string    `json:"id"`
+	Email               string    `json:"email"`
+	FullName            string    `json:"full_name"`
+	Username            *string   `json:"username"`
+	ProfileImageURL     *string   `json:"profile_image_url"`
+	Role                string    `json:"role"`
+	VendorProfileExists bool      `json:"vendor_profile_exists"`
+	Groups              []MeGroup `json:"groups"`
+}
+
+type UpdateMeResponse struct {
+	ID       string  `json:"id"`
+	Email    string  `json:"email"`
+	FullName string  `json:"full_name"`
+	Username *string `json:"username"`
+	Role     string  `json:"role"`
+	Message  string  `json:"message"`
+}
+
 func (h *UserHandler) PromoteToAdmin(c *gin.Context) {
 	targetUserID := c.Param("id")
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
 	// Logic remains same
 	var currentRole string
-	err := db.Pool.QueryRow(context.Background(), "SELECT role FROM users WHERE id=$1", targetUserID).Scan(&currentRole)
+	err := db.Pool.QueryRow(ctx, "SELECT role FROM users WHERE id=$1 AND deleted_at IS NULL", targetUserID).Scan(&currentRole)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		fail(c, err, http.StatusNotFound, "User not found")
 		return
 	}
 	if currentRole == "super_admin" {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot change role of super_admin"})
 		return
 	}
-	_, err = db.Pool.Exec(context.Background(), "UPDATE users SET role='admin' WHERE id=$1", targetUserID)
+	// The role is baked into the JWT; bumping session_version logs the
+	// user out so the new role takes effect at once.
+	_, err = db.Pool.Exec(ctx, "UPDATE users SET role='admin', session_version = session_version + 1 WHERE id=$1", targetUserID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to promote user"})
+		fail(c, err, http.StatusInternalServerError, "Failed to promote user")
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "User promoted to admin"})
+	c.JSON(http.StatusOK, MessageResponse{Message: "User promoted to admin"})
 }
 
 func (h *UserHandler) PromoteToStaff(c *gin.Context) {
 	targetUserID := c.Param("id")
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
 	// Logic remains same
 	var currentRole string
-	err := db.Pool.QueryRow(context.Background(), "SELECT role FROM users WHERE id=$1", targetUserID).Scan(&currentRole)
+	err := db.Pool.QueryRow(ctx, "SELECT role FROM users WHERE id=$1 AND deleted_at IS NULL", targetUserID).Scan(&currentRole)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		fail(c, err, http.StatusNotFound, "User not found")
 		return
 	}
 	if currentRole == "admin" || currentRole == "super_admin" {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot demote/change admin users via this endpoint"})
 		return
 	}
-	_, err = db.Pool.Exec(context.Background(), "UPDATE users SET role='staff' WHERE id=$1", targetUserID)
+	// The role is baked into the JWT; bumping session_version logs the
+	// user out so the new role takes effect at once.
+	_, err = db.Pool.Exec(ctx, "UPDATE users SET role='staff', session_version = session_version + 1 WHERE id=$1", targetUserID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to promote user"})
+		fail(c, err, http.StatusInternalServerError, "Failed to promote user")
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "User promoted to staff"})
+	c.JSON(http.StatusOK, MessageResponse{Message: "User promoted to staff"})
 }
 
 func (h *UserHandler) GetMe(c *gin.Context) {
@@ -73,52 +128,59 @@ func (h *UserHandler) GetMe(c *gin.Context) {
 		return
 	}
 
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
+
 	// Fetch user details
 	var email, role, fullName string
 	var username, profileImageURL *string // Use pointer for nullable string
+	var version int
 
-	query := `SELECT email, role, full_name, username, profile_image_url FROM users WHERE id=$1`
-	err := db.Pool.QueryRow(context.Background(), query, userID).Scan(&email, &role, &fullName, &username, &profileImageURL)
+	query := `SELECT email, role, full_name, username, profile_image_url, version FROM users WHERE id=$1 AND deleted_at IS NULL`
+	err := db.Pool.QueryRow(ctx, query, userID).Scan(&email, &role, &fullName, &username, &profileImageURL, &version)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		fail(c, err, http.StatusNotFound, "User not found")
 		return
 	}
 
 	// Check profiles
 	var vendorExists bool
 	var dummy int
-	err = db.Pool.QueryRow(context.Background(), "SELECT 1 FROM vendor_profiles WHERE owner_user_id=$1", userID).Scan(&dummy)
+	err = db.Pool.QueryRow(ctx, "SELECT 1 FROM vendor_profiles WHERE owner_user_id=$1 AND deleted_at IS NULL", userID).Scan(&dummy)
 	vendorExists = err == nil
 
 	// Fetch groups
-	var groups []gin.H
-	rows, err := db.Pool.Query(context.Background(), `
+	groups := []MeGroup{}
+	rows, err := db.Pool.Query(ctx, `
 		SELECT g.id, g.name, g.slug, gm.role 
 		FROM groups g
 		JOIN group_members gm ON g.id = gm.group_id
-		WHERE gm.user_id = $1
+		WHERE gm.user_id = $1 AND g.deleted_at IS NULL
 	`, userID)
 	if err == nil {
 		defer rows.Close()
 		for rows.Next() {
-			var gid, gname, gslug, grole string
-			if err := rows.Scan(&gid, &gname, &gslug, &grole); err == nil {
-				groups = append(groups, gin.H{"id": gid, "name": gname, "slug": gslug, "role": grole})
+			var g MeGroup
+			if err := rows.Scan(&g.ID, &g.Name, &g.Slug, &g.Role); err == nil {
+				groups = append(groups, g)
 			}
 		}
-	} else {
-		groups = []gin.H{}
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"id":                    userID, // Added ID to response as it's useful
-		"email":                 email,
-		"full_name":             fullName,
-		"username":              username,        // Returns string or null
-		"profile_image_url":     profileImageURL, // Returns string or null
-		"role":                  role,
-		"vendor_profile_exists": vendorExists,
-		"groups":                groups,
+	}
+	if timedOut(c, ctx.Err()) {
+		return
+	}
+
+	// Send this back in If-Match when updating
+	c.Header("ETag", versionETag(version))
+	c.JSON(http.StatusOK, MeResponse{
+		ID:                  userID.(string),
+		Email:               email,
+		FullName:            fullName,
+		Username:            username,        // Returns string or null
+		ProfileImageURL:     profileImageURL, // Returns string or null
+		Role:                role,
+		VendorProfileExists: vendorExists,
+		Groups:              groups,
 	})
 }
 
@@ -137,6 +199,9 @@ func (h *UserHandler) UpdateMe(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
+	if !requireIfMatch(c, h.Config.LegacyRoutesSunset) {
+		return
+	}
 
 	var req UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -144,6 +209,9 @@ func (h *UserHandler) UpdateMe(c *gin.Context) {
 		return
 	}
 
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
 	// 1. Explicit Uniqueness Check (as requested)
 	// Only check if username is provided/non-empty, assuming we allow setting it to NULL (empty) without uniqueness check
 	// EXCEPT if we treat empty as NULL, we don't need to check uniqueness for empty/NULL (Postgres unique allows multiple nulls).
@@ -152,9 +220,9 @@ func (h *UserHandler) UpdateMe(c *gin.Context) {
 	if req.Username != "" {
 		var count int
 		checkQuery := `SELECT count(*) FROM users WHERE username = $1 AND id != $2`
-		err := db.Pool.QueryRow(context.Background(), checkQuery, req.Username, userID).Scan(&count)
+		err := db.Pool.QueryRow(ctx, checkQuery, req.Username, userID).Scan(&count)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate username"})
+			fail(c, err, http.StatusInternalServerError, "Failed to validate username")
 			return
 		}
 		if count > 0 {
@@ -175,8 +243,13 @@ func (h *UserHandler) UpdateMe(c *gin.Context) {
 		phoneArg = nil
 	}
 
-	var cityArg interface{} = req.City
-	if req.City == "" {
+	city, locationID, err := resolveCity(ctx, req.City)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to update profile")
+		return
+	}
+	var cityArg interface{} = city
+	if city == "" {
 		cityArg = nil
 	}
 
@@ -192,15 +265,33 @@ func (h *UserHandler) UpdateMe(c *gin.Context) {
 
 	query := `
 		UPDATE users 
-		SET full_name = $2, username = $3, phone = $4, city = $5, bio = $6, profile_image_url = $7
+		SET full_name = $2, username = $3, phone = $4, city = $5, bio = $6, profile_image_url = $7, location_id = $8, version = version + 1
 		WHERE id = $1
-		RETURNING id, email, full_name, username, role
+		RETURNING id, email, full_name, username, role, version
 	`
 
 	var id, email, fullName, role string
 	var username *string // Scan into pointer for potential NULL
+	var version int
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the row so the version check and the update see the same data
+	err = tx.QueryRow(ctx, "SELECT version FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", userID).Scan(&version)
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "User not found")
+		return
+	}
+	if !ifMatches(c, version) {
+		return
+	}
 
-	err := db.Pool.QueryRow(context.Background(), query,
+	err = tx.QueryRow(ctx, query,
 		userID,
 		req.FullName,
 		usernameArg,
@@ -208,25 +299,136 @@ func (h *UserHandler) UpdateMe(c *gin.Context) {
 		cityArg,
 		bioArg,
 		imageArg,
-	).Scan(&id, &email, &fullName, &username, &role)
+		locationID,
+	).Scan(&id, &email, &fullName, &username, &role, &version)
 
 	if err != nil {
 		// Log the actual error for debugging
 		fmt.Printf("❌ Profile Update Error: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		fail(c, err, http.StatusInternalServerError, "Failed to update profile")
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"id":        id,
-		"email":     email,
-		"full_name": fullName,
-		"username":  username,
-		"role":      role,
-		"message":   "Profile updated successfully",
+	if err := touchOwnedVendor(ctx, tx, userID); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to update profile")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+	h.VendorCache.Invalidate()
+
+	c.Header("ETag", versionETag(version))
+	c.JSON(http.StatusOK, UpdateMeResponse{
+		ID:       id,
+		Email:    email,
+		FullName: fullName,
+		Username: username,
+		Role:     role,
+		Message:  "Profile updated successfully",
 	})
 }
 
+// UserPatch documents the PATCH /me body. The handler reads it as a merge
+// patch through userPatchFields, so keep the two in step.
+type UserPatch struct {
+	FullName        *string `json:"full_name"`
+	Username        *string `json:"username" doc:"3-30 letters, digits, '_' or '.'; null clears it"`
+	Phone           *string `json:"phone" doc:"null clears it"`
+	City            *string `json:"city" doc:"null clears it"`
+	Bio             *string `json:"bio" doc:"null clears it"`
+	ProfileImageURL *string `json:"profile_image_url" doc:"null clears it"`
+}
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.]{3,30}$`)
+
+// userPatchFields are the members PATCH /me accepts.
+var userPatchFields = map[string]patchField{
+	"full_name":         {column: "full_name", parse: textField(true, 120)},
+	"username":          {column: "username", clear: nullValue, parse: usernameField},
+	"phone":             {column: "phone", clear: nullValue, parse: nullableText(20)},
+	"city":              {clear: nullValue, parse: nullableText(80)},
+	"bio":               {column: "bio", clear: nullValue, parse: nullableText(2000)},
+	"profile_image_url": {column: "profile_image_url", clear: nullValue, parse: urlField},
+}
+
+func usernameField(raw json.RawMessage) (any, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, errors.New("must be a string")
+	}
+	if !usernamePattern.MatchString(s) {
+		return nil, errors.New("must be 3-30 letters, digits, '_' or '.'")
+	}
+	return s, nil
+}
+
+// PatchMe applies an RFC 7396 merge patch to the caller's profile: members
+// left out keep their value and null clears one.
+func (h *UserHandler) PatchMe(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !requireIfMatch(c, h.Config.LegacyRoutesSunset) {
+		return
+	}
+	patch, ok := bindMergePatch(c, userPatchFields, 2)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	if err := setPatchCity(ctx, &patch); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to update profile")
+		return
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var version int
+	err = tx.QueryRow(ctx, "SELECT version FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", userID).Scan(&version)
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "User not found")
+		return
+	}
+	if !ifMatches(c, version) {
+		return
+	}
+
+	res := UpdateMeResponse{Message: "Profile updated successfully"}
+	sets := append(patch.sets, "version = version + 1")
+	err = tx.QueryRow(ctx, `
+		UPDATE users SET `+strings.Join(sets, ", ")+`
+		WHERE id = $1
+		RETURNING id, email, full_name, username, role, version
+	`, append([]any{userID}, patch.args...)...).Scan(&res.ID, &res.Email, &res.FullName, &res.Username, &res.Role, &version)
+	if err != nil {
+		if strings.Contains(err.Error(), "unique constraint") {
+			c.JSON(http.StatusConflict, gin.H{"error": "Username is already taken"})
+			return
+		}
+		fail(c, err, http.StatusInternalServerError, "Failed to update profile")
+		return
+	}
+	if err := touchOwnedVendor(ctx, tx, userID); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to update profile")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+	h.VendorCache.Invalidate()
+
+	c.Header("ETag", versionETag(version))
+	c.JSON(http.StatusOK, res)
+}
+
 // UploadProfileImage handles uploading and updating the user's profile image
 func (h *UserHandler) UploadProfileImage(c *gin.Context) {
 	userID := c.MustGet("userID").(string)
@@ -249,34 +451,83 @@ func (h *UserHandler) UploadProfileImage(c *gin.Context) {
 		return
 	}
 	defer file.Close()
+	if err := services.CheckImage(file); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File must be a JPEG, PNG or WebP image"})
+		return
+	}
 
-	// Upload logic
+	// Store the original; a job converts it to WebP and swaps the URL
+	mediaCtx, cancelMedia := withDeadline(c, h.Config.Timeouts.Media)
+	defer cancelMedia()
 	prefix := fmt.Sprintf("users/%s/profile", userID)
-	newURL, err := h.MediaService.CompressAndUploadImage(file, fileHeader.Filename, prefix)
+	newURL, err := h.MediaService.UploadFile(mediaCtx, file, fileHeader.Filename, fileHeader.Header.Get("Content-Type"), prefix)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
+		fail(c, err, http.StatusInternalServerError, "Failed to upload image")
 		return
 	}
 
-	// Clean up old image if exists (fetch old URL from DB first)
-	// We already have `profileImageURL` from previous GET logic? No, this is a POST/PUT endpoint, need to query.
-	var oldURL *string
-	err = db.Pool.QueryRow(context.TODO(), "SELECT profile_image_url FROM users WHERE id=$1", userID).Scan(&oldURL)
-	if err == nil && oldURL != nil && *oldURL != "" {
-		// Try to delete old file
-		// Don't error out if delete fails, just log it (or ignore)
-		_ = h.MediaService.DeleteFile(*oldURL)
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
 	}
+	defer tx.Rollback(ctx)
 
-	// Update DB
-	_, err = db.Pool.Exec(context.TODO(), "UPDATE users SET profile_image_url=$1 WHERE id=$2", newURL, userID)
+	// Swap the URL, remembering the old one so its file can be cleaned up
+	var oldURL *string
+	err = tx.QueryRow(ctx, "SELECT profile_image_url FROM users WHERE id=$1 AND deleted_at IS NULL FOR UPDATE", userID).Scan(&oldURL)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		fail(c, err, http.StatusInternalServerError, "Failed to update profile")
+		return
+	}
+	_, err = tx.Exec(ctx, "UPDATE users SET profile_image_url=$1, version = version + 1 WHERE id=$2", newURL, userID)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to update profile")
+		return
+	}
+	if oldURL != nil && *oldURL != "" && *oldURL != newURL {
+		if err := jobs.EnqueueDeleteMedia(ctx, tx, *oldURL); err != nil {
+			fail(c, err, http.StatusInternalServerError, "Failed to update profile")
+			return
+		}
+	}
+	if err := jobs.EnqueueConvertImage(ctx, tx, jobs.ImageUserProfile, userID, newURL, prefix); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to update profile")
+		return
+	}
+	if err := touchOwnedVendor(ctx, tx, userID); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to update profile")
 		return
 	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+	h.VendorCache.Invalidate()
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Profile image updated",
-		"url":     newURL,
+	c.JSON(http.StatusAccepted, ImageUploadResponse{
+		Message: "Profile image updated",
+		URL:     newURL,
+		Status:  "pending",
 	})
 }
+
+// DeleteMe deletes the current user's account along with their vendor
+// profile, the groups they own and their events. Admins can restore it until
+// it is purged.
+func (h *UserHandler) DeleteMe(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	if !softDelete(c, ctx, trash.Users, userID) {
+		return
+	}
+	h.VendorCache.Invalidate()
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Account deleted"})
+}
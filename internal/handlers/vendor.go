@@ -1,36 +1,124 @@
 package handlers
 
 import (
-	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/bventy/backend/internal/config"
 	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/httpcache"
+	"github.com/bventy/backend/internal/jobs"
 	"github.com/bventy/backend/internal/services"
+	"github.com/bventy/backend/internal/trash"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type VendorHandler struct {
 	Config       *config.Config
-	MediaService *services.MediaService
+	MediaService services.MediaStore
+	// Cache holds rendered /vendors and /vendors/slug/:slug responses.
+	Cache *httpcache.Cache
 }
 
-func NewVendorHandler(cfg *config.Config) *VendorHandler {
-	svc, _ := services.NewMediaService(cfg)
+func NewVendorHandler(cfg *config.Config, media services.MediaStore, cache *httpcache.Cache) *VendorHandler {
 	return &VendorHandler{
 		Config:       cfg,
-		MediaService: svc,
+		MediaService: media,
+		Cache:        cache,
 	}
 }
 
 type OnboardVendorRequest struct {
-	BusinessName string `json:"business_name" binding:"required"`
-	Category     string `json:"category" binding:"required"`
-	City         string `json:"city" binding:"required"`
-	Bio          string `json:"bio"`
-	WhatsappLink string `json:"whatsapp_link" binding:"required"`
+	BusinessName string   `json:"business_name" binding:"required"`
+	Category     string   `json:"category" binding:"required" doc:"Main category: a slug, name or alias from GET /v1/categories"`
+	Categories   []string `json:"categories" binding:"omitempty,max=5" doc:"Up to 5 more categories, likewise"`
+	City         string   `json:"city" binding:"required"`
+	Bio          string   `json:"bio"`
+	WhatsappLink string   `json:"whatsapp_link" binding:"required"`
+	PriceMin     *int     `json:"price_min" binding:"omitempty,min=0" doc:"Typical price range in whole rupees"`
+	PriceMax     *int     `json:"price_max" binding:"omitempty,min=0"`
+	Latitude     *float64 `json:"latitude" binding:"omitempty,min=-90,max=90" doc:"Exact location; set both or neither"`
+	Longitude    *float64 `json:"longitude" binding:"omitempty,min=-180,max=180"`
+}
+
+type OnboardVendorResponse struct {
+	Message  string `json:"message"`
+	VendorID string `json:"vendor_id"`
+	Slug     string `json:"slug"`
+}
+
+type MyVendorProfile struct {
+	BusinessName      string        `json:"business_name"`
+	Slug              string        `json:"slug"`
+	Category          string        `json:"category"`
+	Categories        []string      `json:"categories" doc:"Category slugs, the main category first"`
+	City              string        `json:"city"`
+	Bio               string        `json:"bio"`
+	WhatsappLink      string        `json:"whatsapp_link"`
+	PortfolioImageURL *string       `json:"portfolio_image_url"`
+	GalleryImages     []string      `json:"gallery_images"`
+	PortfolioFiles    []interface{} `json:"portfolio_files" doc:"Array of {name, url} objects"`
+	PriceMin          *int          `json:"price_min"`
+	PriceMax          *int          `json:"price_max"`
+	Latitude          *float64      `json:"latitude"`
+	Longitude         *float64      `json:"longitude"`
+	DailyCapacity     int           `json:"daily_capacity" doc:"Events a day; see GET /v1/vendor/me/availability"`
+	Verified          bool          `json:"verified"`
+}
+
+// PublicVendor is a verified vendor as listed on /vendors.
+type PublicVendor struct {
+	ID                string   `json:"id"`
+	BusinessName      string   `json:"business_name"`
+	Slug              string   `json:"slug"`
+	Category          string   `json:"category"`
+	Categories        []string `json:"categories" doc:"Category slugs, the main category first"`
+	City              string   `json:"city"`
+	Bio               string   `json:"bio"`
+	WhatsappLink      string   `json:"whatsapp_link"`
+	PortfolioImageURL *string  `json:"portfolio_image_url"`
+	GalleryImages     []string `json:"gallery_images"`
+	PriceMin          *int     `json:"price_min"`
+	PriceMax          *int     `json:"price_max"`
+	StartingPrice     *int     `json:"starting_price" doc:"Lowest rupee price over price_min and the packages"`
+	Rating            *float64 `json:"rating" doc:"Average of the visible reviews, to one decimal; null without any"`
+	ReviewCount       int64    `json:"review_count"`
+	Latitude          *float64 `json:"latitude"`
+	Longitude         *float64 `json:"longitude"`
+	DistanceKm        *float64 `json:"distance_km,omitempty" doc:"From near, when searching by it"`
+	OwnerFullName     *string  `json:"owner_full_name"`
+	OwnerProfileImage *string  `json:"owner_profile_image"`
+}
+
+// PublicVendorDetail is the vendor page served by slug.
+type PublicVendorDetail struct {
+	ID                string          `json:"id"`
+	BusinessName      string          `json:"business_name"`
+	Slug              string          `json:"slug"`
+	Category          string          `json:"category"`
+	Categories        []string        `json:"categories" doc:"Category slugs, the main category first"`
+	City              string          `json:"city"`
+	Bio               string          `json:"bio"`
+	WhatsappLink      string          `json:"whatsapp_link"`
+	PortfolioImageURL *string         `json:"portfolio_image_url"`
+	GalleryImages     []string        `json:"gallery_images"`
+	PortfolioFiles    []interface{}   `json:"portfolio_files" doc:"Array of {name, url} objects"`
+	PriceMin          *int            `json:"price_min"`
+	PriceMax          *int            `json:"price_max"`
+	StartingPrice     *int            `json:"starting_price" doc:"Lowest rupee price over price_min and the packages"`
+	Rating            *float64        `json:"rating" doc:"Average of the visible reviews, to one decimal; null without any"`
+	ReviewCount       int64           `json:"review_count" doc:"See GET /v1/vendors/slug/:slug/reviews"`
+	Packages          []VendorPackage `json:"packages"`
+	Latitude          *float64        `json:"latitude"`
+	Longitude         *float64        `json:"longitude"`
+	OwnerFullName     *string         `json:"owner_full_name"`
+	OwnerProfileImage *string         `json:"owner_profile_image"`
 }
 
 func (h *VendorHandler) OnboardVendor(c *gin.Context) {
@@ -46,27 +134,89 @@ func (h *VendorHandler) OnboardVendor(c *gin.Context) {
 		return
 	}
 
-	slug := generateSlug(req.BusinessName, req.City)
+	if req.PriceMin != nil && req.PriceMax != nil && *req.PriceMax < *req.PriceMin {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "price_max must not be below price_min"})
+		return
+	}
+	if !validCoordinates(req.Latitude, req.Longitude) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "latitude and longitude must be set together"})
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	main, ok := resolveCategories(c, ctx, []string{req.Category})
+	if !ok {
+		return
+	}
+	others, ok := resolveCategories(c, ctx, req.Categories)
+	if !ok {
+		return
+	}
+	city, locationID, err := resolveCity(ctx, req.City)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to onboard vendor")
+		return
+	}
+	slug := generateSlug(req.BusinessName, city)
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
 
 	// Insert into vendor_profiles
 	query := `
-		INSERT INTO vendor_profiles (owner_user_id, business_name, slug, category, city, bio, whatsapp_link, status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, 'pending')
+		INSERT INTO vendor_profiles (owner_user_id, business_name, slug, category, category_id, city, location_id, latitude, longitude,
+			bio, whatsapp_link, price_min, price_max, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, 'pending')
 		RETURNING id
 	`
 
 	var vendorID string
-	err := db.Pool.QueryRow(context.Background(), query, userID, req.BusinessName, slug, req.Category, req.City, req.Bio, req.WhatsappLink).Scan(&vendorID)
+	err = tx.QueryRow(ctx, query, userID, req.BusinessName, slug, main[0].name, main[0].id, city, locationID, req.Latitude, req.Longitude,
+		req.Bio, req.WhatsappLink, req.PriceMin, req.PriceMax).Scan(&vendorID)
 	if err != nil {
 		if strings.Contains(err.Error(), "unique constraint") {
 			c.JSON(http.StatusConflict, gin.H{"error": "Vendor profile already exists for this user or slug conflict"})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to onboard vendor: " + err.Error()})
+		fail(c, err, http.StatusInternalServerError, "Failed to onboard vendor: "+err.Error())
 		return
 	}
+	if err := setVendorCategories(ctx, tx, vendorID, nil, others); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to onboard vendor")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+
+	c.JSON(http.StatusCreated, OnboardVendorResponse{Message: "Vendor profile created successfully", VendorID: vendorID, Slug: slug})
+}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Vendor profile created successfully", "vendor_id": vendorID, "slug": slug})
+// myVendorProfileColumns are read by scanMyVendorProfile, from vendor_profiles
+// vp. COALESCE keeps a NULL bio from failing the scan.
+const myVendorProfileColumns = `business_name, slug, category, ` + vendorCategorySlugs + `, city, COALESCE(bio, ''), whatsapp_link,
+	portfolio_image_url, gallery_images, portfolio_files, price_min, price_max, latitude, longitude, daily_capacity, status, version`
+
+// scanMyVendorProfile reads myVendorProfileColumns and returns the profile and
+// its version.
+func scanMyVendorProfile(row pgx.Row) (MyVendorProfile, int, error) {
+	var p MyVendorProfile
+	var status string
+	var version int
+	err := row.Scan(
+		&p.BusinessName, &p.Slug, &p.Category, &p.Categories, &p.City, &p.Bio, &p.WhatsappLink,
+		&p.PortfolioImageURL, &p.GalleryImages, &p.PortfolioFiles, &p.PriceMin, &p.PriceMax, &p.Latitude, &p.Longitude, &p.DailyCapacity, &status, &version,
+	)
+	// Map status to verified boolean
+	p.Verified = status == "verified"
+	return p, version, err
 }
 
 func (h *VendorHandler) GetMyProfile(c *gin.Context) {
@@ -76,132 +226,78 @@ func (h *VendorHandler) GetMyProfile(c *gin.Context) {
 		return
 	}
 
-	// Use COALESCE for nullable text fields to avoid Scan errors
-	// Use 'status' column instead of non-existent 'verified' column
-	query := `
-		SELECT business_name, slug, category, city, COALESCE(bio, ''), whatsapp_link, portfolio_image_url, gallery_images, portfolio_files, status
-		FROM vendor_profiles 
-		WHERE owner_user_id = $1
-	`
-
-	var name, slug, category, city, bio, whatsappLink, status string
-	var portfolioImageURL *string
-	var galleryImages []string
-	var portfolioFiles []interface{}
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
 
-	err := db.Pool.QueryRow(context.Background(), query, userID).Scan(
-		&name, &slug, &category, &city, &bio, &whatsappLink,
-		&portfolioImageURL, &galleryImages, &portfolioFiles, &status,
-	)
+	profile, version, err := scanMyVendorProfile(db.Pool.QueryRow(ctx,
+		`SELECT `+myVendorProfileColumns+` FROM vendor_profiles vp WHERE owner_user_id = $1 AND deleted_at IS NULL`, userID))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Vendor profile not found"})
+		fail(c, err, http.StatusNotFound, "Vendor profile not found")
 		return
 	}
 
-	// Map status to verified boolean
-	verified := (status == "verified")
-
-	c.JSON(http.StatusOK, gin.H{
-		"business_name":       name,
-		"slug":                slug,
-		"category":            category,
-		"city":                city,
-		"bio":                 bio,
-		"whatsapp_link":       whatsappLink,
-		"portfolio_image_url": portfolioImageURL,
-		"gallery_images":      galleryImages,
-		"portfolio_files":     portfolioFiles,
-		"verified":            verified,
-	})
+	// Send this back in If-Match when updating
+	c.Header("ETag", versionETag(version))
+	c.JSON(http.StatusOK, profile)
 }
 
-func (h *VendorHandler) ListVerifiedVendors(c *gin.Context) {
-	query := `
-		SELECT 
-			vp.id, vp.business_name, vp.slug, vp.category, vp.city, vp.bio, vp.whatsapp_link, vp.portfolio_image_url, vp.gallery_images,
-			u.full_name, u.profile_image_url
-		FROM vendor_profiles vp
-		JOIN users u ON vp.owner_user_id = u.id
-		WHERE vp.status = 'verified'
-	`
-	rows, err := db.Pool.Query(context.Background(), query)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vendors"})
+func (h *VendorHandler) GetVendorBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+	key := "vendor:" + slug
+	if e, ok := h.Cache.Get(key); ok {
+		serveCached(c, e, h.Config.PublicCacheControl)
 		return
 	}
-	defer rows.Close()
+	generation := h.Cache.Generation()
 
-	var vendors []gin.H
-	for rows.Next() {
-		var id, name, slug, category, city, bio, whatsappLink string
-		var portfolioImageURL, ownerFullName, ownerProfileImage *string
-		var galleryImages []string
-		if err := rows.Scan(&id, &name, &slug, &category, &city, &bio, &whatsappLink, &portfolioImageURL, &galleryImages, &ownerFullName, &ownerProfileImage); err != nil {
-			continue
-		}
-		vendors = append(vendors, gin.H{
-			"id":                  id,
-			"business_name":       name,
-			"slug":                slug,
-			"category":            category,
-			"city":                city,
-			"bio":                 bio,
-			"whatsapp_link":       whatsappLink,
-			"portfolio_image_url": portfolioImageURL,
-			"gallery_images":      galleryImages,
-			"owner_full_name":     ownerFullName,
-			"owner_profile_image": ownerProfileImage,
-		})
-	}
-
-	c.JSON(http.StatusOK, vendors)
-}
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
 
-func (h *VendorHandler) GetVendorBySlug(c *gin.Context) {
-	slug := c.Param("slug")
 	query := `
 		SELECT 
-			vp.id, vp.business_name, vp.slug, vp.category, vp.city, vp.bio, vp.whatsapp_link, vp.portfolio_image_url, vp.gallery_images, vp.portfolio_files,
-			u.full_name, u.profile_image_url
+			vp.id, vp.business_name, vp.slug, vp.category, ` + vendorCategorySlugs + `, vp.city, vp.bio, vp.whatsapp_link, vp.portfolio_image_url, vp.gallery_images, vp.portfolio_files,
+			vp.price_min, vp.price_max, ` + vendorStartingPrice + `, ` + vendorRating + `, ` + vendorReviewCount + `, vp.latitude, vp.longitude, u.full_name, u.profile_image_url, vp.updated_at
 		FROM vendor_profiles vp
 		JOIN users u ON vp.owner_user_id = u.id
-		WHERE vp.slug = $1 AND vp.status = 'verified'
+		WHERE vp.slug = $1 AND vp.status = 'verified' AND vp.deleted_at IS NULL AND u.deleted_at IS NULL
 	`
 
-	var id, name, s, category, city, bio, whatsappLink string
-	var portfolioImageURL, ownerFullName, ownerProfileImage *string
-	var galleryImages []string
-	var portfolioFiles []interface{}
-
-	err := db.Pool.QueryRow(context.Background(), query, slug).Scan(
-		&id, &name, &s, &category, &city, &bio, &whatsappLink,
-		&portfolioImageURL, &galleryImages, &portfolioFiles,
-		&ownerFullName, &ownerProfileImage,
+	var v PublicVendorDetail
+	var lastModified time.Time
+	err := db.Pool.QueryRow(ctx, query, slug).Scan(
+		&v.ID, &v.BusinessName, &v.Slug, &v.Category, &v.Categories, &v.City, &v.Bio, &v.WhatsappLink,
+		&v.PortfolioImageURL, &v.GalleryImages, &v.PortfolioFiles,
+		&v.PriceMin, &v.PriceMax, &v.StartingPrice, &v.Rating, &v.ReviewCount, &v.Latitude, &v.Longitude, &v.OwnerFullName, &v.OwnerProfileImage, &lastModified,
 	)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Vendor not found"})
+		fail(c, err, http.StatusNotFound, "Vendor not found")
+		return
+	}
+	if v.Packages, err = vendorPackages(ctx, v.ID); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch packages")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"id":                  id,
-		"business_name":       name,
-		"slug":                s,
-		"category":            category,
-		"city":                city,
-		"bio":                 bio,
-		"whatsapp_link":       whatsappLink,
-		"portfolio_image_url": portfolioImageURL,
-		"gallery_images":      galleryImages,
-		"portfolio_files":     portfolioFiles,
-		"owner_full_name":     ownerFullName,
-		"owner_profile_image": ownerProfileImage,
-	})
+	h.renderCached(c, generation, key, v, lastModified)
+}
+
+// renderCached encodes v, caches it under key and serves it. Only successful
+// responses are cached, so unknown slugs cannot fill the cache.
+func (h *VendorHandler) renderCached(c *gin.Context, generation uint64, key string, v any, lastModified time.Time) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode response"})
+		return
+	}
+	e := httpcache.NewEntry(body, lastModified)
+	h.Cache.Set(generation, key, e)
+	serveCached(c, e, h.Config.PublicCacheControl)
 }
 
 type UpdateVendorRequest struct {
 	BusinessName      string        `json:"business_name"`
-	Category          string        `json:"category"`
+	Category          string        `json:"category" doc:"Main category, as on onboarding; empty keeps it"`
+	Categories        []string      `json:"categories" binding:"omitempty,max=5" doc:"Replaces the other categories when present"`
 	City              string        `json:"city"`
 	Bio               string        `json:"bio"`
 	WhatsappLink      string        `json:"whatsapp_link"`
@@ -216,6 +312,9 @@ func (h *VendorHandler) UpdateVendor(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
+	if !requireIfMatch(c, h.Config.LegacyRoutesSunset) {
+		return
+	}
 
 	var req UpdateVendorRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -223,6 +322,30 @@ func (h *VendorHandler) UpdateVendor(c *gin.Context) {
 		return
 	}
 
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	var main *vendorCategory
+	if strings.TrimSpace(req.Category) != "" {
+		cats, ok := resolveCategories(c, ctx, []string{req.Category})
+		if !ok {
+			return
+		}
+		main = &cats[0]
+	}
+	var others []vendorCategory
+	if req.Categories != nil {
+		var ok bool
+		if others, ok = resolveCategories(c, ctx, req.Categories); !ok {
+			return
+		}
+	}
+	city, locationID, err := resolveCity(ctx, req.City)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to update vendor profile")
+		return
+	}
+
 	// Calculate new slug if business name changes?
 	// For simplicity, let's keep slug persistent or only update if explicitly needed.
 	// The requirement doesn't specify slug updates, so we'll skip slug updates to avoid breaking links.
@@ -232,20 +355,46 @@ func (h *VendorHandler) UpdateVendor(c *gin.Context) {
 	// but pgx usually handles []interface{} -> jsonb automatically if we pass it right.
 	// However, it's safer to just pass it directly if the driver supports it.
 
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the profile so the version check and the update see the same row
+	var vendorID string
+	var version int
+	err = tx.QueryRow(ctx, "SELECT id, version FROM vendor_profiles WHERE owner_user_id = $1 AND deleted_at IS NULL FOR UPDATE", userID).Scan(&vendorID, &version)
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Vendor profile not found")
+		return
+	}
+	if !ifMatches(c, version) {
+		return
+	}
+	if main != nil || others != nil {
+		if err := setVendorCategories(ctx, tx, vendorID, main, others); err != nil {
+			fail(c, err, http.StatusInternalServerError, "Failed to update vendor profile")
+			return
+		}
+	}
+
 	// Handle Updates
-	// We need to verify ownership first or just update where owner_user_id = userID
 	query := `
 		UPDATE vendor_profiles 
 		SET business_name = COALESCE(NULLIF($2, ''), business_name),
-		    category = COALESCE(NULLIF($3, ''), category),
-		    city = COALESCE(NULLIF($4, ''), city),
-		    bio = COALESCE(NULLIF($5, ''), bio),
-		    whatsapp_link = COALESCE(NULLIF($6, ''), whatsapp_link),
-		    portfolio_image_url = $7,
-		    gallery_images = $8,
-		    portfolio_files = $9
+		    city = COALESCE(NULLIF($3, ''), city),
+		    location_id = CASE WHEN $3 = '' THEN location_id ELSE $9::uuid END,
+		    bio = COALESCE(NULLIF($4, ''), bio),
+		    whatsapp_link = COALESCE(NULLIF($5, ''), whatsapp_link),
+		    portfolio_image_url = $6,
+		    gallery_images = $7,
+		    portfolio_files = $8,
+		    updated_at = now(),
+		    version = version + 1
 		WHERE owner_user_id = $1
-		RETURNING id
+		RETURNING version
 	`
 
 	// Note: For arrays and jsonb, if they are empty/nil in request, we might want to keep existing?
@@ -253,25 +402,202 @@ func (h *VendorHandler) UpdateVendor(c *gin.Context) {
 	// COALESCE logic above is for strings. For arrays, we probably want to allow clearing them (empty list).
 	// So we pass them directly.
 
-	var id string
-	err := db.Pool.QueryRow(context.Background(), query,
+	err = tx.QueryRow(ctx, query,
 		userID,
 		req.BusinessName,
-		req.Category,
-		req.City,
+		city,
 		req.Bio,
 		req.WhatsappLink,
 		req.PortfolioImageURL,
 		req.GalleryImages,
 		req.PortfolioFiles,
-	).Scan(&id)
+		locationID,
+	).Scan(&version)
+
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to update vendor profile: "+err.Error())
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+	h.Cache.Invalidate()
+
+	c.Header("ETag", versionETag(version))
+	c.JSON(http.StatusOK, MessageResponse{Message: "Vendor profile updated successfully"})
+}
+
+// VendorPatch documents the PATCH /vendor/me body. The handler reads it as a
+// merge patch through vendorPatchFields, so keep the two in step.
+type VendorPatch struct {
+	BusinessName      *string         `json:"business_name"`
+	Category          *string         `json:"category" doc:"Main category: a slug, name or alias from GET /v1/categories"`
+	Categories        []string        `json:"categories" doc:"Up to 5 other categories; replaces them, null removes them"`
+	City              *string         `json:"city"`
+	Bio               *string         `json:"bio" doc:"null clears it"`
+	WhatsappLink      *string         `json:"whatsapp_link"`
+	PortfolioImageURL *string         `json:"portfolio_image_url" doc:"null clears it"`
+	GalleryImages     []string        `json:"gallery_images" doc:"Up to 25 URLs; replaces the list, null empties it"`
+	PortfolioFiles    []PortfolioFile `json:"portfolio_files" doc:"Up to 20 files; replaces the list, null empties it"`
+	PriceMin          *int            `json:"price_min" doc:"Whole rupees; null clears it"`
+	PriceMax          *int            `json:"price_max" doc:"Whole rupees, at least price_min; null clears it"`
+	Latitude          *float64        `json:"latitude" doc:"Set or clear together with longitude"`
+	Longitude         *float64        `json:"longitude"`
+	DailyCapacity     *int            `json:"daily_capacity" doc:"Events a day, at least 1"`
+}
+
+// vendorPatchFields are the members PATCH /vendor/me accepts.
+var vendorPatchFields = map[string]patchField{
+	"business_name":       {column: "business_name", parse: textField(true, 120)},
+	"category":            {parse: textField(true, 80)},
+	"categories":          {clear: []string{}, parse: categoriesField},
+	"city":                {parse: textField(true, 80)},
+	"bio":                 {column: "bio", clear: "", parse: textField(false, 2000)},
+	"whatsapp_link":       {column: "whatsapp_link", parse: textField(true, 200)},
+	"portfolio_image_url": {column: "portfolio_image_url", clear: nullValue, parse: urlField},
+	"gallery_images":      {column: "gallery_images", clear: []string{}, parse: galleryImagesField},
+	"portfolio_files":     {column: "portfolio_files", clear: []PortfolioFile{}, parse: portfolioFilesField},
+	"price_min":           {column: "price_min", clear: nullValue, parse: priceField},
+	"price_max":           {column: "price_max", clear: nullValue, parse: priceField},
+	"latitude":            {column: "latitude", clear: nullValue, parse: coordinateField(-90, 90)},
+	"longitude":           {column: "longitude", clear: nullValue, parse: coordinateField(-180, 180)},
+	"daily_capacity":      {column: "daily_capacity", parse: capacityField},
+}
 
+// PortfolioFile is one entry of a vendor's portfolio_files.
+type PortfolioFile struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+func priceField(raw json.RawMessage) (any, error) {
+	var price int
+	if err := json.Unmarshal(raw, &price); err != nil || price < 0 {
+		return nil, errors.New("must be a whole number of rupees, not negative")
+	}
+	return price, nil
+}
+
+func galleryImagesField(raw json.RawMessage) (any, error) {
+	var urls []string
+	if err := json.Unmarshal(raw, &urls); err != nil {
+		return nil, errors.New("must be an array of URLs")
+	}
+	if len(urls) > 25 {
+		return nil, errors.New("at most 25 images")
+	}
+	for _, u := range urls {
+		if !isHTTPURL(u) {
+			return nil, fmt.Errorf("%q is not an http or https URL", u)
+		}
+	}
+	return urls, nil
+}
+
+func portfolioFilesField(raw json.RawMessage) (any, error) {
+	var files []PortfolioFile
+	if err := json.Unmarshal(raw, &files); err != nil {
+		return nil, errors.New("must be an array of {name, url} objects")
+	}
+	if len(files) > 20 {
+		return nil, errors.New("at most 20 files")
+	}
+	for _, f := range files {
+		if strings.TrimSpace(f.Name) == "" || !isHTTPURL(f.URL) {
+			return nil, errors.New("every file needs a name and an http or https URL")
+		}
+	}
+	return files, nil
+}
+
+// PatchVendor applies an RFC 7396 merge patch to the caller's vendor profile:
+// members left out keep their value and null clears one. Unlike PUT it never
+// touches media the client did not mention.
+func (h *VendorHandler) PatchVendor(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !requireIfMatch(c, h.Config.LegacyRoutesSunset) {
+		return
+	}
+	patch, ok := bindMergePatch(c, vendorPatchFields, 2)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	// Categories are resolved here, as they live in their own table
+	var main *vendorCategory
+	if ref, ok := patch.values["category"].(string); ok {
+		cats, ok := resolveCategories(c, ctx, []string{ref})
+		if !ok {
+			return
+		}
+		main = &cats[0]
+	}
+	var others []vendorCategory
+	if refs, ok := patch.values["categories"].([]string); ok {
+		if others, ok = resolveCategories(c, ctx, refs); !ok {
+			return
+		}
+	}
+	if err := setPatchCity(ctx, &patch); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to update vendor profile")
+		return
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var vendorID string
+	var version int
+	err = tx.QueryRow(ctx, "SELECT id, version FROM vendor_profiles WHERE owner_user_id = $1 AND deleted_at IS NULL FOR UPDATE", userID).Scan(&vendorID, &version)
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Vendor profile not found")
+		return
+	}
+	if !ifMatches(c, version) {
+		return
+	}
+	if main != nil || others != nil {
+		if err := setVendorCategories(ctx, tx, vendorID, main, others); err != nil {
+			fail(c, err, http.StatusInternalServerError, "Failed to update vendor profile")
+			return
+		}
+	}
+
+	sets := append(patch.sets, "updated_at = now()", "version = version + 1")
+	profile, version, err := scanMyVendorProfile(tx.QueryRow(ctx, `
+		UPDATE vendor_profiles vp SET `+strings.Join(sets, ", ")+`
+		WHERE id = $1
+		RETURNING `+myVendorProfileColumns,
+		append([]any{vendorID}, patch.args...)...))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.ConstraintName == "vendor_profiles_price_range_check" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "price_max must not be below price_min"})
+		return
+	}
+	if errors.As(err, &pgErr) && pgErr.ConstraintName == "vendor_profiles_coordinates_check" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "latitude and longitude must be set together"})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update vendor profile: " + err.Error()})
+		fail(c, err, http.StatusInternalServerError, "Failed to update vendor profile")
 		return
 	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+	h.Cache.Invalidate()
 
-	c.JSON(http.StatusOK, gin.H{"message": "Vendor profile updated successfully"})
+	c.Header("ETag", versionETag(version))
+	c.JSON(http.StatusOK, profile)
 }
 
 // UploadGalleryImage adds an image to the vendor's gallery
@@ -279,11 +605,14 @@ func (h *VendorHandler) UploadGalleryImage(c *gin.Context) {
 	vendorID := c.Param("id")
 	userID := c.MustGet("userID").(string)
 
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
 	// Validate ownership
 	var ownerID string
-	err := db.Pool.QueryRow(context.TODO(), "SELECT owner_user_id FROM vendor_profiles WHERE id=$1", vendorID).Scan(&ownerID)
+	err := db.Pool.QueryRow(ctx, "SELECT owner_user_id FROM vendor_profiles WHERE id=$1 AND deleted_at IS NULL", vendorID).Scan(&ownerID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Vendor not found"})
+		fail(c, err, http.StatusNotFound, "Vendor not found")
 		return
 	}
 	if ownerID != userID {
@@ -293,9 +622,9 @@ func (h *VendorHandler) UploadGalleryImage(c *gin.Context) {
 
 	// Check limit (25)
 	var count int
-	err = db.Pool.QueryRow(context.TODO(), "SELECT COUNT(*) FROM vendor_gallery_images WHERE vendor_id=$1", vendorID).Scan(&count)
+	err = db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM vendor_gallery_images WHERE vendor_id=$1", vendorID).Scan(&count)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		fail(c, err, http.StatusInternalServerError, "Database error")
 		return
 	}
 	if count >= 25 {
@@ -321,25 +650,52 @@ func (h *VendorHandler) UploadGalleryImage(c *gin.Context) {
 		return
 	}
 	defer file.Close()
+	if err := services.CheckImage(file); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File must be a JPEG, PNG or WebP image"})
+		return
+	}
 
-	// Upload
+	// Store the original; a job converts it to WebP and swaps the URL
+	mediaCtx, cancelMedia := withDeadline(c, h.Config.Timeouts.Media)
+	defer cancelMedia()
 	prefix := fmt.Sprintf("vendors/%s/gallery", vendorID)
-	url, err := h.MediaService.CompressAndUploadImage(file, fileHeader.Filename, prefix)
+	url, err := h.MediaService.UploadFile(mediaCtx, file, fileHeader.Filename, fileHeader.Header.Get("Content-Type"), prefix)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
+		fail(c, err, http.StatusInternalServerError, "Failed to upload image")
 		return
 	}
 
 	// Insert into DB
-	_, err = db.Pool.Exec(context.TODO(),
-		"INSERT INTO vendor_gallery_images (vendor_id, image_url, sort_order) VALUES ($1, $2, $3)",
-		vendorID, url, count+1) // Simple sort order
+	tx, err := db.Pool.Begin(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image metadata"})
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var imageID string
+	err = tx.QueryRow(ctx,
+		"INSERT INTO vendor_gallery_images (vendor_id, image_url, sort_order) VALUES ($1, $2, $3) RETURNING id",
+		vendorID, url, count+1).Scan(&imageID) // Simple sort order
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to save image metadata")
+		return
+	}
+	if err := jobs.EnqueueConvertImage(ctx, tx, jobs.ImageVendorGallery, imageID, url, prefix); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to save image metadata")
+		return
+	}
+	if err := touchVendor(ctx, tx, vendorID); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to save image metadata")
 		return
 	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+	h.Cache.Invalidate()
 
-	c.JSON(http.StatusOK, gin.H{"message": "Image uploaded", "url": url})
+	c.JSON(http.StatusAccepted, ImageUploadResponse{Message: "Image uploaded", URL: url, Status: "pending"})
 }
 
 // DeleteGalleryImage removes an image from the gallery
@@ -348,11 +704,14 @@ func (h *VendorHandler) DeleteGalleryImage(c *gin.Context) {
 	imageID := c.Param("imageID")
 	userID := c.MustGet("userID").(string)
 
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
 	// Validate ownership
 	var ownerID string
-	err := db.Pool.QueryRow(context.TODO(), "SELECT owner_user_id FROM vendor_profiles WHERE id=$1", vendorID).Scan(&ownerID)
+	err := db.Pool.QueryRow(ctx, "SELECT owner_user_id FROM vendor_profiles WHERE id=$1 AND deleted_at IS NULL", vendorID).Scan(&ownerID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Vendor not found"})
+		fail(c, err, http.StatusNotFound, "Vendor not found")
 		return
 	}
 	if ownerID != userID {
@@ -360,25 +719,42 @@ func (h *VendorHandler) DeleteGalleryImage(c *gin.Context) {
 		return
 	}
 
-	// Get URL to delete from R2
+	// Get URL to delete from storage
 	var url string
-	err = db.Pool.QueryRow(context.TODO(), "SELECT image_url FROM vendor_gallery_images WHERE id=$1 AND vendor_id=$2", imageID, vendorID).Scan(&url)
+	err = db.Pool.QueryRow(ctx, "SELECT image_url FROM vendor_gallery_images WHERE id=$1 AND vendor_id=$2", imageID, vendorID).Scan(&url)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+		fail(c, err, http.StatusNotFound, "Image not found")
 		return
 	}
 
-	// Delete from R2
-	_ = h.MediaService.DeleteFile(url)
+	// Delete from DB and queue the R2 delete in the same transaction
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
 
-	// Delete from DB
-	_, err = db.Pool.Exec(context.TODO(), "DELETE FROM vendor_gallery_images WHERE id=$1", imageID)
+	_, err = tx.Exec(ctx, "DELETE FROM vendor_gallery_images WHERE id=$1", imageID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete image record"})
+		fail(c, err, http.StatusInternalServerError, "Failed to delete image record")
+		return
+	}
+	if err := jobs.EnqueueDeleteMedia(ctx, tx, url); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to delete image record")
 		return
 	}
+	if err := touchVendor(ctx, tx, vendorID); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to delete image record")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+	h.Cache.Invalidate()
 
-	c.JSON(http.StatusOK, gin.H{"message": "Image deleted"})
+	c.JSON(http.StatusOK, MessageResponse{Message: "Image deleted"})
 }
 
 // UploadPortfolioFile adds a PDF to the vendor's portfolio
@@ -386,11 +762,14 @@ func (h *VendorHandler) UploadPortfolioFile(c *gin.Context) {
 	vendorID := c.Param("id")
 	userID := c.MustGet("userID").(string)
 
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
 	// Validate ownership
 	var ownerID string
-	err := db.Pool.QueryRow(context.TODO(), "SELECT owner_user_id FROM vendor_profiles WHERE id=$1", vendorID).Scan(&ownerID)
+	err := db.Pool.QueryRow(ctx, "SELECT owner_user_id FROM vendor_profiles WHERE id=$1 AND deleted_at IS NULL", vendorID).Scan(&ownerID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Vendor not found"})
+		fail(c, err, http.StatusNotFound, "Vendor not found")
 		return
 	}
 	if ownerID != userID {
@@ -400,9 +779,9 @@ func (h *VendorHandler) UploadPortfolioFile(c *gin.Context) {
 
 	// Check limit (20)
 	var count int
-	err = db.Pool.QueryRow(context.TODO(), "SELECT COUNT(*) FROM vendor_portfolio_files WHERE vendor_id=$1", vendorID).Scan(&count)
+	err = db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM vendor_portfolio_files WHERE vendor_id=$1", vendorID).Scan(&count)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		fail(c, err, http.StatusInternalServerError, "Database error")
 		return
 	}
 	if count >= 20 {
@@ -436,10 +815,12 @@ func (h *VendorHandler) UploadPortfolioFile(c *gin.Context) {
 	defer file.Close()
 
 	// Upload (Raw file, no compression for PDF)
+	mediaCtx, cancelMedia := withDeadline(c, h.Config.Timeouts.Media)
+	defer cancelMedia()
 	prefix := fmt.Sprintf("vendors/%s/portfolio", vendorID)
-	url, err := h.MediaService.UploadFile(file, fileHeader.Filename, "application/pdf", prefix)
+	url, err := h.MediaService.UploadFile(mediaCtx, file, fileHeader.Filename, "application/pdf", prefix)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload file"})
+		fail(c, err, http.StatusInternalServerError, "Failed to upload file")
 		return
 	}
 
@@ -449,15 +830,31 @@ func (h *VendorHandler) UploadPortfolioFile(c *gin.Context) {
 	}
 
 	// Insert into DB
-	_, err = db.Pool.Exec(context.TODO(),
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
 		"INSERT INTO vendor_portfolio_files (vendor_id, file_url, title, sort_order) VALUES ($1, $2, $3, $4)",
 		vendorID, url, title, count+1)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file metadata"})
+		fail(c, err, http.StatusInternalServerError, "Failed to save file metadata")
 		return
 	}
+	if err := touchVendor(ctx, tx, vendorID); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to save file metadata")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+	h.Cache.Invalidate()
 
-	c.JSON(http.StatusOK, gin.H{"message": "File uploaded", "url": url})
+	c.JSON(http.StatusOK, UploadResponse{Message: "File uploaded", URL: url})
 }
 
 // DeletePortfolioFile removes a file from the portfolio
@@ -466,11 +863,14 @@ func (h *VendorHandler) DeletePortfolioFile(c *gin.Context) {
 	fileID := c.Param("fileID")
 	userID := c.MustGet("userID").(string)
 
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
 	// Validate ownership
 	var ownerID string
-	err := db.Pool.QueryRow(context.TODO(), "SELECT owner_user_id FROM vendor_profiles WHERE id=$1", vendorID).Scan(&ownerID)
+	err := db.Pool.QueryRow(ctx, "SELECT owner_user_id FROM vendor_profiles WHERE id=$1 AND deleted_at IS NULL", vendorID).Scan(&ownerID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Vendor not found"})
+		fail(c, err, http.StatusNotFound, "Vendor not found")
 		return
 	}
 	if ownerID != userID {
@@ -478,23 +878,63 @@ func (h *VendorHandler) DeletePortfolioFile(c *gin.Context) {
 		return
 	}
 
-	// Get URL to delete from R2
+	// Get URL to delete from storage
 	var url string
-	err = db.Pool.QueryRow(context.TODO(), "SELECT file_url FROM vendor_portfolio_files WHERE id=$1 AND vendor_id=$2", fileID, vendorID).Scan(&url)
+	err = db.Pool.QueryRow(ctx, "SELECT file_url FROM vendor_portfolio_files WHERE id=$1 AND vendor_id=$2", fileID, vendorID).Scan(&url)
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "File not found")
+		return
+	}
+
+	// Delete from DB and queue the R2 delete in the same transaction
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, "DELETE FROM vendor_portfolio_files WHERE id=$1", fileID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		fail(c, err, http.StatusInternalServerError, "Failed to delete file record")
 		return
 	}
+	if err := jobs.EnqueueDeleteMedia(ctx, tx, url); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to delete file record")
+		return
+	}
+	if err := touchVendor(ctx, tx, vendorID); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to delete file record")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+	h.Cache.Invalidate()
 
-	// Delete from R2
-	_ = h.MediaService.DeleteFile(url)
+	c.JSON(http.StatusOK, MessageResponse{Message: "File deleted"})
+}
 
-	// Delete from DB
-	_, err = db.Pool.Exec(context.TODO(), "DELETE FROM vendor_portfolio_files WHERE id=$1", fileID)
+// DeleteMyProfile deletes the current user's vendor profile. Onboarding again
+// is not possible until it is purged; an admin can restore it meanwhile.
+func (h *VendorHandler) DeleteMyProfile(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	var vendorID string
+	err := db.Pool.QueryRow(ctx, "SELECT id FROM vendor_profiles WHERE owner_user_id = $1 AND deleted_at IS NULL", userID).Scan(&vendorID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete file record"})
+		fail(c, err, http.StatusNotFound, "Vendor profile not found")
+		return
+	}
+
+	if !softDelete(c, ctx, trash.Vendors, vendorID) {
 		return
 	}
+	h.Cache.Invalidate()
 
-	c.JSON(http.StatusOK, gin.H{"message": "File deleted"})
+	c.JSON(http.StatusOK, MessageResponse{Message: "Vendor profile deleted"})
 }
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bventy/backend/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// AvailabilityDay is a day on which a vendor's availability differs from its
+// daily capacity.
+type AvailabilityDay struct {
+	Date     string `json:"date" doc:"YYYY-MM-DD"`
+	Status   string `json:"status" doc:"blocked, full, partial (some bookings) or available"`
+	Blocked  bool   `json:"blocked"`
+	Booked   int    `json:"booked"`
+	Capacity int    `json:"capacity" doc:"The day's own capacity, or daily_capacity"`
+	Note     string `json:"note"`
+}
+
+type VendorAvailability struct {
+	DailyCapacity int               `json:"daily_capacity" doc:"Events a day the vendor takes; set it with PATCH /vendor/me"`
+	Days          []AvailabilityDay `json:"days" doc:"Days in the range that have an entry, by date; other days are free"`
+}
+
+type SetAvailabilityRequest struct {
+	Blocked  bool   `json:"blocked" doc:"Unavailable whatever the bookings"`
+	Booked   int    `json:"booked" binding:"min=0" doc:"Events already booked that day"`
+	Capacity *int   `json:"capacity" binding:"omitempty,min=1" doc:"Replaces daily_capacity for the day"`
+	Note     string `json:"note" binding:"max=200"`
+}
+
+// maxAvailabilityDays caps the range GET /vendor/me/availability returns.
+const maxAvailabilityDays = 366
+
+// vendorBusy is an SQL condition that holds when the vendor_profiles row
+// aliased vendor is blocked or fully booked on date, an SQL date expression.
+func vendorBusy(vendor, date string) string {
+	return `EXISTS (
+		SELECT 1 FROM vendor_availability va
+		WHERE va.vendor_id = ` + vendor + `.id AND va.date = ` + date + `
+		  AND (va.blocked OR va.booked >= COALESCE(va.capacity, ` + vendor + `.daily_capacity)))`
+}
+
+// availabilityColumns are read by scanAvailabilityDay, from
+// vendor_availability va joined to vendor_profiles vp.
+const availabilityColumns = `va.date, va.blocked, va.booked, COALESCE(va.capacity, vp.daily_capacity), va.note`
+
+func scanAvailabilityDay(row pgx.Row) (AvailabilityDay, error) {
+	var d AvailabilityDay
+	var date time.Time
+	err := row.Scan(&date, &d.Blocked, &d.Booked, &d.Capacity, &d.Note)
+	d.Date = date.Format(time.DateOnly)
+	switch {
+	case d.Blocked:
+		d.Status = "blocked"
+	case d.Booked >= d.Capacity:
+		d.Status = "full"
+	case d.Booked > 0:
+		d.Status = "partial"
+	default:
+		d.Status = "available"
+	}
+	return d, err
+}
+
+// parseDate reads a YYYY-MM-DD value, answering 400 for anything else.
+func parseDate(c *gin.Context, name, value string) (time.Time, bool) {
+	d, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be a date, YYYY-MM-DD"})
+		return d, false
+	}
+	return d, true
+}
+
+func capacityField(raw json.RawMessage) (any, error) {
+	var n int32
+	if err := json.Unmarshal(raw, &n); err != nil || n < 1 {
+		return nil, errors.New("must be a whole number, at least 1")
+	}
+	return n, nil
+}
+
+// GetMyAvailability returns the caller's daily capacity and the days from
+// ?from= (today by default) to ?to= (90 days on) that have an entry.
+func (h *VendorHandler) GetMyAvailability(c *gin.Context) {
+	from := time.Now().UTC().Truncate(24 * time.Hour)
+	if s := c.Query("from"); s != "" {
+		var ok bool
+		if from, ok = parseDate(c, "from", s); !ok {
+			return
+		}
+	}
+	to := from.AddDate(0, 0, 90)
+	if s := c.Query("to"); s != "" {
+		var ok bool
+		if to, ok = parseDate(c, "to", s); !ok {
+			return
+		}
+	}
+	if to.Before(from) || to.Sub(from) > maxAvailabilityDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be on or after from, and at most 366 days on"})
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
+
+	var res VendorAvailability
+	var vendorID string
+	err := db.Pool.QueryRow(ctx, `SELECT id, daily_capacity FROM vendor_profiles WHERE owner_user_id = $1 AND deleted_at IS NULL`,
+		c.GetString("userID")).Scan(&vendorID, &res.DailyCapacity)
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Vendor profile not found")
+		return
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+availabilityColumns+`
+		FROM vendor_availability va JOIN vendor_profiles vp ON vp.id = va.vendor_id
+		WHERE va.vendor_id = $1 AND va.date BETWEEN $2 AND $3
+		ORDER BY va.date`, vendorID, from, to)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch availability")
+		return
+	}
+	res.Days, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (AvailabilityDay, error) { return scanAvailabilityDay(row) })
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch availability")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// SetAvailability records the caller's availability on :date, replacing any
+// earlier entry for it.
+func (h *VendorHandler) SetAvailability(c *gin.Context) {
+	date, ok := parseDate(c, "date", c.Param("date"))
+	if !ok {
+		return
+	}
+	var req SetAvailabilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	vendorID, ok := lockMyVendor(c, ctx, tx)
+	if !ok {
+		return
+	}
+	day, err := scanAvailabilityDay(tx.QueryRow(ctx, `
+		WITH va AS (
+			INSERT INTO vendor_availability (vendor_id, date, blocked, booked, capacity, note)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (vendor_id, date) DO UPDATE
+			SET blocked = EXCLUDED.blocked, booked = EXCLUDED.booked, capacity = EXCLUDED.capacity,
+			    note = EXCLUDED.note, updated_at = now()
+			RETURNING *
+		)
+		SELECT `+availabilityColumns+` FROM va JOIN vendor_profiles vp ON vp.id = va.vendor_id`,
+		vendorID, date, req.Blocked, req.Booked, req.Capacity, req.Note))
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to save availability")
+		return
+	}
+	// Searches filtered by date change with it.
+	if err := touchVendor(ctx, tx, vendorID); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to save availability")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+	h.Cache.Invalidate()
+
+	c.JSON(http.StatusOK, day)
+}
+
+// ClearAvailability removes the caller's entry for :date, which frees the day.
+func (h *VendorHandler) ClearAvailability(c *gin.Context) {
+	date, ok := parseDate(c, "date", c.Param("date"))
+	if !ok {
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	vendorID, ok := lockMyVendor(c, ctx, tx)
+	if !ok {
+		return
+	}
+	tag, err := tx.Exec(ctx, `DELETE FROM vendor_availability WHERE vendor_id = $1 AND date = $2`, vendorID, date)
+	if err != nil || tag.RowsAffected() == 0 {
+		fail(c, err, http.StatusNotFound, "No availability entry for this date")
+		return
+	}
+	if err := touchVendor(ctx, tx, vendorID); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to clear availability")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+	h.Cache.Invalidate()
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Availability cleared"})
+}
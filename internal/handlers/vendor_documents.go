@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/jobs"
+	"github.com/bventy/backend/internal/moderation"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// VendorDocument is a verification document a vendor uploaded. The file
+// itself is private; admins reach it through AdminVendorDocument.URL.
+type VendorDocument struct {
+	ID          string     `json:"id"`
+	Kind        string     `json:"kind" doc:"gst_certificate, identity, address_proof or other"`
+	Filename    string     `json:"filename"`
+	ContentType string     `json:"content_type"`
+	SizeBytes   int64      `json:"size_bytes"`
+	Status      string     `json:"status" doc:"pending, accepted or rejected"`
+	ReviewNote  string     `json:"review_note" doc:"The admin's note, e.g. why it was rejected"`
+	ReviewedAt  *time.Time `json:"reviewed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" doc:"When the file was last replaced or reviewed"`
+}
+
+// VendorDocuments is a vendor's documents and what approval still needs.
+type VendorDocuments struct {
+	Required  []string         `json:"required" doc:"Kinds that must be accepted before the vendor can be approved"`
+	Missing   []string         `json:"missing" doc:"Required kinds not accepted yet"`
+	Documents []VendorDocument `json:"documents"`
+}
+
+// AdminVendorDocument adds a short-lived link to the file.
+type AdminVendorDocument struct {
+	VendorDocument
+	ReviewedBy   *string   `json:"reviewed_by"`
+	URL          string    `json:"url" doc:"Signed link to the file; fetch it again once it expires"`
+	URLExpiresAt time.Time `json:"url_expires_at"`
+}
+
+type AdminVendorDocuments struct {
+	Required  []string              `json:"required"`
+	Missing   []string              `json:"missing"`
+	Documents []AdminVendorDocument `json:"documents"`
+}
+
+type ReviewDocumentRequest struct {
+	Status string `json:"status" binding:"required" doc:"accepted or rejected"`
+	Note   string `json:"note" binding:"max=1000" doc:"Shown to the vendor; required when rejecting"`
+}
+
+// documentKinds are the vendor_documents kinds; the mandatory ones are
+// moderation.RequiredDocuments.
+var documentKinds = []string{"gst_certificate", "identity", "address_proof", "other"}
+
+// documentTypes are the content types a document may be uploaded as.
+var documentTypes = []string{"application/pdf", "image/jpeg", "image/png"}
+
+const maxDocumentSize = 5 * 1024 * 1024
+
+const documentColumns = `id, kind, filename, content_type, size_bytes, status, review_note, reviewed_at, created_at, updated_at`
+
+// scanDocument scans documentColumns, then any extra columns into extra.
+func scanDocument(row pgx.Row, extra ...any) (VendorDocument, error) {
+	var d VendorDocument
+	err := row.Scan(append([]any{&d.ID, &d.Kind, &d.Filename, &d.ContentType, &d.SizeBytes, &d.Status, &d.ReviewNote,
+		&d.ReviewedAt, &d.CreatedAt, &d.UpdatedAt}, extra...)...)
+	return d, err
+}
+
+// documentOrder lists a vendor's documents in documentKinds order.
+const documentOrder = `ORDER BY array_position($2::text[], kind)`
+
+// ListMyDocuments lists the caller's verification documents.
+func (h *VendorHandler) ListMyDocuments(c *gin.Context) {
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
+
+	var vendorID string
+	err := db.Pool.QueryRow(ctx, `SELECT id FROM vendor_profiles WHERE owner_user_id = $1 AND deleted_at IS NULL`,
+		c.GetString("userID")).Scan(&vendorID)
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Vendor profile not found")
+		return
+	}
+	rows, err := db.Pool.Query(ctx, `SELECT `+documentColumns+` FROM vendor_documents WHERE vendor_id = $1 `+documentOrder,
+		vendorID, documentKinds)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch documents")
+		return
+	}
+	docs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (VendorDocument, error) { return scanDocument(row) })
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch documents")
+		return
+	}
+	missing, err := moderation.MissingDocuments(ctx, db.Pool, vendorID)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch documents")
+		return
+	}
+
+	c.JSON(http.StatusOK, VendorDocuments{Required: moderation.RequiredDocuments, Missing: missing, Documents: docs})
+}
+
+// UploadDocument stores the multipart "file" as the caller's document of
+// form field "kind", replacing any earlier one. A replaced document goes
+// back to pending review.
+func (h *VendorHandler) UploadDocument(c *gin.Context) {
+	kind := c.PostForm("kind")
+	if !slices.Contains(documentKinds, kind) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be gst_certificate, identity, address_proof or other"})
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file provided"})
+		return
+	}
+	contentType := fileHeader.Header.Get("Content-Type")
+	if !slices.Contains(documentTypes, contentType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only PDF, JPEG and PNG files allowed"})
+		return
+	}
+	if fileHeader.Size > maxDocumentSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File too large (max 5MB)"})
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	var vendorID string
+	err = db.Pool.QueryRow(ctx, `SELECT id FROM vendor_profiles WHERE owner_user_id = $1 AND deleted_at IS NULL`,
+		c.GetString("userID")).Scan(&vendorID)
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Vendor profile not found")
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open file"})
+		return
+	}
+	defer file.Close()
+
+	mediaCtx, cancelMedia := withDeadline(c, h.Config.Timeouts.Media)
+	defer cancelMedia()
+	key, err := h.MediaService.UploadPrivateFile(mediaCtx, file, fileHeader.Filename, contentType, fmt.Sprintf("vendors/%s/documents", vendorID))
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to upload file")
+		return
+	}
+	// Don't leave an unreferenced copy of someone's ID behind if the row
+	// is never written.
+	saved := false
+	defer func() {
+		if !saved {
+			h.MediaService.DeletePrivateFile(context.WithoutCancel(ctx), key)
+		}
+	}()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	vendorID, ok := lockMyVendor(c, ctx, tx)
+	if !ok {
+		return
+	}
+	var previous *string
+	err = tx.QueryRow(ctx, `SELECT object_key FROM vendor_documents WHERE vendor_id = $1 AND kind = $2`, vendorID, kind).Scan(&previous)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		fail(c, err, http.StatusInternalServerError, "Failed to save document")
+		return
+	}
+	doc, err := scanDocument(tx.QueryRow(ctx, `
+		INSERT INTO vendor_documents (vendor_id, kind, object_key, filename, content_type, size_bytes)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (vendor_id, kind) DO UPDATE SET
+			object_key = EXCLUDED.object_key, filename = EXCLUDED.filename,
+			content_type = EXCLUDED.content_type, size_bytes = EXCLUDED.size_bytes,
+			status = 'pending', review_note = '', reviewed_by = NULL, reviewed_at = NULL, updated_at = now()
+		RETURNING `+documentColumns,
+		vendorID, kind, key, filepath.Base(fileHeader.Filename), contentType, fileHeader.Size))
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to save document")
+		return
+	}
+	if previous != nil {
+		if err := jobs.EnqueueDeletePrivateMedia(ctx, tx, *previous); err != nil {
+			fail(c, err, http.StatusInternalServerError, "Failed to save document")
+			return
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+	saved = true
+
+	c.JSON(http.StatusCreated, doc)
+}
+
+// DeleteDocument removes one of the caller's documents. Accepted documents
+// stay, since approval was based on them.
+func (h *VendorHandler) DeleteDocument(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	vendorID, ok := lockMyVendor(c, ctx, tx)
+	if !ok {
+		return
+	}
+	var key, status string
+	err = tx.QueryRow(ctx, `SELECT object_key, status FROM vendor_documents WHERE id = $1 AND vendor_id = $2`, id, vendorID).Scan(&key, &status)
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Document not found")
+		return
+	}
+	if status == "accepted" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Accepted documents cannot be deleted; upload a replacement instead"})
+		return
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM vendor_documents WHERE id = $1`, id); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to delete document")
+		return
+	}
+	if err := jobs.EnqueueDeletePrivateMedia(ctx, tx, key); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to delete document")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Document deleted"})
+}
+
+// ListVendorDocuments lists vendor :id's documents, each with a signed link
+// valid for Config.DocumentURLTTL.
+func (h *AdminHandler) ListVendorDocuments(c *gin.Context) {
+	vendorID := c.Param("id")
+	if _, err := uuid.Parse(vendorID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vendor not found"})
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
+
+	var exists bool
+	err := db.Pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM vendor_profiles WHERE id = $1 AND deleted_at IS NULL)`, vendorID).Scan(&exists)
+	if err != nil || !exists {
+		fail(c, err, http.StatusNotFound, "Vendor not found")
+		return
+	}
+	rows, err := db.Pool.Query(ctx, `SELECT `+documentColumns+`, object_key, reviewed_by FROM vendor_documents WHERE vendor_id = $1 `+documentOrder,
+		vendorID, documentKinds)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch documents")
+		return
+	}
+	expires := time.Now().Add(h.Config.DocumentURLTTL)
+	docs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (AdminVendorDocument, error) {
+		var d AdminVendorDocument
+		var key string
+		var err error
+		d.VendorDocument, err = scanDocument(row, &key, &d.ReviewedBy)
+		if err != nil {
+			return d, err
+		}
+		d.URL, err = h.MediaService.SignedURL(ctx, key, h.Config.DocumentURLTTL)
+		d.URLExpiresAt = expires
+		return d, err
+	})
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch documents")
+		return
+	}
+	missing, err := moderation.MissingDocuments(ctx, db.Pool, vendorID)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch documents")
+		return
+	}
+
+	c.JSON(http.StatusOK, AdminVendorDocuments{Required: moderation.RequiredDocuments, Missing: missing, Documents: docs})
+}
+
+// ReviewDocument accepts or rejects one of vendor :id's documents. It locks
+// the vendor like moderation.Approve does, so an approval never sees a
+// document halfway through review.
+func (h *AdminHandler) ReviewDocument(c *gin.Context) {
+	vendorID, docID := c.Param("id"), c.Param("documentID")
+	if _, err := uuid.Parse(vendorID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	}
+	if _, err := uuid.Parse(docID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	}
+	var req ReviewDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Status != "accepted" && req.Status != "rejected" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be accepted or rejected"})
+		return
+	}
+	if req.Status == "rejected" && req.Note == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A note is required when rejecting a document"})
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var locked string
+	err = tx.QueryRow(ctx, `SELECT id FROM vendor_profiles WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, vendorID).Scan(&locked)
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Document not found")
+		return
+	}
+	doc, err := scanDocument(tx.QueryRow(ctx, `
+		UPDATE vendor_documents
+		SET status = $3, review_note = $4, reviewed_by = $5, reviewed_at = now(), updated_at = now()
+		WHERE id = $1 AND vendor_id = $2
+		RETURNING `+documentColumns,
+		docID, vendorID, req.Status, req.Note, c.GetString("userID")))
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Document not found")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+
+	c.JSON(http.StatusOK, doc)
+}
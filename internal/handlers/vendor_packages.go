@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bventy/backend/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// VendorPackage is a priced offer on a vendor's page.
+type VendorPackage struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Inclusions  []string  `json:"inclusions"`
+	PriceMin    int       `json:"price_min" doc:"Whole units of currency"`
+	PriceMax    *int      `json:"price_max" doc:"Top of the range; null for a fixed price"`
+	Unit        string    `json:"unit" doc:"per_event, per_guest, per_hour or per_day"`
+	Currency    string    `json:"currency" doc:"ISO 4217 code"`
+	SortOrder   int       `json:"sort_order"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type CreatePackageRequest struct {
+	Name        string   `json:"name" binding:"required,max=120"`
+	Description string   `json:"description" binding:"max=2000"`
+	Inclusions  []string `json:"inclusions" doc:"Up to 30 items, each at most 200 characters"`
+	PriceMin    *int     `json:"price_min" binding:"required,min=0" doc:"The price, or the bottom of the range"`
+	PriceMax    *int     `json:"price_max" binding:"omitempty,min=0" doc:"Top of the range; leave out for a fixed price"`
+	Unit        string   `json:"unit" doc:"per_event (default), per_guest, per_hour or per_day"`
+	Currency    string   `json:"currency" doc:"ISO 4217 code; INR when empty"`
+	SortOrder   int      `json:"sort_order"`
+}
+
+// PackagePatch documents the PATCH /vendor/me/packages/:id body, read as a
+// merge patch through packagePatchFields.
+type PackagePatch struct {
+	Name        *string  `json:"name"`
+	Description *string  `json:"description" doc:"null clears it"`
+	Inclusions  []string `json:"inclusions" doc:"Replaces the list, null empties it"`
+	PriceMin    *int     `json:"price_min"`
+	PriceMax    *int     `json:"price_max" doc:"null makes the price fixed"`
+	Unit        *string  `json:"unit"`
+	Currency    *string  `json:"currency"`
+	SortOrder   *int     `json:"sort_order"`
+}
+
+var packagePatchFields = map[string]patchField{
+	"name":        {column: "name", parse: textField(true, 120)},
+	"description": {column: "description", clear: "", parse: textField(false, 2000)},
+	"inclusions":  {column: "inclusions", clear: []string{}, parse: inclusionsField},
+	"price_min":   {column: "price_min", parse: priceField},
+	"price_max":   {column: "price_max", clear: nullValue, parse: priceField},
+	"unit":        {column: "unit", parse: unitField},
+	"currency":    {column: "currency", parse: currencyField},
+	"sort_order":  {column: "sort_order", parse: sortOrderField},
+}
+
+// maxVendorPackages caps the packages one vendor lists.
+const maxVendorPackages = 20
+
+var (
+	packageUnits    = []string{"per_event", "per_guest", "per_hour", "per_day"}
+	currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// vendorStartingPrice is the lowest rupee price of vendor vp: its price_min
+// or a package's. LEAST skips NULLs, so either may be missing.
+const vendorStartingPrice = `LEAST(vp.price_min,
+	(SELECT min(pk.price_min) FROM vendor_packages pk WHERE pk.vendor_id = vp.id AND pk.currency = 'INR'))`
+
+func normalizeInclusions(items []string) ([]string, error) {
+	if len(items) > 30 {
+		return nil, errors.New("at most 30 inclusions")
+	}
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" || len([]rune(item)) > 200 {
+			return nil, errors.New("inclusions must be 1-200 characters")
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+func inclusionsField(raw json.RawMessage) (any, error) {
+	var items []string
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, errors.New("must be an array of strings")
+	}
+	return normalizeInclusions(items)
+}
+
+func unitField(raw json.RawMessage) (any, error) {
+	var unit string
+	if err := json.Unmarshal(raw, &unit); err != nil || !slices.Contains(packageUnits, unit) {
+		return nil, errors.New("must be " + strings.Join(packageUnits, ", "))
+	}
+	return unit, nil
+}
+
+func currencyField(raw json.RawMessage) (any, error) {
+	var currency string
+	if err := json.Unmarshal(raw, &currency); err != nil {
+		return nil, errors.New("must be a string")
+	}
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !currencyPattern.MatchString(currency) {
+		return nil, errors.New("must be a three-letter ISO 4217 code")
+	}
+	return currency, nil
+}
+
+const packageColumns = `id, name, description, inclusions, price_min, price_max, unit, currency, sort_order, created_at, updated_at`
+
+func scanPackage(row pgx.Row) (VendorPackage, error) {
+	var p VendorPackage
+	err := row.Scan(&p.ID, &p.Name, &p.Description, &p.Inclusions, &p.PriceMin, &p.PriceMax, &p.Unit, &p.Currency,
+		&p.SortOrder, &p.CreatedAt, &p.UpdatedAt)
+	return p, err
+}
+
+// vendorPackages lists vendorID's packages in display order.
+func vendorPackages(ctx context.Context, vendorID string) ([]VendorPackage, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+packageColumns+` FROM vendor_packages
+		WHERE vendor_id = $1
+		ORDER BY sort_order, price_min, name`, vendorID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (VendorPackage, error) { return scanPackage(row) })
+}
+
+// lockMyVendor locks the caller's vendor profile in tx, so writes to the
+// vendor's rows (and checks on them, like the package limit) run one at a
+// time, and returns its id. It writes a 404 when the caller has no profile.
+func lockMyVendor(c *gin.Context, ctx context.Context, tx pgx.Tx) (string, bool) {
+	var vendorID string
+	err := tx.QueryRow(ctx, `SELECT id FROM vendor_profiles WHERE owner_user_id = $1 AND deleted_at IS NULL FOR UPDATE`,
+		c.GetString("userID")).Scan(&vendorID)
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Vendor profile not found")
+		return "", false
+	}
+	return vendorID, true
+}
+
+// packageWriteFailed answers the errors Postgres raises for a bad package
+// write and reports whether it did.
+func packageWriteFailed(c *gin.Context, err error) bool {
+	var pgErr *pgconn.PgError
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, pgx.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Package not found"})
+	case errors.As(err, &pgErr) && pgErr.ConstraintName == "vendor_packages_price_check":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "price_max must not be below price_min"})
+	default:
+		fail(c, err, http.StatusInternalServerError, "Failed to save package")
+	}
+	return true
+}
+
+// ListMyPackages lists the caller's packages in display order.
+func (h *VendorHandler) ListMyPackages(c *gin.Context) {
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
+
+	var vendorID string
+	err := db.Pool.QueryRow(ctx, `SELECT id FROM vendor_profiles WHERE owner_user_id = $1 AND deleted_at IS NULL`,
+		c.GetString("userID")).Scan(&vendorID)
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Vendor profile not found")
+		return
+	}
+	packages, err := vendorPackages(ctx, vendorID)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch packages")
+		return
+	}
+
+	c.JSON(http.StatusOK, packages)
+}
+
+func (h *VendorHandler) CreatePackage(c *gin.Context) {
+	var req CreatePackageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must not be empty"})
+		return
+	}
+	inclusions, err := normalizeInclusions(req.Inclusions)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Unit == "" {
+		req.Unit = "per_event"
+	}
+	if !slices.Contains(packageUnits, req.Unit) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unit must be " + strings.Join(packageUnits, ", ")})
+		return
+	}
+	req.Currency = strings.ToUpper(strings.TrimSpace(req.Currency))
+	if req.Currency == "" {
+		req.Currency = "INR"
+	}
+	if !currencyPattern.MatchString(req.Currency) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "currency must be a three-letter ISO 4217 code"})
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	vendorID, ok := lockMyVendor(c, ctx, tx)
+	if !ok {
+		return
+	}
+	var count int
+	if err := tx.QueryRow(ctx, `SELECT count(*) FROM vendor_packages WHERE vendor_id = $1`, vendorID).Scan(&count); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to save package")
+		return
+	}
+	if count >= maxVendorPackages {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Package limit reached (max %d)", maxVendorPackages)})
+		return
+	}
+
+	pkg, err := scanPackage(tx.QueryRow(ctx, `
+		INSERT INTO vendor_packages (vendor_id, name, description, inclusions, price_min, price_max, unit, currency, sort_order)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING `+packageColumns,
+		vendorID, req.Name, strings.TrimSpace(req.Description), inclusions, *req.PriceMin, req.PriceMax, req.Unit, req.Currency, req.SortOrder))
+	if packageWriteFailed(c, err) {
+		return
+	}
+	if err := touchVendor(ctx, tx, vendorID); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to save package")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+	h.Cache.Invalidate()
+
+	c.JSON(http.StatusCreated, pkg)
+}
+
+// PatchPackage applies a merge patch to one of the caller's packages.
+func (h *VendorHandler) PatchPackage(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Package not found"})
+		return
+	}
+	patch, ok := bindMergePatch(c, packagePatchFields, 3)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	vendorID, ok := lockMyVendor(c, ctx, tx)
+	if !ok {
+		return
+	}
+	sets := append(patch.sets, "updated_at = now()")
+	pkg, err := scanPackage(tx.QueryRow(ctx, `
+		UPDATE vendor_packages SET `+strings.Join(sets, ", ")+`
+		WHERE id = $1 AND vendor_id = $2
+		RETURNING `+packageColumns,
+		append([]any{id, vendorID}, patch.args...)...))
+	if packageWriteFailed(c, err) {
+		return
+	}
+	if err := touchVendor(ctx, tx, vendorID); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to save package")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+	h.Cache.Invalidate()
+
+	c.JSON(http.StatusOK, pkg)
+}
+
+func (h *VendorHandler) DeletePackage(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Package not found"})
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	vendorID, ok := lockMyVendor(c, ctx, tx)
+	if !ok {
+		return
+	}
+	tag, err := tx.Exec(ctx, `DELETE FROM vendor_packages WHERE id = $1 AND vendor_id = $2`, id, vendorID)
+	if err != nil || tag.RowsAffected() == 0 {
+		fail(c, err, http.StatusNotFound, "Package not found")
+		return
+	}
+	if err := touchVendor(ctx, tx, vendorID); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to delete package")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+	h.Cache.Invalidate()
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Package deleted"})
+}
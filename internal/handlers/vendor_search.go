@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bventy/backend/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// VendorSearchResponse is GET /vendors: a page of matching vendors, how many
+// match in all and, for each facet, how many vendors each value would give.
+type VendorSearchResponse struct {
+	Vendors    []PublicVendor `json:"vendors"`
+	NextCursor *string        `json:"next_cursor"`
+	Total      int            `json:"total"`
+	Facets     VendorFacets   `json:"facets"`
+}
+
+// VendorFacets are counted with every filter applied except the facet's own,
+// so picking a city still shows how many vendors the other cities have.
+type VendorFacets struct {
+	Categories []FacetCount `json:"categories"`
+	Cities     []FacetCount `json:"cities"`
+}
+
+type FacetCount struct {
+	Value string `json:"value" doc:"Category slug or city"`
+	Count int    `json:"count"`
+	Name  string `json:"name,omitempty" doc:"Category name"`
+}
+
+const (
+	// vendorFrom joins what the search conditions read. Vendors are placed
+	// at their own coordinates, or their city's.
+	vendorFrom = `FROM vendor_profiles vp
+		JOIN users u ON vp.owner_user_id = u.id
+		LEFT JOIN locations l ON l.id = vp.location_id`
+	// vendorRank needs q as $1.
+	vendorRank       = `ts_rank(vp.search_vector, websearch_to_tsquery('english', $1))`
+	vendorShortlists = `(SELECT count(*) FROM event_shortlisted_vendors esv JOIN events e ON e.id = esv.event_id
+		WHERE esv.vendor_id = vp.id AND e.deleted_at IS NULL)`
+)
+
+// vendorSorts maps the sort parameter to the list's ordering. relevance falls
+// back to name order without q.
+var vendorSorts = map[string]keyset{
+	"relevance":  {{expr: vendorRank, typ: "real", desc: true}, {expr: "vp.business_name", typ: "text"}, {expr: "vp.id", typ: "uuid"}},
+	"newest":     {{expr: "vp.created_at", typ: "timestamp", desc: true}, {expr: "vp.id", typ: "uuid", desc: true}},
+	"popularity": {{expr: vendorShortlists, typ: "bigint", desc: true}, {expr: "vp.business_name", typ: "text"}, {expr: "vp.id", typ: "uuid"}},
+	"name":       {{expr: "vp.business_name", typ: "text"}, {expr: "vp.id", typ: "uuid"}},
+}
+
+// vendorSearch is a parsed GET /vendors query.
+type vendorSearch struct {
+	q, category, city  string
+	minPrice, maxPrice *int
+	// unit keeps vendors with a package priced per unit.
+	unit string
+	// availableOn, a YYYY-MM-DD date, drops vendors blocked or fully
+	// booked that day.
+	availableOn string
+	// near is set when lat and lng are.
+	near               bool
+	lat, lng, radiusKm float64
+	sort               string
+}
+
+const (
+	defaultRadiusKm = 25
+	maxRadiusKm     = 500
+)
+
+// distance is the haversine distance in km from near to vendor vp. It is NULL
+// for vendors with no coordinates and no known city. lat and lng are parsed
+// floats, so they are safe to inline.
+func (s vendorSearch) distance() string {
+	const lat, lng = "COALESCE(vp.latitude, l.latitude)", "COALESCE(vp.longitude, l.longitude)"
+	return fmt.Sprintf(`(2 * 6371 * asin(LEAST(1, sqrt(
+		power(sin(radians(%[3]s - (%[1]g)) / 2), 2) +
+		cos(radians(%[1]g)) * cos(radians(%[3]s)) * power(sin(radians(%[4]s - (%[2]g)) / 2), 2)))))::real`,
+		s.lat, s.lng, lat, lng)
+}
+
+// keys returns the ordering for s.sort.
+func (s vendorSearch) keys() keyset {
+	if s.sort == "distance" {
+		return keyset{{expr: s.distance(), typ: "real"}, {expr: "vp.business_name", typ: "text"}, {expr: "vp.id", typ: "uuid"}}
+	}
+	return vendorSorts[s.sort]
+}
+
+// parseVendorSearch reads the query string, answering 400 and returning false
+// when it is invalid.
+func parseVendorSearch(c *gin.Context) (vendorSearch, bool) {
+	s := vendorSearch{
+		q:        strings.TrimSpace(c.Query("q")),
+		category: strings.TrimSpace(c.Query("category")),
+		city:     strings.TrimSpace(c.Query("city")),
+		unit:     c.Query("unit"),
+		sort:     c.DefaultQuery("sort", "relevance"),
+	}
+	if s.availableOn = c.Query("available_on"); s.availableOn != "" {
+		if _, ok := parseDate(c, "available_on", s.availableOn); !ok {
+			return s, false
+		}
+	}
+	if s.unit != "" && !slices.Contains(packageUnits, s.unit) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unit must be " + strings.Join(packageUnits, ", ")})
+		return s, false
+	}
+	if near := c.Query("near"); near != "" {
+		lat, lng, ok := strings.Cut(near, ",")
+		var err error
+		if ok {
+			s.lat, err = strconv.ParseFloat(strings.TrimSpace(lat), 64)
+		}
+		if ok && err == nil {
+			s.lng, err = strconv.ParseFloat(strings.TrimSpace(lng), 64)
+		}
+		if !ok || err != nil || math.Abs(s.lat) > 90 || math.Abs(s.lng) > 180 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "near must be latitude,longitude in degrees"})
+			return s, false
+		}
+		s.near, s.radiusKm = true, defaultRadiusKm
+	}
+	if r := c.Query("radius_km"); r != "" {
+		n, err := strconv.ParseFloat(r, 64)
+		if err != nil || !s.near || n <= 0 || n > maxRadiusKm {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("radius_km must be above 0 and at most %d, with near", maxRadiusKm)})
+			return s, false
+		}
+		s.radiusKm = n
+	}
+	if _, ok := vendorSorts[s.sort]; (!ok || s.sort == "name") && s.sort != "distance" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be relevance, newest, popularity or distance"})
+		return s, false
+	}
+	if s.sort == "distance" && !s.near {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort=distance needs near"})
+		return s, false
+	}
+	if s.sort == "relevance" && s.q == "" {
+		s.sort = "name"
+	}
+	prices := []struct {
+		name string
+		dst  **int
+	}{{"min_price", &s.minPrice}, {"max_price", &s.maxPrice}}
+	for _, p := range prices {
+		if v := c.Query(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": p.name + " must be a whole number of rupees"})
+				return s, false
+			}
+			*p.dst = &n
+		}
+	}
+	if s.minPrice != nil && s.maxPrice != nil && *s.maxPrice < *s.minPrice {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "max_price must not be below min_price"})
+		return s, false
+	}
+	return s, true
+}
+
+// where builds the WHERE clause for s without the filter named skip, which is
+// how facet counts leave out their own filter. q is always $1 when set.
+func (s vendorSearch) where(skip string) (string, []any) {
+	conds := []string{"vp.status = 'verified'", "vp.deleted_at IS NULL", "u.deleted_at IS NULL"}
+	var args []any
+	param := func(arg any) string {
+		args = append(args, arg)
+		return "$" + strconv.Itoa(len(args))
+	}
+	add := func(name, cond string, arg any) {
+		if name == skip {
+			return
+		}
+		conds = append(conds, strings.ReplaceAll(cond, "$?", param(arg)))
+	}
+
+	if s.q != "" {
+		add("q", `vp.search_vector @@ websearch_to_tsquery('english', $?)`, s.q)
+	}
+	// A top-level category also matches its subcategories.
+	if s.category != "" {
+		add("category", `EXISTS (
+			SELECT 1 FROM vendor_categories vc
+			JOIN categories c ON c.id = vc.category_id
+			LEFT JOIN categories p ON p.id = c.parent_id
+			WHERE vc.vendor_id = vp.id AND lower($?) IN (c.slug, lower(c.name), p.slug, lower(p.name)))`, s.category)
+	}
+	if s.city != "" {
+		add("city", `lower(vp.city) = lower($?)`, s.city)
+	}
+	if s.near {
+		add("near", s.distance()+` <= $?`, s.radiusKm)
+	}
+	if s.availableOn != "" {
+		add("available_on", `NOT `+vendorBusy("vp", "$?::date"), s.availableOn)
+	}
+	// A vendor matches when its own range or a rupee package's overlaps the
+	// requested one; with unit, only packages priced per unit count. Vendors
+	// without prices only show up when no price filter is set.
+	if (s.minPrice != nil || s.maxPrice != nil || s.unit != "") && skip != "price" {
+		var own []string
+		pkg := []string{"pk.vendor_id = vp.id"}
+		if s.minPrice != nil || s.maxPrice != nil {
+			pkg = append(pkg, "pk.currency = 'INR'")
+		}
+		if s.minPrice != nil {
+			p := param(*s.minPrice)
+			own = append(own, "COALESCE(vp.price_max, vp.price_min) >= "+p)
+			pkg = append(pkg, "COALESCE(pk.price_max, pk.price_min) >= "+p)
+		}
+		if s.maxPrice != nil {
+			p := param(*s.maxPrice)
+			own = append(own, "vp.price_min <= "+p)
+			pkg = append(pkg, "pk.price_min <= "+p)
+		}
+		if s.unit != "" {
+			pkg = append(pkg, "pk.unit = "+param(s.unit))
+		}
+		cond := "EXISTS (SELECT 1 FROM vendor_packages pk WHERE " + strings.Join(pkg, " AND ") + ")"
+		if s.unit == "" {
+			cond = "(" + strings.Join(own, " AND ") + " OR " + cond + ")"
+		}
+		conds = append(conds, cond)
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// vendorHit is a search result with its sort key values.
+type vendorHit struct {
+	vendor     PublicVendor
+	rank       float32
+	shortlists int64
+	createdAt  time.Time
+	distance   *float32
+}
+
+// searchVendors runs s for page p and counts its facets.
+func searchVendors(ctx context.Context, s vendorSearch, p page) (VendorSearchResponse, error) {
+	res := VendorSearchResponse{Vendors: []PublicVendor{}}
+	keys := s.keys()
+
+	// Filter on the city's canonical name, so aliases find the same vendors.
+	city, _, err := resolveCity(ctx, s.city)
+	if err != nil {
+		return res, err
+	}
+	s.city = city
+
+	rank, distance := "0::real", "NULL::real"
+	if s.q != "" {
+		rank = vendorRank
+	}
+	if s.near {
+		distance = s.distance()
+	}
+	where, args := s.where("")
+	query, args := p.query(`
+		SELECT
+			vp.id, vp.business_name, vp.slug, vp.category, `+vendorCategorySlugs+`, vp.city, COALESCE(vp.bio, ''), vp.whatsapp_link, vp.portfolio_image_url, vp.gallery_images,
+			vp.price_min, vp.price_max, `+vendorStartingPrice+`, `+vendorRating+`, `+vendorReviewCount+`, vp.latitude, vp.longitude, u.full_name, u.profile_image_url,
+			`+rank+`, `+vendorShortlists+`, vp.created_at, `+distance+`
+		`+vendorFrom+`
+		WHERE `+where, keys, args)
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return res, err
+	}
+	defer rows.Close()
+	hits := []vendorHit{}
+	for rows.Next() {
+		var h vendorHit
+		v := &h.vendor
+		if err := rows.Scan(&v.ID, &v.BusinessName, &v.Slug, &v.Category, &v.Categories, &v.City, &v.Bio, &v.WhatsappLink, &v.PortfolioImageURL, &v.GalleryImages,
+			&v.PriceMin, &v.PriceMax, &v.StartingPrice, &v.Rating, &v.ReviewCount, &v.Latitude, &v.Longitude, &v.OwnerFullName, &v.OwnerProfileImage, &h.rank, &h.shortlists, &h.createdAt, &h.distance); err != nil {
+			return res, err
+		}
+		if h.distance != nil {
+			km := math.Round(float64(*h.distance)*10) / 10
+			v.DistanceKm = &km
+		}
+		hits = append(hits, h)
+	}
+	if err := rows.Err(); err != nil {
+		return res, err
+	}
+
+	hits, res.NextCursor = trim(p, hits, func(h vendorHit) []string {
+		switch s.sort {
+		case "relevance":
+			return []string{cursorReal(h.rank), h.vendor.BusinessName, h.vendor.ID}
+		case "newest":
+			return []string{cursorTime(h.createdAt), h.vendor.ID}
+		case "popularity":
+			return []string{strconv.FormatInt(h.shortlists, 10), h.vendor.BusinessName, h.vendor.ID}
+		case "distance":
+			return []string{cursorReal(*h.distance), h.vendor.BusinessName, h.vendor.ID}
+		}
+		return []string{h.vendor.BusinessName, h.vendor.ID}
+	})
+	for _, h := range hits {
+		res.Vendors = append(res.Vendors, h.vendor)
+	}
+
+	where, args = s.where("")
+	err = db.Pool.QueryRow(ctx, `SELECT count(*) `+vendorFrom+` WHERE `+where, args...).Scan(&res.Total)
+	if err != nil {
+		return res, err
+	}
+
+	res.Facets.Categories, err = vendorFacet(ctx, s, "category", "c.slug", "c.name",
+		`JOIN vendor_categories vc ON vc.vendor_id = vp.id JOIN categories c ON c.id = vc.category_id`)
+	if err != nil {
+		return res, err
+	}
+	res.Facets.Cities, err = vendorFacet(ctx, s, "city", "vp.city", "''", "")
+	return res, err
+}
+
+// vendorFacet counts matching vendors per value, most common first, leaving
+// out the filter of the same name. join brings in the tables value and name
+// come from.
+func vendorFacet(ctx context.Context, s vendorSearch, filter, value, name, join string) ([]FacetCount, error) {
+	where, args := s.where(filter)
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+value+`, count(*), `+name+`
+		`+vendorFrom+`
+		`+join+`
+		WHERE `+where+`
+		GROUP BY 1, 3
+		ORDER BY 2 DESC, 1`, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[FacetCount])
+}
+
+// SearchVendors lists verified vendors matching a full-text query and
+// filters, a page at a time, with facet counts. Responses are cached per
+// query string. Deprecated routes get every match as a bare array, the shape
+// from before search that the deployed web client parses.
+func (h *VendorHandler) SearchVendors(c *gin.Context) {
+	s, ok := parseVendorSearch(c)
+	if !ok {
+		return
+	}
+	p, ok := parsePage(c, s.keys())
+	if !ok {
+		return
+	}
+	// Encode sorts the parameters, so equivalent URLs share an entry.
+	key := "vendors?" + c.Request.URL.Query().Encode()
+	if p.all() {
+		key = "vendors-legacy?" + c.Request.URL.Query().Encode()
+	}
+	if e, ok := h.Cache.Get(key); ok {
+		serveCached(c, e, h.Config.PublicCacheControl)
+		return
+	}
+	generation := h.Cache.Generation()
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
+
+	// Taken over every profile, so a vendor leaving the list still moves it
+	// forward.
+	var lastModified time.Time
+	err := db.Pool.QueryRow(ctx, `SELECT COALESCE(max(updated_at), 'epoch') FROM vendor_profiles`).Scan(&lastModified)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch vendors")
+		return
+	}
+
+	res, err := searchVendors(ctx, s, p)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch vendors")
+		return
+	}
+	if p.all() {
+		h.renderCached(c, generation, key, res.Vendors, lastModified)
+		return
+	}
+	h.renderCached(c, generation, key, res, lastModified)
+}
@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bventy/backend/internal/config"
+	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/outbox"
+	"github.com/bventy/backend/internal/webhooks"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type WebhookHandler struct {
+	Config *config.Config
+}
+
+func NewWebhookHandler(cfg *config.Config) *WebhookHandler {
+	return &WebhookHandler{Config: cfg}
+}
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" binding:"required" doc:"https URL that receives the POSTs"`
+	EventTypes []string `json:"event_types" binding:"required" doc:"vendor.verified, vendor.shortlisted; admins may also use group.created, event.created"`
+	Secret     string   `json:"secret" doc:"HMAC-SHA256 key, at least 16 characters; generated when omitted"`
+	AllVendors bool     `json:"all_vendors" doc:"Receive events for every vendor rather than only your own (admins only)"`
+}
+
+type UpdateWebhookRequest struct {
+	URL        *string  `json:"url"`
+	EventTypes []string `json:"event_types" doc:"Replaces the list when present"`
+	Active     *bool    `json:"active" doc:"Inactive subscriptions get no new deliveries"`
+}
+
+type WebhookSubscription struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	AllVendors bool      `json:"all_vendors"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type CreateWebhookResponse struct {
+	Subscription WebhookSubscription `json:"subscription"`
+	Secret       string              `json:"secret" doc:"Only returned here; store it to verify X-Bventy-Signature"`
+}
+
+type WebhookDelivery struct {
+	ID             string     `json:"id"`
+	EventID        int64      `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status" doc:"pending, succeeded or failed"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus *int       `json:"response_status"`
+	LastError      *string    `json:"last_error"`
+	ReplayOf       *string    `json:"replay_of"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
+
+const subscriptionColumns = `id, url, event_types, all_vendors, active, created_at, updated_at`
+
+func scanSubscription(row pgx.Row) (WebhookSubscription, error) {
+	var s WebhookSubscription
+	err := row.Scan(&s.ID, &s.URL, &s.EventTypes, &s.AllVendors, &s.Active, &s.CreatedAt, &s.UpdatedAt)
+	return s, err
+}
+
+const deliveryColumns = `id, event_id, event_type, status, attempts, response_status, last_error, replay_of, created_at, delivered_at`
+
+func scanDelivery(row pgx.Row) (WebhookDelivery, error) {
+	var d WebhookDelivery
+	err := row.Scan(&d.ID, &d.EventID, &d.EventType, &d.Status, &d.Attempts, &d.ResponseStatus, &d.LastError, &d.ReplayOf, &d.CreatedAt, &d.DeliveredAt)
+	return d, err
+}
+
+func isAdminRole(role string) bool {
+	return role == "admin" || role == "super_admin"
+}
+
+// validateWebhookURL rejects anything but absolute https URLs (http too when
+// WEBHOOKS_ALLOW_HTTP is set, for local receivers), and hosts that resolve to
+// loopback or private addresses unless WEBHOOKS_ALLOW_PRIVATE_NETWORKS is set.
+// The delivery client checks the address again when it connects.
+func (h *WebhookHandler) validateWebhookURL(ctx context.Context, raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "url must be an absolute URL"
+	}
+	if u.Scheme != "https" && !(u.Scheme == "http" && h.Config.WebhooksAllowHTTP) {
+		return "url must use https"
+	}
+	if !h.Config.WebhooksAllowPrivate {
+		if err := webhooks.CheckDestination(ctx, u.Hostname()); err != nil {
+			return "url must point to a public address"
+		}
+	}
+	return ""
+}
+
+// validateEventTypes checks types against the outbox catalogue. Only
+// vendor events can be scoped to a vendor owner, so the rest need admin.
+func validateEventTypes(types []string, admin bool) string {
+	if len(types) == 0 {
+		return "event_types must not be empty"
+	}
+	for _, t := range types {
+		if !slices.Contains(outbox.Types, t) {
+			return "Unknown event type: " + t
+		}
+		if !admin && !strings.HasPrefix(t, "vendor.") {
+			return "Only admins can subscribe to " + t
+		}
+	}
+	return ""
+}
+
+func generateWebhookSecret() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return "whsec_" + hex.EncodeToString(b)
+}
+
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	userID := c.GetString("userID")
+	admin := isAdminRole(c.GetString("role"))
+
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := h.validateWebhookURL(c.Request.Context(), req.URL); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	req.EventTypes = slices.Compact(slices.Sorted(slices.Values(req.EventTypes)))
+	if msg := validateEventTypes(req.EventTypes, admin); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if req.AllVendors && !admin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can subscribe to all vendors"})
+		return
+	}
+	if req.Secret == "" {
+		req.Secret = generateWebhookSecret()
+	} else if len(req.Secret) < 16 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "secret must be at least 16 characters"})
+		return
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	sub, err := scanSubscription(db.Pool.QueryRow(ctx, `
+		INSERT INTO webhook_subscriptions (owner_user_id, url, secret, event_types, all_vendors)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+subscriptionColumns,
+		userID, req.URL, req.Secret, req.EventTypes, req.AllVendors))
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to create webhook")
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateWebhookResponse{Subscription: sub, Secret: req.Secret})
+}
+
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
+
+	rows, err := db.Pool.Query(ctx,
+		`SELECT `+subscriptionColumns+` FROM webhook_subscriptions WHERE owner_user_id = $1 ORDER BY created_at`,
+		c.GetString("userID"))
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch webhooks")
+		return
+	}
+	defer rows.Close()
+
+	subs := []WebhookSubscription{}
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			continue
+		}
+		subs = append(subs, s)
+	}
+	if err := rows.Err(); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch webhooks")
+		return
+	}
+
+	c.JSON(http.StatusOK, subs)
+}
+
+// ownedSubscription loads the :id subscription if the caller owns it, and
+// otherwise writes a 404.
+func (h *WebhookHandler) ownedSubscription(ctx context.Context, c *gin.Context) (WebhookSubscription, bool) {
+	sub, err := scanSubscription(db.Pool.QueryRow(ctx,
+		`SELECT `+subscriptionColumns+` FROM webhook_subscriptions WHERE id = $1 AND owner_user_id = $2`,
+		c.Param("id"), c.GetString("userID")))
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Webhook not found")
+		return sub, false
+	}
+	return sub, true
+}
+
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
+
+	sub, ok := h.ownedSubscription(ctx, c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	var req UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.URL != nil {
+		if msg := h.validateWebhookURL(c.Request.Context(), *req.URL); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+	}
+	if req.EventTypes != nil {
+		req.EventTypes = slices.Compact(slices.Sorted(slices.Values(req.EventTypes)))
+		if msg := validateEventTypes(req.EventTypes, isAdminRole(c.GetString("role"))); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+	}
+
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	sub, err := scanSubscription(db.Pool.QueryRow(ctx, `
+		UPDATE webhook_subscriptions
+		SET url = COALESCE($3, url), event_types = COALESCE($4, event_types), active = COALESCE($5, active), updated_at = now()
+		WHERE id = $1 AND owner_user_id = $2
+		RETURNING `+subscriptionColumns,
+		c.Param("id"), c.GetString("userID"), req.URL, req.EventTypes, req.Active))
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Webhook not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1 AND owner_user_id = $2`,
+		c.Param("id"), c.GetString("userID"))
+	if err != nil || tag.RowsAffected() == 0 {
+		fail(c, err, http.StatusNotFound, "Webhook not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Webhook deleted"})
+}
+
+// ListDeliveries returns the subscription's 50 most recent deliveries.
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Query)
+	defer cancel()
+
+	sub, ok := h.ownedSubscription(ctx, c)
+	if !ok {
+		return
+	}
+
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE subscription_id = $1`
+	args := []any{sub.ID}
+	if status := c.Query("status"); status != "" {
+		query += ` AND status = $2`
+		args = append(args, status)
+	}
+	query += ` ORDER BY created_at DESC LIMIT 50`
+
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch deliveries")
+		return
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			continue
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to fetch deliveries")
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// ReplayDelivery sends a past delivery's body again as a new delivery, e.g.
+// after the receiver was fixed. The body keeps its event id so receivers can
+// still dedupe.
+func (h *WebhookHandler) ReplayDelivery(c *gin.Context) {
+	ctx, cancel := withDeadline(c, h.Config.Timeouts.Write)
+	defer cancel()
+
+	sub, ok := h.ownedSubscription(ctx, c)
+	if !ok {
+		return
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	replay, err := scanDelivery(tx.QueryRow(ctx, `
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, body, replay_of)
+		SELECT subscription_id, event_id, event_type, body, id FROM webhook_deliveries
+		WHERE id = $1 AND subscription_id = $2
+		RETURNING `+deliveryColumns,
+		c.Param("deliveryID"), sub.ID))
+	if err != nil {
+		fail(c, err, http.StatusNotFound, "Delivery not found")
+		return
+	}
+	if err := webhooks.EnqueueDelivery(ctx, tx, replay.ID); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to queue replay")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		fail(c, err, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, replay)
+}
@@ -0,0 +1,131 @@
+// Package httpcache keeps rendered responses of public, rarely changing
+// endpoints in memory and answers conditional requests for them.
+//
+// Handlers invalidate the cache after every write that can change a cached
+// response. Other instances only see the change once their copy expires, so
+// the TTL bounds how stale a response can be across a deployment.
+package httpcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a rendered response.
+type Entry struct {
+	Body         []byte
+	ETag         string // strong, quoted
+	LastModified time.Time
+
+	storedAt time.Time
+}
+
+// NewEntry builds an entry whose ETag is derived from body, so two instances
+// rendering the same data agree on it.
+func NewEntry(body []byte, lastModified time.Time) Entry {
+	sum := sha256.Sum256(body)
+	return Entry{
+		Body:         body,
+		ETag:         `"` + hex.EncodeToString(sum[:16]) + `"`,
+		LastModified: lastModified.UTC().Truncate(time.Second),
+	}
+}
+
+// MaxEntries caps how many entries a Cache holds. Search results are cached
+// per query string, so the key space is up to clients.
+const MaxEntries = 1000
+
+// Cache maps keys to entries for at most TTL.
+type Cache struct {
+	ttl time.Duration
+
+	mu         sync.RWMutex
+	entries    map[string]Entry
+	generation uint64
+}
+
+// New returns an empty cache. A ttl of zero disables caching; NotModified
+// still works on freshly rendered entries.
+func New(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, entries: map[string]Entry{}}
+}
+
+// Get returns the entry for key if it has not expired.
+func (c *Cache) Get(key string) (Entry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.entries[key]
+	if !ok || time.Since(e.storedAt) >= c.ttl {
+		return Entry{}, false
+	}
+	return e, true
+}
+
+// Generation identifies the cache contents. Take it before reading from the
+// database and pass it to Set, so a response rendered from data that was
+// changed in the meantime is not stored.
+func (c *Cache) Generation() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.generation
+}
+
+// Set stores e under key unless the cache was invalidated since generation.
+func (c *Cache) Set(generation uint64, key string, e Entry) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= MaxEntries {
+		// Make room by dropping expired entries; when everything is fresh,
+		// the new one is simply not cached.
+		for k, old := range c.entries {
+			if time.Since(old.storedAt) >= c.ttl {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= MaxEntries {
+			return
+		}
+	}
+	e.storedAt = time.Now()
+	c.entries[key] = e
+}
+
+// Invalidate drops every entry. Writes are rare enough that tracking which
+// keys they affect is not worth it.
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	clear(c.entries)
+}
+
+// NotModified reports whether r already has e, following RFC 9110: when
+// If-None-Match is present If-Modified-Since is ignored.
+func NotModified(r *http.Request, e Entry) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			// If-None-Match uses weak comparison, so W/ prefixes added by
+			// compressing proxies still match.
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == e.ETag {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !e.LastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		return err == nil && !e.LastModified.After(t)
+	}
+	return false
+}
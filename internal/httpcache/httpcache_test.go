@@ -0,0 +1,107 @@
+package httpcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2026, time.October, 1, 12, 0, 0, 0, time.UTC)
+	e := NewEntry([]byte(`{"id":"1"}`), modified.Add(300*time.Millisecond))
+
+	cases := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{"no validators", nil, false},
+		{"matching etag", map[string]string{"If-None-Match": e.ETag}, true},
+		{"weak etag from a proxy", map[string]string{"If-None-Match": "W/" + e.ETag}, true},
+		{"etag in a list", map[string]string{"If-None-Match": `"other", ` + e.ETag}, true},
+		{"wildcard", map[string]string{"If-None-Match": "*"}, true},
+		{"stale etag", map[string]string{"If-None-Match": `"other"`}, false},
+		{"same second", map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, true},
+		{"modified since", map[string]string{"If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat)}, false},
+		{"bad date", map[string]string{"If-Modified-Since": "yesterday"}, false},
+		{"etag wins over date", map[string]string{
+			"If-None-Match":     `"other"`,
+			"If-Modified-Since": modified.Format(http.TimeFormat),
+		}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tc.headers {
+				r.Header.Set(k, v)
+			}
+			if got := NotModified(r, e); got != tc.want {
+				t.Fatalf("NotModified = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestETagFollowsBody(t *testing.T) {
+	now := time.Now()
+	a, b := NewEntry([]byte("a"), now), NewEntry([]byte("a"), now.Add(time.Hour))
+	if a.ETag != b.ETag {
+		t.Fatalf("same body, different etags: %s %s", a.ETag, b.ETag)
+	}
+	if c := NewEntry([]byte("b"), now); c.ETag == a.ETag {
+		t.Fatal("different bodies share an etag")
+	}
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	c := New(time.Minute)
+	gen := c.Generation()
+	c.Set(gen, "k", NewEntry([]byte("v1"), time.Now()))
+	if _, ok := c.Get("k"); !ok {
+		t.Fatal("entry not cached")
+	}
+
+	c.Invalidate()
+	if _, ok := c.Get("k"); ok {
+		t.Fatal("entry survived invalidation")
+	}
+	// A response rendered before the invalidation must not be stored.
+	c.Set(gen, "k", NewEntry([]byte("v1"), time.Now()))
+	if _, ok := c.Get("k"); ok {
+		t.Fatal("stale render was cached")
+	}
+}
+
+func TestCacheExpires(t *testing.T) {
+	c := New(20 * time.Millisecond)
+	c.Set(c.Generation(), "k", NewEntry([]byte("v"), time.Now()))
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := c.Get("k"); ok {
+		t.Fatal("entry outlived its ttl")
+	}
+
+	off := New(0)
+	off.Set(off.Generation(), "k", NewEntry([]byte("v"), time.Now()))
+	if _, ok := off.Get("k"); ok {
+		t.Fatal("zero ttl still cached")
+	}
+}
+
+func TestCacheIsBounded(t *testing.T) {
+	c := New(time.Minute)
+	gen := c.Generation()
+	for i := range MaxEntries {
+		c.Set(gen, strconv.Itoa(i), NewEntry([]byte("v"), time.Now()))
+	}
+	c.Set(gen, "one too many", NewEntry([]byte("v"), time.Now()))
+	if _, ok := c.Get("one too many"); ok {
+		t.Fatal("cache grew past MaxEntries")
+	}
+	// Replacing an existing key still works when full.
+	c.Set(gen, "0", NewEntry([]byte("v2"), time.Now()))
+	if e, ok := c.Get("0"); !ok || string(e.Body) != "v2" {
+		t.Fatal("existing entry not replaced")
+	}
+}
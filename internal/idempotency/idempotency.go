@@ -0,0 +1,104 @@
+// Package idempotency records the first response to a request carrying an
+// Idempotency-Key so that retries of it can be answered without running the
+// request again.
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	// ErrInProgress means the first request with the key has not finished.
+	ErrInProgress = errors.New("idempotency: request in progress")
+	// ErrMismatch means the key was first used for a different request.
+	ErrMismatch = errors.New("idempotency: key reused for a different request")
+)
+
+// Response is a stored first response.
+type Response struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// Claim reserves key for userID. It returns (nil, nil) when the caller now
+// owns the key and must run the request, then Complete or Release it. It
+// returns the stored response when the request already ran, ErrMismatch when
+// fingerprint differs from the first request, and ErrInProgress while the
+// first request is running.
+//
+// Keys older than ttl are forgotten, and unfinished ones are taken over after
+// lease in case the process running them died.
+func Claim(ctx context.Context, pool *pgxpool.Pool, userID, key, fingerprint string, ttl, lease time.Duration) (*Response, error) {
+	// A concurrent Release can delete the row between the insert and the
+	// select; one retry covers that.
+	for range 2 {
+		var claimed bool
+		err := pool.QueryRow(ctx, `
+			INSERT INTO idempotency_keys (user_id, key, fingerprint)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, key) DO UPDATE SET
+				fingerprint = EXCLUDED.fingerprint, status_code = NULL, content_type = NULL, body = NULL,
+				created_at = now(), completed_at = NULL
+			WHERE idempotency_keys.created_at < now() - $4::interval
+			   OR (idempotency_keys.completed_at IS NULL AND idempotency_keys.created_at < now() - $5::interval)
+			RETURNING true
+		`, userID, key, fingerprint, ttl, lease).Scan(&claimed)
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+
+		var stored string
+		var status *int
+		var res Response
+		err = pool.QueryRow(ctx, `
+			SELECT fingerprint, status_code, COALESCE(content_type, ''), body
+			FROM idempotency_keys WHERE user_id = $1 AND key = $2
+		`, userID, key).Scan(&stored, &status, &res.ContentType, &res.Body)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case stored != fingerprint:
+			return nil, ErrMismatch
+		case status == nil:
+			return nil, ErrInProgress
+		}
+		res.StatusCode = *status
+		return &res, nil
+	}
+	return nil, ErrInProgress
+}
+
+// Complete stores the response to a claimed key.
+func Complete(ctx context.Context, pool *pgxpool.Pool, userID, key string, res Response) error {
+	_, err := pool.Exec(ctx, `
+		UPDATE idempotency_keys SET status_code = $3, content_type = $4, body = $5, completed_at = now()
+		WHERE user_id = $1 AND key = $2
+	`, userID, key, res.StatusCode, res.ContentType, res.Body)
+	return err
+}
+
+// Release forgets a claimed key without a response, so a retry runs the
+// request again. Use it when the request failed in a way worth retrying.
+func Release(ctx context.Context, pool *pgxpool.Pool, userID, key string) error {
+	_, err := pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2`, userID, key)
+	return err
+}
+
+// Prune deletes keys older than ttl and returns how many it removed.
+func Prune(ctx context.Context, pool *pgxpool.Pool, ttl time.Duration) (int64, error) {
+	tag, err := pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE created_at < now() - $1::interval`, ttl)
+	return tag.RowsAffected(), err
+}
@@ -0,0 +1,85 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bventy/backend/internal/testdb"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func newUser(t *testing.T, pool *pgxpool.Pool) string {
+	t.Helper()
+	var id string
+	err := pool.QueryRow(context.Background(), `
+		INSERT INTO users (email, password_hash, full_name)
+		VALUES (uuid_generate_v4()::text || '@example.com', 'x', 'Idem') RETURNING id
+	`).Scan(&id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestClaimLifecycle(t *testing.T) {
+	pool := testdb.New(t)
+	ctx := context.Background()
+	user := newUser(t, pool)
+	claim := func(fingerprint string) (*Response, error) {
+		return Claim(ctx, pool, user, "k1", fingerprint, time.Hour, time.Minute)
+	}
+
+	if res, err := claim("a"); res != nil || err != nil {
+		t.Fatalf("first claim = %v, %v; want ownership", res, err)
+	}
+	if _, err := claim("a"); !errors.Is(err, ErrInProgress) {
+		t.Fatalf("concurrent claim err = %v, want ErrInProgress", err)
+	}
+	if _, err := claim("b"); !errors.Is(err, ErrMismatch) {
+		t.Fatalf("different request err = %v, want ErrMismatch", err)
+	}
+
+	want := Response{StatusCode: 201, ContentType: "application/json", Body: []byte(`{"id":"x"}`)}
+	if err := Complete(ctx, pool, user, "k1", want); err != nil {
+		t.Fatal(err)
+	}
+	res, err := claim("a")
+	if err != nil || res == nil || res.StatusCode != 201 || string(res.Body) != string(want.Body) {
+		t.Fatalf("replay = %+v, %v", res, err)
+	}
+
+	// A released key runs again.
+	if res, err := Claim(ctx, pool, user, "k2", "a", time.Hour, time.Minute); res != nil || err != nil {
+		t.Fatalf("claim k2 = %v, %v", res, err)
+	}
+	if err := Release(ctx, pool, user, "k2"); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := Claim(ctx, pool, user, "k2", "a", time.Hour, time.Minute); res != nil || err != nil {
+		t.Fatalf("claim after release = %v, %v", res, err)
+	}
+}
+
+func TestExpiredKeysAreReclaimed(t *testing.T) {
+	pool := testdb.New(t)
+	ctx := context.Background()
+	user := newUser(t, pool)
+
+	Claim(ctx, pool, user, "done", "a", time.Hour, time.Minute)
+	Complete(ctx, pool, user, "done", Response{StatusCode: 200})
+	Claim(ctx, pool, user, "stuck", "a", time.Hour, time.Minute)
+	pool.Exec(ctx, `UPDATE idempotency_keys SET created_at = now() - interval '2 hours'`)
+
+	// The stuck request's lease ran out, so a retry takes it over.
+	if res, err := Claim(ctx, pool, user, "stuck", "a", 24*time.Hour, time.Minute); res != nil || err != nil {
+		t.Fatalf("claim abandoned key = %v, %v", res, err)
+	}
+	if n, err := Prune(ctx, pool, time.Hour); err != nil || n != 1 {
+		t.Fatalf("Prune = %d, %v; want 1", n, err)
+	}
+	if res, err := Claim(ctx, pool, user, "done", "b", time.Hour, time.Minute); res != nil || err != nil {
+		t.Fatalf("claim pruned key = %v, %v", res, err)
+	}
+}
@@ -0,0 +1,103 @@
+// Package jobs is a background job queue stored in the jobs table. Workers
+// claim rows with FOR UPDATE SKIP LOCKED, so any number of processes (the API
+// with an embedded worker, cmd/worker, or both) can share one queue.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Querier is satisfied by *pgxpool.Pool and pgx.Tx, so a job can be enqueued
+// in the same transaction as the change that needs it.
+type Querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Job is a claimed row handed to a handler.
+type Job struct {
+	ID          int64
+	Kind        string
+	Payload     json.RawMessage
+	Attempt     int // 1 on the first run
+	MaxAttempts int
+}
+
+type enqueueOptions struct {
+	runAt       time.Time
+	maxAttempts int
+}
+
+type EnqueueOption func(*enqueueOptions)
+
+// RunAt schedules the job for t instead of now.
+func RunAt(t time.Time) EnqueueOption {
+	return func(o *enqueueOptions) { o.runAt = t }
+}
+
+// MaxAttempts overrides how many times the job runs before it is dead-lettered.
+func MaxAttempts(n int) EnqueueOption {
+	return func(o *enqueueOptions) { o.maxAttempts = n }
+}
+
+// Enqueue inserts a job whose payload is the JSON encoding of payload and
+// returns its id.
+func Enqueue(ctx context.Context, q Querier, kind string, payload any, opts ...EnqueueOption) (int64, error) {
+	o := enqueueOptions{maxAttempts: 10}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("encode %s payload: %w", kind, err)
+	}
+
+	var runAt any
+	if !o.runAt.IsZero() {
+		runAt = o.runAt
+	}
+	var id int64
+	err = q.QueryRow(ctx, `
+		INSERT INTO jobs (kind, payload, max_attempts, run_at)
+		VALUES ($1, $2, $3, COALESCE($4, now()))
+		RETURNING id
+	`, kind, data, o.maxAttempts, runAt).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("enqueue %s: %w", kind, err)
+	}
+	return id, nil
+}
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying; the job is dead-lettered at once.
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+// Backoff is the delay before retrying after the given failed attempt:
+// base, 2*base, 4*base, ... capped at max.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
+
+// IsPermanent reports whether err, or anything it wraps, came from Permanent.
+func IsPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
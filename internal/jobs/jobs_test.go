@@ -0,0 +1,149 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bventy/backend/internal/testdb"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestBackoff(t *testing.T) {
+	base, max := 5*time.Second, time.Minute
+	want := []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute}
+	for i, w := range want {
+		if got := Backoff(i+1, base, max); got != w {
+			t.Errorf("Backoff(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+	if got := Backoff(1000, base, max); got != max {
+		t.Errorf("Backoff(1000) = %s, want cap %s", got, max)
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	cause := errors.New("bad payload")
+	err := fmt.Errorf("wrapped: %w", Permanent(cause))
+	if !IsPermanent(err) || !errors.Is(err, cause) {
+		t.Fatalf("Permanent lost through wrapping: %v", err)
+	}
+	if IsPermanent(cause) {
+		t.Fatal("plain error reported as permanent")
+	}
+}
+
+type echo struct {
+	N int `json:"n"`
+}
+
+func jobState(t *testing.T, pool *pgxpool.Pool, id int64) (status string, attempts int) {
+	t.Helper()
+	err := pool.QueryRow(context.Background(), "SELECT status, attempts FROM jobs WHERE id=$1", id).Scan(&status, &attempts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return status, attempts
+}
+
+func TestRunOnceRetriesThenDeadLetters(t *testing.T) {
+	pool := testdb.New(t)
+	ctx := context.Background()
+
+	w := NewWorker(pool, Options{BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	var seen int
+	Handle(w, "test.flaky", func(ctx context.Context, p echo) error {
+		seen = p.N
+		return errors.New("still broken")
+	})
+
+	id, err := Enqueue(ctx, pool, "test.flaky", echo{N: 7}, MaxAttempts(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		time.Sleep(5 * time.Millisecond) // let the backoff elapse
+		ran, err := w.RunOnce(ctx)
+		if err != nil || !ran {
+			t.Fatalf("attempt %d: ran=%v err=%v", attempt, ran, err)
+		}
+	}
+	if seen != 7 {
+		t.Fatalf("handler saw payload %d, want 7", seen)
+	}
+	if status, attempts := jobState(t, pool, id); status != "dead" || attempts != 2 {
+		t.Fatalf("got %s after %d attempts, want dead after 2", status, attempts)
+	}
+	if ran, _ := w.RunOnce(ctx); ran {
+		t.Fatal("dead job was claimed again")
+	}
+}
+
+func TestRunOnceSkipsFutureAndUnknownJobs(t *testing.T) {
+	pool := testdb.New(t)
+	ctx := context.Background()
+
+	w := NewWorker(pool, Options{})
+	Handle(w, "test.ok", func(ctx context.Context, p echo) error { return nil })
+
+	later, _ := Enqueue(ctx, pool, "test.ok", echo{}, RunAt(time.Now().Add(time.Hour)))
+	other, _ := Enqueue(ctx, pool, "test.other", echo{})
+	if ran, err := w.RunOnce(ctx); ran || err != nil {
+		t.Fatalf("ran=%v err=%v, want nothing due", ran, err)
+	}
+
+	now, _ := Enqueue(ctx, pool, "test.ok", echo{})
+	if ran, err := w.RunOnce(ctx); !ran || err != nil {
+		t.Fatalf("ran=%v err=%v", ran, err)
+	}
+	for id, want := range map[int64]string{later: "pending", other: "pending", now: "done"} {
+		if status, _ := jobState(t, pool, id); status != want {
+			t.Errorf("job %d is %s, want %s", id, status, want)
+		}
+	}
+}
+
+func TestRunDrainsInFlightJobsOnShutdown(t *testing.T) {
+	pool := testdb.New(t)
+
+	w := NewWorker(pool, Options{Concurrency: 3, PollInterval: 10 * time.Millisecond})
+	started := make(chan struct{}, 10)
+	var finished atomic.Int32
+	Handle(w, "test.slow", func(ctx context.Context, p echo) error {
+		started <- struct{}{}
+		time.Sleep(50 * time.Millisecond)
+		finished.Add(1)
+		return nil
+	})
+
+	for i := 0; i < 3; i++ {
+		if _, err := Enqueue(context.Background(), pool, "test.slow", echo{N: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+	for i := 0; i < 3; i++ {
+		<-started
+	}
+	stop()
+	<-done
+
+	if n := finished.Load(); n != 3 {
+		t.Fatalf("%d of 3 in-flight jobs finished before Run returned", n)
+	}
+	var pending int
+	pool.QueryRow(context.Background(), "SELECT count(*) FROM jobs WHERE status <> 'done'").Scan(&pending)
+	if pending != 0 {
+		t.Fatalf("%d jobs not marked done after shutdown", pending)
+	}
+}
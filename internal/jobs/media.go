@@ -0,0 +1,160 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bventy/backend/internal/services"
+)
+
+// KindDeleteMedia removes an object from the media store after its row is gone.
+const KindDeleteMedia = "media.delete"
+
+// KindDeletePrivateMedia removes a private object, by key, after its row is gone.
+const KindDeletePrivateMedia = "media.delete_private"
+
+// KindConvertImage re-encodes an uploaded image as WebP and swaps it into the
+// row that still references the original.
+const KindConvertImage = "media.convert_image"
+
+// Targets of a ConvertImage job: which row's URL it replaces.
+const (
+	ImageUserProfile   = "user_profile"   // users.profile_image_url; ID is the user
+	ImageVendorGallery = "vendor_gallery" // vendor_gallery_images.image_url; ID is the image
+)
+
+type DeleteMedia struct {
+	URL string `json:"url"`
+}
+
+type DeletePrivateMedia struct {
+	Key string `json:"key"`
+}
+
+type ConvertImage struct {
+	Target string `json:"target"`
+	ID     string `json:"id"`
+	URL    string `json:"url"`    // the original upload
+	Prefix string `json:"prefix"` // where the WebP file goes
+}
+
+// imageTarget holds the statements a ConvertImage job runs for one target,
+// both taking ($1 row id, $2 original URL). current reports whether the row
+// still references the original; swap replaces it with $3, touches the
+// owning vendor like the upload handlers do, and returns the rows changed.
+type imageTarget struct {
+	current, swap string
+}
+
+var imageTargets = map[string]imageTarget{
+	// The user's version is bumped, so a PATCH based on the original URL
+	// fails its If-Match instead of writing it back.
+	ImageUserProfile: {
+		current: `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND profile_image_url = $2 AND deleted_at IS NULL)`,
+		swap: `
+			WITH swapped AS (
+				UPDATE users SET profile_image_url = $3, version = version + 1
+				WHERE id = $1 AND profile_image_url = $2 AND deleted_at IS NULL
+				RETURNING id
+			), touched AS (
+				UPDATE vendor_profiles SET updated_at = now()
+				WHERE owner_user_id IN (SELECT id FROM swapped) AND deleted_at IS NULL
+			)
+			SELECT count(*) FROM swapped`,
+	},
+	// Gallery rows are not versioned; touching the vendor refreshes the
+	// public vendor responses.
+	ImageVendorGallery: {
+		current: `SELECT EXISTS (SELECT 1 FROM vendor_gallery_images WHERE id = $1 AND image_url = $2)`,
+		swap: `
+			WITH swapped AS (
+				UPDATE vendor_gallery_images SET image_url = $3
+				WHERE id = $1 AND image_url = $2
+				RETURNING vendor_id
+			), touched AS (
+				UPDATE vendor_profiles SET updated_at = now()
+				WHERE id IN (SELECT vendor_id FROM swapped)
+			)
+			SELECT count(*) FROM swapped`,
+	},
+}
+
+// EnqueueConvertImage schedules the original upload at fileURL to be
+// converted to WebP under prefix. Pass the transaction that stores fileURL
+// in the target row.
+func EnqueueConvertImage(ctx context.Context, q Querier, target, id, fileURL, prefix string) error {
+	_, err := Enqueue(ctx, q, KindConvertImage, ConvertImage{Target: target, ID: id, URL: fileURL, Prefix: prefix})
+	return err
+}
+
+// EnqueueDeleteMedia schedules fileURL for deletion. Pass the transaction that
+// removes the row referencing it, so the object is only deleted if that commits.
+func EnqueueDeleteMedia(ctx context.Context, q Querier, fileURL string) error {
+	_, err := Enqueue(ctx, q, KindDeleteMedia, DeleteMedia{URL: fileURL})
+	return err
+}
+
+// EnqueueDeletePrivateMedia is EnqueueDeleteMedia for a private object key.
+func EnqueueDeletePrivateMedia(ctx context.Context, q Querier, key string) error {
+	_, err := Enqueue(ctx, q, KindDeletePrivateMedia, DeletePrivateMedia{Key: key})
+	return err
+}
+
+// RegisterMedia adds the media job handlers to w.
+func RegisterMedia(w *Worker, store services.MediaStore) {
+	Handle(w, KindDeleteMedia, func(ctx context.Context, job DeleteMedia) error {
+		return store.DeleteFile(ctx, job.URL)
+	})
+	Handle(w, KindDeletePrivateMedia, func(ctx context.Context, job DeletePrivateMedia) error {
+		return store.DeletePrivateFile(ctx, job.Key)
+	})
+	Handle(w, KindConvertImage, func(ctx context.Context, job ConvertImage) error {
+		return w.convertImage(ctx, store, job)
+	})
+}
+
+// convertImage encodes job's original as WebP and swaps it in. If the row
+// moved on meanwhile (a newer upload, or a delete) the job does nothing, or
+// discards its WebP file when that happened mid-conversion. Once swapped,
+// the original is deleted.
+func (w *Worker) convertImage(ctx context.Context, store services.MediaStore, job ConvertImage) error {
+	target, ok := imageTargets[job.Target]
+	if !ok {
+		return Permanent(fmt.Errorf("unknown image target %q", job.Target))
+	}
+	var current bool
+	if err := w.pool.QueryRow(ctx, target.current, job.ID, job.URL).Scan(&current); err != nil {
+		return err
+	}
+	if !current {
+		return nil
+	}
+
+	webpURL, err := store.ConvertToWebP(ctx, job.URL, job.Prefix)
+	if errors.Is(err, services.ErrNotImage) {
+		return Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+
+	tx, err := w.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var swapped int
+	if err := tx.QueryRow(ctx, target.swap, job.ID, job.URL, webpURL).Scan(&swapped); err != nil {
+		return err
+	}
+	stale := job.URL
+	if swapped == 0 {
+		stale = webpURL
+	}
+	if err := EnqueueDeleteMedia(ctx, tx, stale); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,247 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Options struct {
+	// ID identifies this worker in jobs.locked_by. Defaults to host-pid.
+	ID string
+	// Concurrency is how many jobs run at once. Defaults to 4.
+	Concurrency int
+	// PollInterval is how long an idle slot waits before looking again.
+	// Defaults to 1s.
+	PollInterval time.Duration
+	// JobTimeout bounds a single run. A job still marked running after twice
+	// this long is assumed orphaned by a dead worker and is retried.
+	// Defaults to 5m.
+	JobTimeout time.Duration
+	// BaseBackoff and MaxBackoff shape retry delays; see Backoff. Default
+	// 5s and 1h.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// KeepDone is how long finished jobs stay in the table. Defaults to 7 days.
+	KeepDone time.Duration
+}
+
+// HandlerFunc runs one job. Returning an error schedules a retry unless the
+// error is Permanent or the job is out of attempts.
+type HandlerFunc func(ctx context.Context, job Job) error
+
+type Worker struct {
+	pool     *pgxpool.Pool
+	opts     Options
+	handlers map[string]HandlerFunc
+}
+
+func NewWorker(pool *pgxpool.Pool, opts Options) *Worker {
+	if opts.ID == "" {
+		host, _ := os.Hostname()
+		opts.ID = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.JobTimeout <= 0 {
+		opts.JobTimeout = 5 * time.Minute
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = 5 * time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Hour
+	}
+	if opts.KeepDone <= 0 {
+		opts.KeepDone = 7 * 24 * time.Hour
+	}
+	return &Worker{pool: pool, opts: opts, handlers: map[string]HandlerFunc{}}
+}
+
+// HandleFunc registers fn for kind. Only registered kinds are claimed, so a
+// worker never takes a job it cannot run.
+func (w *Worker) HandleFunc(kind string, fn HandlerFunc) {
+	w.handlers[kind] = fn
+}
+
+// Handle registers a typed handler whose payload is decoded into T. A payload
+// that does not decode is a permanent failure.
+func Handle[T any](w *Worker, kind string, fn func(ctx context.Context, payload T) error) {
+	w.HandleFunc(kind, func(ctx context.Context, job Job) error {
+		var payload T
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return Permanent(fmt.Errorf("decode payload: %w", err))
+		}
+		return fn(ctx, payload)
+	})
+}
+
+// Run processes jobs until ctx is cancelled, then stops claiming and waits for
+// in-flight jobs to finish (each still bounded by JobTimeout).
+func (w *Worker) Run(ctx context.Context) {
+	kinds := make([]string, 0, len(w.handlers))
+	for kind := range w.handlers {
+		kinds = append(kinds, kind)
+	}
+	log.Printf("Worker %s started: %d slots, kinds %v", w.opts.ID, w.opts.Concurrency, kinds)
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx, kinds)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.maintain(ctx)
+	}()
+
+	wg.Wait()
+	log.Printf("Worker %s stopped", w.opts.ID)
+}
+
+func (w *Worker) loop(ctx context.Context, kinds []string) {
+	for ctx.Err() == nil {
+		ran, err := w.RunOnce(ctx, kinds...)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("⚠️  Worker %s: claim failed: %v", w.opts.ID, err)
+		}
+		if ran {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(w.opts.PollInterval):
+		}
+	}
+}
+
+// RunOnce claims and runs at most one due job of the given kinds (all
+// registered kinds when none are given), reporting whether it found one.
+func (w *Worker) RunOnce(ctx context.Context, kinds ...string) (bool, error) {
+	if len(kinds) == 0 {
+		for kind := range w.handlers {
+			kinds = append(kinds, kind)
+		}
+	}
+
+	var job Job
+	err := w.pool.QueryRow(ctx, `
+		UPDATE jobs
+		SET status = 'running', attempts = attempts + 1, locked_by = $2, locked_at = now(), updated_at = now()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE status = 'pending' AND run_at <= now() AND kind = ANY($1)
+			ORDER BY run_at, id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING id, kind, payload, attempts, max_attempts
+	`, kinds, w.opts.ID).Scan(&job.ID, &job.Kind, &job.Payload, &job.Attempt, &job.MaxAttempts)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// The job outlives a shutdown signal: it gets its full timeout, and its
+	// outcome is recorded even if ctx is already cancelled.
+	runCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), w.opts.JobTimeout)
+	defer cancel()
+	runErr := w.run(runCtx, job)
+
+	if err := w.finish(context.WithoutCancel(ctx), job, runErr); err != nil {
+		log.Printf("⚠️  Worker %s: recording job %d failed: %v", w.opts.ID, job.ID, err)
+	}
+	return true, nil
+}
+
+func (w *Worker) run(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return w.handlers[job.Kind](ctx, job)
+}
+
+func (w *Worker) finish(ctx context.Context, job Job, runErr error) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if runErr == nil {
+		_, err := w.pool.Exec(ctx, `
+			UPDATE jobs SET status = 'done', last_error = NULL, locked_by = NULL, locked_at = NULL, updated_at = now()
+			WHERE id = $1
+		`, job.ID)
+		return err
+	}
+
+	if IsPermanent(runErr) || job.Attempt >= job.MaxAttempts {
+		log.Printf("❌ Job %d (%s) dead after %d attempt(s): %v", job.ID, job.Kind, job.Attempt, runErr)
+		_, err := w.pool.Exec(ctx, `
+			UPDATE jobs SET status = 'dead', last_error = $2, locked_by = NULL, locked_at = NULL, updated_at = now()
+			WHERE id = $1
+		`, job.ID, runErr.Error())
+		return err
+	}
+
+	delay := Backoff(job.Attempt, w.opts.BaseBackoff, w.opts.MaxBackoff)
+	delay += rand.N(delay/10 + 1) // spread retries of jobs that failed together
+	log.Printf("⚠️  Job %d (%s) attempt %d failed, retrying in %s: %v", job.ID, job.Kind, job.Attempt, delay.Round(time.Second), runErr)
+	_, err := w.pool.Exec(ctx, `
+		UPDATE jobs SET status = 'pending', run_at = now() + $2::interval, last_error = $3,
+			locked_by = NULL, locked_at = NULL, updated_at = now()
+		WHERE id = $1
+	`, job.ID, delay, runErr.Error())
+	return err
+}
+
+// maintain periodically requeues jobs orphaned by dead workers and prunes old
+// finished jobs.
+func (w *Worker) maintain(ctx context.Context) {
+	ticker := time.NewTicker(w.opts.JobTimeout)
+	defer ticker.Stop()
+	for {
+		if err := w.Recover(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("⚠️  Worker %s: lease recovery failed: %v", w.opts.ID, err)
+		}
+		if _, err := w.pool.Exec(ctx, `DELETE FROM jobs WHERE status = 'done' AND updated_at < now() - $1::interval`, w.opts.KeepDone); err != nil && ctx.Err() == nil {
+			log.Printf("⚠️  Worker %s: pruning done jobs failed: %v", w.opts.ID, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Recover returns jobs that have been running for more than twice JobTimeout
+// to the queue, or dead-letters them when they are out of attempts.
+func (w *Worker) Recover(ctx context.Context) error {
+	_, err := w.pool.Exec(ctx, `
+		UPDATE jobs
+		SET status = CASE WHEN attempts >= max_attempts THEN 'dead' ELSE 'pending' END,
+			last_error = 'lease expired on ' || COALESCE(locked_by, 'unknown worker'),
+			locked_by = NULL, locked_at = NULL, run_at = now(), updated_at = now()
+		WHERE status = 'running' AND locked_at < now() - $1::interval
+	`, 2*w.opts.JobTimeout)
+	return err
+}
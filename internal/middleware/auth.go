@@ -1,7 +1,7 @@
 package middleware
 
 import (
-	"context"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -9,6 +9,7 @@ import (
 	"github.com/bventy/backend/internal/config"
 	"github.com/bventy/backend/internal/db"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 )
 
 func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
@@ -28,6 +29,29 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
+		// The account must still exist and the token must not predate a
+		// revocation or password reset.
+		ctx, cancel := timeoutContext(c.Request.Context(), cfg.Timeouts.Query)
+		var sessionVersion int
+		err = db.Pool.QueryRow(ctx,
+			"SELECT session_version FROM users WHERE id = $1 AND deleted_at IS NULL", claims.UserID,
+		).Scan(&sessionVersion)
+		cancel()
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.Abort()
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check session"})
+			c.Abort()
+			return
+		case sessionVersion != claims.SessionVersion:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session revoked, please log in again"})
+			c.Abort()
+			return
+		}
+
 		c.Set("userID", claims.UserID)
 		c.Set("role", claims.Role)
 		c.Next()
@@ -86,7 +110,7 @@ func RequirePermission(requiredPermission string) gin.HandlerFunc {
 			WHERE up.user_id = $1 AND p.code = $2
 		`
 		var existsFlag int
-		err := db.Pool.QueryRow(context.Background(), query, userID, requiredPermission).Scan(&existsFlag)
+		err := db.Pool.QueryRow(c.Request.Context(), query, userID, requiredPermission).Scan(&existsFlag)
 
 		if err == nil {
 			c.Next()
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated marks responses from a route that is scheduled for removal. It
+// sets the Deprecation (RFC 9745) and Sunset (RFC 8594) headers plus a Link to
+// the successor, and logs each use so remaining callers can be found before
+// the sunset date. successor maps the request to the path that replaces it.
+func Deprecated(deprecatedAt, sunset time.Time, successor func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		next := successor(c)
+
+		c.Header("Deprecation", "@"+strconv.FormatInt(deprecatedAt.Unix(), 10))
+		if !sunset.IsZero() {
+			c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		if next != "" {
+			c.Header("Link", "<"+next+`>; rel="successor-version"`)
+		}
+
+		log.Printf("⚠️  Deprecated route %s %s called by %s (%s); use %s",
+			c.Request.Method, c.FullPath(), c.ClientIP(), c.Request.UserAgent(), next)
+		c.Set(deprecatedKey, true)
+		c.Next()
+	}
+}
+
+const deprecatedKey = "deprecatedRoute"
+
+// IsDeprecated reports whether the request came in through a Deprecated
+// route, for handlers that keep older behaviour for legacy callers.
+func IsDeprecated(c *gin.Context) bool {
+	return c.GetBool(deprecatedKey)
+}
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/bventy/backend/internal/config"
+	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/idempotency"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader names the header clients send to make a retry safe.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed from an earlier
+	// request.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// idempotencyLease is how long an unfinished request keeps its key before
+	// a retry may take it over, e.g. after the instance running it crashed.
+	idempotencyLease = 5 * time.Minute
+)
+
+// Idempotent makes a route safe to retry. When the request has an
+// Idempotency-Key header, the first response for that user and key is stored
+// for cfg.IdempotencyTTL and replayed to later requests with the same key; a
+// retry arriving while the first request is still running gets 409. Server
+// errors, including handler panics, are not stored, so the client can retry
+// them. Requests without the
+// header run as usual. Mount it after AuthMiddleware.
+func Idempotent(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			c.Abort()
+			return
+		}
+		userID := c.GetString("userID")
+
+		fingerprint, err := requestFingerprint(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+
+		ctx, cancel := timeoutContext(c.Request.Context(), cfg.Timeouts.Write)
+		stored, err := idempotency.Claim(ctx, db.Pool, userID, key, fingerprint, cfg.IdempotencyTTL, idempotencyLease)
+		cancel()
+		switch {
+		case errors.Is(err, idempotency.ErrInProgress):
+			c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still in progress"})
+			c.Abort()
+			return
+		case errors.Is(err, idempotency.ErrMismatch):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+			c.Abort()
+			return
+		case err != nil:
+			log.Printf("⚠️  Claiming idempotency key failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check Idempotency-Key"})
+			c.Abort()
+			return
+		case stored != nil:
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(stored.StatusCode, stored.ContentType, stored.Body)
+			c.Abort()
+			return
+		}
+
+		recorder := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		// A panicking handler is answered with a 500 by gin's recovery further
+		// up. Release the key while the panic unwinds, as for any other server
+		// error, so retries aren't turned away until the lease runs out.
+		panicked := true
+		defer func() {
+			if !panicked {
+				return
+			}
+			ctx, cancel := timeoutContext(context.WithoutCancel(c.Request.Context()), cfg.Timeouts.Write)
+			defer cancel()
+			if err := idempotency.Release(ctx, db.Pool, userID, key); err != nil {
+				log.Printf("⚠️  Releasing idempotency key after a panic failed: %v", err)
+			}
+		}()
+		c.Next()
+		panicked = false
+
+		// Record the outcome even if the client has gone away; its retry
+		// is exactly who needs it.
+		ctx, cancel = timeoutContext(context.WithoutCancel(c.Request.Context()), cfg.Timeouts.Write)
+		defer cancel()
+		if status := recorder.Status(); status >= http.StatusInternalServerError {
+			err = idempotency.Release(ctx, db.Pool, userID, key)
+		} else {
+			err = idempotency.Complete(ctx, db.Pool, userID, key, idempotency.Response{
+				StatusCode:  status,
+				ContentType: recorder.Header().Get("Content-Type"),
+				Body:        recorder.body.Bytes(),
+			})
+		}
+		if err != nil {
+			log.Printf("⚠️  Recording idempotency key failed: %v", err)
+		}
+	}
+}
+
+// requestFingerprint identifies what the key was used for, so reusing a key
+// for a different request is rejected. Multipart bodies are left out: clients
+// pick a new boundary on every attempt, so their bytes never match.
+func requestFingerprint(c *gin.Context) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	if mediaType != "multipart/form-data" && c.Request.Body != nil {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return "", err
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		h.Write(body)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// timeoutContext bounds parent by d; a zero d means no bound.
+func timeoutContext(parent context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, d)
+}
+
+// recordingWriter keeps a copy of the response body.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,447 @@
+// Package migrate applies the versioned SQL files in internal/db/migrations
+// and records them in schema_migrations.
+//
+// A migration file is named NNN_name.sql. Everything before a
+// "-- migrate:down" line is the up script; everything after it is the down
+// script. Files without a down marker are irreversible.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	upMarker   = "-- migrate:up"
+	downMarker = "-- migrate:down"
+
+	// lockKey is the pg_advisory_lock key shared by every migrator, so two
+	// deploys starting at once apply migrations one after the other.
+	lockKey int64 = 7_385_201_446
+)
+
+// ErrNotBaselined is returned when a database has application tables but an
+// empty schema_migrations: running 001 there would drop them.
+var ErrNotBaselined = errors.New("database has tables but no recorded migrations; run `migrate baseline 12` first")
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.sql$`)
+
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	HasDown  bool
+	Checksum string
+}
+
+// Status describes one migration as seen by the database.
+type Status struct {
+	Migration
+	Applied          bool
+	AppliedAt        *time.Time
+	ChecksumMismatch bool
+	// Missing is set for versions recorded in schema_migrations that have no
+	// file in this build.
+	Missing bool
+}
+
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+func New(pool *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{pool: pool, migrations: migrations}, nil
+}
+
+// Load reads and orders every migration in the root of fsys.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	seen := map[int]string{}
+	var migrations []Migration
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %q does not match NNN_name.sql", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %q and %q share version %d", other, entry.Name(), version)
+		}
+		seen[version] = entry.Name()
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, parse(version, match[2], content))
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func parse(version int, name string, content []byte) Migration {
+	sum := sha256.Sum256(content)
+	m := Migration{Version: version, Name: name, Checksum: hex.EncodeToString(sum[:])}
+
+	var up, down strings.Builder
+	current := &up
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		switch strings.TrimSpace(line) {
+		case upMarker:
+			continue
+		case downMarker:
+			m.HasDown = true
+			current = &down
+			continue
+		}
+		current.WriteString(line)
+	}
+	m.Up = up.String()
+	m.Down = down.String()
+	return m
+}
+
+// Migrations returns the migrations known to this build, oldest first.
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Up applies every pending migration and returns the ones it ran.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		done, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := checkBaselined(ctx, conn, done); err != nil {
+			return err
+		}
+		if err := m.checkChecksums(done); err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := done[mig.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, mig); err != nil {
+				return err
+			}
+			applied = append(applied, mig)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the most recently applied migration. It returns nil when
+// nothing is applied.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var rolledBack *Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		done, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		latest := -1
+		for version := range done {
+			if version > latest {
+				latest = version
+			}
+		}
+		if latest < 0 {
+			return nil
+		}
+		mig, err := m.find(latest)
+		if err != nil {
+			return err
+		}
+		if err := m.revert(ctx, conn, mig); err != nil {
+			return err
+		}
+		rolledBack = &mig
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Goto migrates up or down until version is the latest applied migration.
+// Version 0 rolls everything back.
+func (m *Migrator) Goto(ctx context.Context, version int) error {
+	if version != 0 {
+		if _, err := m.find(version); err != nil {
+			return err
+		}
+	}
+	return m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		done, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := checkBaselined(ctx, conn, done); err != nil {
+			return err
+		}
+		if err := m.checkChecksums(done); err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := done[mig.Version]; ok && mig.Version > version {
+				if err := m.revert(ctx, conn, mig); err != nil {
+					return err
+				}
+			}
+		}
+		for _, mig := range m.migrations {
+			if _, ok := done[mig.Version]; !ok && mig.Version <= version {
+				if err := m.apply(ctx, conn, mig); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Baseline records every migration up to version as applied without running
+// it. It is meant for databases whose schema predates schema_migrations.
+func (m *Migrator) Baseline(ctx context.Context, version int) error {
+	if _, err := m.find(version); err != nil {
+		return err
+	}
+	return m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		for _, mig := range m.migrations {
+			if mig.Version > version {
+				break
+			}
+			_, err := conn.Exec(ctx, `
+				INSERT INTO schema_migrations (version, name, checksum)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (version) DO NOTHING
+			`, mig.Version, mig.Name, mig.Checksum)
+			if err != nil {
+				return fmt.Errorf("baseline %03d: %w", mig.Version, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Status reports every known migration plus any applied version this build
+// has no file for.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		rows, err := conn.Query(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version`)
+		if err != nil {
+			return err
+		}
+		type record struct {
+			name, checksum string
+			appliedAt      time.Time
+		}
+		records := map[int]record{}
+		for rows.Next() {
+			var version int
+			var r record
+			if err := rows.Scan(&version, &r.name, &r.checksum, &r.appliedAt); err != nil {
+				rows.Close()
+				return err
+			}
+			records[version] = r
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			s := Status{Migration: mig}
+			if r, ok := records[mig.Version]; ok {
+				appliedAt := r.appliedAt
+				s.Applied = true
+				s.AppliedAt = &appliedAt
+				s.ChecksumMismatch = r.checksum != mig.Checksum
+				delete(records, mig.Version)
+			}
+			statuses = append(statuses, s)
+		}
+		for version, r := range records {
+			appliedAt := r.appliedAt
+			statuses = append(statuses, Status{
+				Migration: Migration{Version: version, Name: r.name, Checksum: r.checksum},
+				Applied:   true,
+				AppliedAt: &appliedAt,
+				Missing:   true,
+			})
+		}
+		sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+		return nil
+	})
+	return statuses, err
+}
+
+// Create writes an empty NNN_name.sql into dir, numbered after the highest
+// existing migration, and returns its path.
+func Create(dir, name string) (string, error) {
+	slug := strings.Trim(regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if slug == "" {
+		return "", errors.New("migration name is empty")
+	}
+
+	existing, err := Load(os.DirFS(dir))
+	if err != nil {
+		return "", err
+	}
+	next := 1
+	if len(existing) > 0 {
+		next = existing[len(existing)-1].Version + 1
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%03d_%s.sql", next, slug))
+	body := fmt.Sprintf("%s\n\n%s\n", upMarker, downMarker)
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func (m *Migrator) find(version int) (Migration, error) {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return mig, nil
+		}
+	}
+	return Migration{}, fmt.Errorf("no migration with version %d", version)
+}
+
+func (m *Migrator) checkChecksums(done map[int]string) error {
+	for _, mig := range m.migrations {
+		if checksum, ok := done[mig.Version]; ok && checksum != mig.Checksum {
+			return fmt.Errorf("migration %03d_%s was modified after it was applied (checksum mismatch)", mig.Version, mig.Name)
+		}
+	}
+	return nil
+}
+
+// checkBaselined refuses to migrate a database whose schema predates
+// schema_migrations. 001_init.sql starts by dropping the tables it creates,
+// so applying it to such a database would wipe it; the operator has to
+// record the existing schema with Baseline first.
+func checkBaselined(ctx context.Context, conn *pgxpool.Conn, done map[int]string) error {
+	if len(done) > 0 {
+		return nil
+	}
+	var exists bool
+	if err := conn.QueryRow(ctx, "SELECT to_regclass('public.users') IS NOT NULL").Scan(&exists); err != nil {
+		return fmt.Errorf("check for an existing schema: %w", err)
+	}
+	if exists {
+		return ErrNotBaselined
+	}
+	return nil
+}
+
+// withLock holds the migration advisory lock on a single connection for the
+// duration of fn. Every statement in fn must run on that connection.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+
+	_, err = conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version integer PRIMARY KEY,
+			name text NOT NULL,
+			checksum text NOT NULL,
+			applied_at timestamp NOT NULL DEFAULT now()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context, conn *pgxpool.Conn) (map[int]string, error) {
+	rows, err := conn.Query(ctx, "SELECT version, checksum FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	done := map[int]string{}
+	for rows.Next() {
+		var version int
+		var checksum string
+		if err := rows.Scan(&version, &checksum); err != nil {
+			return nil, err
+		}
+		done[version] = checksum
+	}
+	return done, rows.Err()
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *pgxpool.Conn, mig Migration) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, mig.Up); err != nil {
+			return fmt.Errorf("apply %03d_%s: %w", mig.Version, mig.Name, err)
+		}
+		_, err := tx.Exec(ctx,
+			"INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+			mig.Version, mig.Name, mig.Checksum)
+		return err
+	})
+}
+
+func (m *Migrator) revert(ctx context.Context, conn *pgxpool.Conn, mig Migration) error {
+	if !mig.HasDown {
+		return fmt.Errorf("migration %03d_%s has no down section", mig.Version, mig.Name)
+	}
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if strings.TrimSpace(mig.Down) != "" {
+			if _, err := tx.Exec(ctx, mig.Down); err != nil {
+				return fmt.Errorf("revert %03d_%s: %w", mig.Version, mig.Name, err)
+			}
+		}
+		_, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", mig.Version)
+		return err
+	})
+}
@@ -0,0 +1,264 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bventy/backend/internal/db/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestLoadOrdersAndSplitsSections(t *testing.T) {
+	fsys := fstest.MapFS{
+		"010_second.sql": {Data: []byte("CREATE TABLE b ();\n-- migrate:down\nDROP TABLE b;\n")},
+		"002_first.sql":  {Data: []byte("-- migrate:up\nCREATE TABLE a ();\n")},
+		"embed.go":       {Data: []byte("package migrations")},
+	}
+	got, err := Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Version != 2 || got[1].Version != 10 {
+		t.Fatalf("unexpected order: %+v", got)
+	}
+	if got[0].HasDown || strings.Contains(got[0].Up, "migrate:up") {
+		t.Fatalf("unexpected first migration: %+v", got[0])
+	}
+	if !got[1].HasDown || strings.TrimSpace(got[1].Down) != "DROP TABLE b;" || strings.Contains(got[1].Up, "DROP") {
+		t.Fatalf("unexpected second migration: %+v", got[1])
+	}
+	if got[0].Checksum == got[1].Checksum || len(got[0].Checksum) != 64 {
+		t.Fatalf("unexpected checksums: %q %q", got[0].Checksum, got[1].Checksum)
+	}
+}
+
+func TestLoadRejectsBadFiles(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"duplicate version": {"001_a.sql": {}, "1_b.sql": {}},
+		"bad name":          {"init.sql": {}},
+	}
+	for name, fsys := range cases {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	got, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) == 0 || got[0].Version != 1 {
+		t.Fatalf("unexpected embedded migrations: %+v", got)
+	}
+}
+
+// TestShippedMigrationsUnchanged pins migrations that were applied before
+// schema_migrations existed; editing one breaks every database that ran it.
+func TestShippedMigrationsUnchanged(t *testing.T) {
+	shipped := map[int]string{
+		1:  "3c335cf88fa86ba9d1e7e615711ddc866c3822526dfd49470e6f5032773294f2",
+		2:  "56ab97c20ea8a8fd76dc0133148d6407cfdfd7dfbdc947250c8b304da2cfa87b",
+		10: "30ab8b226891e9b914f1d2a6acb8d514bfd27d64d9ff60f16eda220595537c81",
+		11: "0803dd61be75927bb054bb0d2e3e2df4a3a3fadae018fb30daa60bdaef611b9a",
+		12: "ad48395d7da6b9b7532d4a578bd54d6c170a2336d7973ee29368f726b14eefbb",
+	}
+	got, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range got {
+		if want, ok := shipped[m.Version]; ok && m.Checksum != want {
+			t.Errorf("%03d_%s.sql changed after it shipped", m.Version, m.Name)
+		}
+	}
+}
+
+func TestCreateNumbersAfterLatest(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "007_existing.sql"), []byte("SELECT 1;\n"), 0o644)
+
+	path, err := Create(dir, "Add Vendor Ratings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(path) != "008_add_vendor_ratings.sql" {
+		t.Fatalf("unexpected path %s", path)
+	}
+	content, _ := os.ReadFile(path)
+	if !strings.Contains(string(content), downMarker) {
+		t.Fatalf("template has no down marker: %q", content)
+	}
+}
+
+// scratchPool connects to a fresh database on the TEST_DATABASE_URL server
+// that is dropped when the test ends.
+func scratchPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+
+	admin, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool, drop, err := ScratchDatabase(ctx, admin, "migrate_test")
+	if err != nil {
+		admin.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		drop()
+		admin.Close()
+	})
+	return pool
+}
+
+func TestUpDownGoto(t *testing.T) {
+	pool := scratchPool(t)
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"001_a.sql": {Data: []byte("CREATE TABLE a (id int);\n-- migrate:down\nDROP TABLE a;\n")},
+		"002_b.sql": {Data: []byte("CREATE TABLE b (id int);\n-- migrate:down\nDROP TABLE b;\n")},
+		"003_c.sql": {Data: []byte("CREATE TABLE c (id int);\n")},
+	}
+	m, err := New(pool, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil || len(applied) != 3 {
+		t.Fatalf("up: applied=%d err=%v", len(applied), err)
+	}
+	if applied, _ := m.Up(ctx); len(applied) != 0 {
+		t.Fatalf("second up applied %d migrations", len(applied))
+	}
+
+	// 003 has no down section.
+	if _, err := m.Down(ctx); err == nil {
+		t.Fatal("expected down of irreversible migration to fail")
+	}
+
+	if _, err := pool.Exec(ctx, "DELETE FROM schema_migrations WHERE version = 3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Goto(ctx, 1); err != nil {
+		t.Fatalf("goto 1: %v", err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.Applied != (s.Version == 1) {
+			t.Fatalf("after goto 1, version %d applied=%v", s.Version, s.Applied)
+		}
+	}
+
+	// Editing an applied migration is refused.
+	fsys["001_a.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE a (id bigint);\n")}
+	m, _ = New(pool, fsys)
+	if _, err := m.Up(ctx); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Fatalf("expected checksum error, got %v", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	expected := &Snapshot{
+		Tables:      map[string]bool{"events": true, "users": true},
+		Columns:     map[string]string{"events.event_date": "timestamp not null", "events.title": "text", "users.id": "uuid"},
+		Indexes:     map[string]string{"events.idx_events_date": "CREATE INDEX ..."},
+		Constraints: map[string]string{"users.users_pkey": "PRIMARY KEY (id)"},
+	}
+	actual := &Snapshot{
+		Tables:      map[string]bool{"events": true, "legacy": true},
+		Columns:     map[string]string{"events.date": "timestamp not null", "events.title": "character varying(255)", "legacy.id": "int"},
+		Indexes:     map[string]string{},
+		Constraints: map[string]string{},
+	}
+
+	var got []string
+	for _, d := range Diff(expected, actual) {
+		got = append(got, d.Problem+" "+d.Kind+" "+d.Name)
+	}
+	want := []string{
+		"extra column events.date",
+		"missing column events.event_date",
+		"missing index events.idx_events_date",
+		"changed column events.title",
+		"extra table legacy",
+		"missing table users",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected drift:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestUpRefusesUnbaselinedDatabase(t *testing.T) {
+	pool := scratchPool(t)
+	ctx := context.Background()
+
+	if _, err := pool.Exec(ctx, "CREATE TABLE public.users (id int); INSERT INTO public.users VALUES (1)"); err != nil {
+		t.Fatal(err)
+	}
+	m, err := New(pool, migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); !errors.Is(err, ErrNotBaselined) {
+		t.Fatalf("expected ErrNotBaselined, got %v", err)
+	}
+	if err := m.Goto(ctx, 12); !errors.Is(err, ErrNotBaselined) {
+		t.Fatalf("goto: expected ErrNotBaselined, got %v", err)
+	}
+	var rows int
+	if err := pool.QueryRow(ctx, "SELECT count(*) FROM public.users").Scan(&rows); err != nil || rows != 1 {
+		t.Fatalf("users was touched: %d rows, %v", rows, err)
+	}
+}
+
+func TestVerifyDetectsDrift(t *testing.T) {
+	pool := scratchPool(t)
+	ctx := context.Background()
+
+	m, err := New(pool, migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	drifts, err := m.Verify(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drifts) != 0 {
+		t.Fatalf("expected no drift after up, got %v", drifts)
+	}
+
+	if _, err := pool.Exec(ctx, "ALTER TABLE events DROP COLUMN cover_image_url; CREATE INDEX idx_adhoc ON users (city)"); err != nil {
+		t.Fatal(err)
+	}
+	drifts, err = m.Verify(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range drifts {
+		got = append(got, d.String())
+	}
+	if len(drifts) != 2 || drifts[0].Name != "events.cover_image_url" || drifts[1].Name != "users.idx_adhoc" {
+		t.Fatalf("unexpected drift: %v", got)
+	}
+}
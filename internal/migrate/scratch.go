@@ -0,0 +1,40 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ScratchDatabase creates an empty database, named prefix plus a timestamp,
+// on the server pool is connected to. It returns a pool on it and a function
+// that closes that pool and drops the database.
+//
+// Building the schema from scratch needs a database rather than a schema:
+// 001 and other early migrations create tables in "public" by name and start
+// with unqualified DROP TABLE statements, which from a scratch schema on the
+// search_path would reach the real tables.
+func ScratchDatabase(ctx context.Context, pool *pgxpool.Pool, prefix string) (*pgxpool.Pool, func(), error) {
+	name := fmt.Sprintf("%s_%d", prefix, time.Now().UnixNano())
+	if _, err := pool.Exec(ctx, "CREATE DATABASE "+name); err != nil {
+		return nil, nil, fmt.Errorf("create scratch database: %w", err)
+	}
+	drop := func() {
+		pool.Exec(context.Background(), "DROP DATABASE IF EXISTS "+name+" WITH (FORCE)")
+	}
+
+	cfg := pool.Config()
+	cfg.ConnConfig.Database = name
+	delete(cfg.ConnConfig.RuntimeParams, "search_path")
+	scratch, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		drop()
+		return nil, nil, fmt.Errorf("connect scratch database: %w", err)
+	}
+	return scratch, func() {
+		scratch.Close()
+		drop()
+	}, nil
+}
@@ -0,0 +1,215 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Snapshot is the shape of one schema: tables, columns, indexes and
+// constraints keyed by name, with a normalized definition as the value.
+type Snapshot struct {
+	Tables      map[string]bool
+	Columns     map[string]string
+	Indexes     map[string]string
+	Constraints map[string]string
+}
+
+// Drift is one difference between the schema the migrations produce and the
+// live one.
+type Drift struct {
+	Kind     string // table, column, index or constraint
+	Name     string
+	Problem  string // missing, extra or changed
+	Expected string
+	Actual   string
+}
+
+func (d Drift) String() string {
+	switch d.Problem {
+	case "missing":
+		return fmt.Sprintf("missing %s %s (%s)", d.Kind, d.Name, d.Expected)
+	case "extra":
+		return fmt.Sprintf("extra %s %s (%s)", d.Kind, d.Name, d.Actual)
+	default:
+		return fmt.Sprintf("changed %s %s: expected %s, got %s", d.Kind, d.Name, d.Expected, d.Actual)
+	}
+}
+
+// Verify applies every migration to a scratch database and compares the
+// result with the pool's current schema. The pool's role needs CREATEDB.
+func (m *Migrator) Verify(ctx context.Context) ([]Drift, error) {
+	var live string
+	if err := m.pool.QueryRow(ctx, "SELECT current_schema()").Scan(&live); err != nil {
+		return nil, fmt.Errorf("read current schema: %w", err)
+	}
+	actual, err := Inspect(ctx, m.pool, live)
+	if err != nil {
+		return nil, err
+	}
+
+	scratchPool, drop, err := ScratchDatabase(ctx, m.pool, "migrate_verify")
+	if err != nil {
+		return nil, err
+	}
+	defer drop()
+
+	scratchMigrator := &Migrator{pool: scratchPool, migrations: m.migrations}
+	if _, err := scratchMigrator.Up(ctx); err != nil {
+		return nil, fmt.Errorf("apply migrations to scratch database: %w", err)
+	}
+	var scratch string
+	if err := scratchPool.QueryRow(ctx, "SELECT current_schema()").Scan(&scratch); err != nil {
+		return nil, fmt.Errorf("read scratch schema: %w", err)
+	}
+	expected, err := Inspect(ctx, scratchPool, scratch)
+	if err != nil {
+		return nil, err
+	}
+
+	return Diff(expected, actual), nil
+}
+
+// Inspect reads the tables, columns, indexes and constraints of schema.
+func Inspect(ctx context.Context, pool *pgxpool.Pool, schema string) (*Snapshot, error) {
+	s := &Snapshot{
+		Tables:      map[string]bool{},
+		Columns:     map[string]string{},
+		Indexes:     map[string]string{},
+		Constraints: map[string]string{},
+	}
+	// Definitions returned by Postgres qualify names with the schema; strip it
+	// so the scratch and live schemas compare equal.
+	unqualify := strings.NewReplacer(schema+".", "", `"`+schema+`".`, "")
+
+	rows, err := pool.Query(ctx, `
+		SELECT c.table_name, c.column_name, c.data_type, c.udt_name,
+		       c.character_maximum_length, c.is_nullable, COALESCE(c.column_default, '')
+		FROM information_schema.columns c
+		JOIN information_schema.tables t
+		  ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = $1 AND t.table_type = 'BASE TABLE'
+	`, schema)
+	if err != nil {
+		return nil, fmt.Errorf("inspect columns: %w", err)
+	}
+	for rows.Next() {
+		var table, column, dataType, udtName, nullable, def string
+		var maxLen *int
+		if err := rows.Scan(&table, &column, &dataType, &udtName, &maxLen, &nullable, &def); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		typ := dataType
+		if dataType == "ARRAY" {
+			typ = strings.TrimPrefix(udtName, "_") + "[]"
+		}
+		if maxLen != nil {
+			typ = fmt.Sprintf("%s(%d)", typ, *maxLen)
+		}
+		if nullable == "NO" {
+			typ += " not null"
+		}
+		if def != "" {
+			typ += " default " + unqualify.Replace(def)
+		}
+		s.Tables[table] = true
+		s.Columns[table+"."+column] = typ
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = pool.Query(ctx, `SELECT tablename, indexname, indexdef FROM pg_indexes WHERE schemaname = $1`, schema)
+	if err != nil {
+		return nil, fmt.Errorf("inspect indexes: %w", err)
+	}
+	for rows.Next() {
+		var table, name, def string
+		if err := rows.Scan(&table, &name, &def); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		s.Indexes[table+"."+name] = unqualify.Replace(def)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Not-null constraints are already part of the column definition.
+	rows, err = pool.Query(ctx, `
+		SELECT cl.relname, con.conname, pg_get_constraintdef(con.oid)
+		FROM pg_constraint con
+		JOIN pg_class cl ON cl.oid = con.conrelid
+		JOIN pg_namespace ns ON ns.oid = con.connamespace
+		WHERE ns.nspname = $1 AND con.contype <> 'n'
+	`, schema)
+	if err != nil {
+		return nil, fmt.Errorf("inspect constraints: %w", err)
+	}
+	for rows.Next() {
+		var table, name, def string
+		if err := rows.Scan(&table, &name, &def); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		s.Constraints[table+"."+name] = unqualify.Replace(def)
+	}
+	rows.Close()
+	return s, rows.Err()
+}
+
+// Diff lists what actual lacks, adds or defines differently compared to
+// expected. Objects on a missing or extra table are reported once, as the
+// table.
+func Diff(expected, actual *Snapshot) []Drift {
+	var drifts []Drift
+	for table := range expected.Tables {
+		if !actual.Tables[table] {
+			drifts = append(drifts, Drift{Kind: "table", Name: table, Problem: "missing", Expected: "table"})
+		}
+	}
+	for table := range actual.Tables {
+		if !expected.Tables[table] {
+			drifts = append(drifts, Drift{Kind: "table", Name: table, Problem: "extra", Actual: "table"})
+		}
+	}
+
+	skip := func(key string) bool {
+		table := key[:strings.Index(key, ".")]
+		return expected.Tables[table] != actual.Tables[table]
+	}
+	compare := func(kind string, want, got map[string]string) {
+		for key, def := range want {
+			if skip(key) {
+				continue
+			}
+			if other, ok := got[key]; !ok {
+				drifts = append(drifts, Drift{Kind: kind, Name: key, Problem: "missing", Expected: def})
+			} else if other != def {
+				drifts = append(drifts, Drift{Kind: kind, Name: key, Problem: "changed", Expected: def, Actual: other})
+			}
+		}
+		for key, def := range got {
+			if _, ok := want[key]; !ok && !skip(key) {
+				drifts = append(drifts, Drift{Kind: kind, Name: key, Problem: "extra", Actual: def})
+			}
+		}
+	}
+	compare("column", expected.Columns, actual.Columns)
+	compare("index", expected.Indexes, actual.Indexes)
+	compare("constraint", expected.Constraints, actual.Constraints)
+
+	sort.Slice(drifts, func(i, j int) bool {
+		if drifts[i].Name != drifts[j].Name {
+			return drifts[i].Name < drifts[j].Name
+		}
+		return drifts[i].Kind < drifts[j].Kind
+	})
+	return drifts
+}
@@ -0,0 +1,115 @@
+// Package moderation approves and rejects vendor profiles. The admin API and
+// bventyctl both go through it, so vendor.verified is published, and the
+// verification documents checked, the same way whichever one an operator uses.
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bventy/backend/internal/outbox"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrNotFound means there is no live vendor profile with that id.
+var ErrNotFound = errors.New("moderation: vendor not found")
+
+// RequiredDocuments are the vendor_documents kinds that must be accepted
+// before a vendor can be approved.
+var RequiredDocuments = []string{"gst_certificate", "identity", "address_proof"}
+
+// MissingDocumentsError is returned by Approve when some RequiredDocuments
+// have not been accepted yet.
+type MissingDocumentsError struct {
+	Missing []string
+}
+
+func (e *MissingDocumentsError) Error() string {
+	return fmt.Sprintf("moderation: documents not accepted: %s", strings.Join(e.Missing, ", "))
+}
+
+// Querier is satisfied by both the pool and a transaction.
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// MissingDocuments lists the RequiredDocuments the vendor has no accepted
+// document for, in RequiredDocuments order.
+func MissingDocuments(ctx context.Context, q Querier, vendorID string) ([]string, error) {
+	rows, err := q.Query(ctx, `
+		SELECT kind FROM unnest($2::text[]) WITH ORDINALITY AS r(kind, n)
+		WHERE NOT EXISTS (
+			SELECT 1 FROM vendor_documents d
+			WHERE d.vendor_id = $1 AND d.kind = r.kind AND d.status = 'accepted'
+		)
+		ORDER BY n
+	`, vendorID, RequiredDocuments)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// Approve marks the vendor verified. verifiedBy is the acting user's id, or
+// empty when the change did not come from a user. vendor.verified is only
+// published when the status actually changes, and only then must every one
+// of RequiredDocuments be accepted; otherwise it fails with a
+// *MissingDocumentsError.
+func Approve(ctx context.Context, pool *pgxpool.Pool, vendorID, verifiedBy string) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the row so only the request that actually flips the status
+	// publishes vendor.verified.
+	var previous string
+	event := outbox.VendorVerified{VendorID: vendorID, VerifiedBy: verifiedBy}
+	err = tx.QueryRow(ctx, `
+		SELECT status, slug, business_name, owner_user_id
+		FROM vendor_profiles WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+	`, vendorID).Scan(&previous, &event.Slug, &event.BusinessName, &event.OwnerUserID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if previous != "verified" {
+		missing, err := MissingDocuments(ctx, tx, vendorID)
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			return &MissingDocumentsError{Missing: missing}
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE vendor_profiles SET status = 'verified', updated_at = now() WHERE id = $1`, vendorID); err != nil {
+		return err
+	}
+	if previous != "verified" {
+		if err := outbox.Publish(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// Reject marks the vendor rejected, which hides it from the public listing.
+func Reject(ctx context.Context, pool *pgxpool.Pool, vendorID string) error {
+	var id string
+	err := pool.QueryRow(ctx,
+		`UPDATE vendor_profiles SET status = 'rejected', updated_at = now() WHERE id = $1 AND deleted_at IS NULL RETURNING id`,
+		vendorID,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+// Package notifications turns domain events into in-app notifications for
+// the users they concern.
+package notifications
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bventy/backend/internal/jobs"
+	"github.com/bventy/backend/internal/outbox"
+	"github.com/jackc/pgx/v5"
+)
+
+// Subscriber is the outbox subscriber name; it is stored with deliveries.
+const Subscriber = "notifications"
+
+// Subscribe notifies vendor owners when their vendor is verified or
+// shortlisted. Rows are written in the outbox's transaction, so each event
+// notifies at most once.
+func Subscribe(d *outbox.Dispatcher) {
+	d.SubscribeTx(Subscriber, notify, outbox.TypeVendorVerified, outbox.TypeVendorShortlisted)
+}
+
+func notify(ctx context.Context, tx pgx.Tx, m outbox.Message) error {
+	var vendorID string
+	switch m.Type {
+	case outbox.TypeVendorVerified:
+		var e outbox.VendorVerified
+		if err := m.Decode(&e); err != nil {
+			return jobs.Permanent(err)
+		}
+		vendorID = e.VendorID
+	case outbox.TypeVendorShortlisted:
+		var e outbox.VendorShortlisted
+		if err := m.Decode(&e); err != nil {
+			return jobs.Permanent(err)
+		}
+		vendorID = e.VendorID
+	default:
+		return nil
+	}
+
+	var owner string
+	err := tx.QueryRow(ctx, "SELECT owner_user_id FROM vendor_profiles WHERE id = $1 AND deleted_at IS NULL", vendorID).Scan(&owner)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil // the vendor is gone; nobody to tell
+	}
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO notifications (user_id, type, event_id, data)
+		SELECT id, $2, $3, $4 FROM users WHERE id = $1 AND deleted_at IS NULL
+		ON CONFLICT (user_id, event_id) DO NOTHING
+	`, owner, m.Type, m.ID, m.Payload)
+	return err
+}
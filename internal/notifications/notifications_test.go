@@ -0,0 +1,87 @@
+package notifications
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bventy/backend/internal/jobs"
+	"github.com/bventy/backend/internal/outbox"
+	"github.com/bventy/backend/internal/testdb"
+)
+
+func TestDispatcherNotifiesVendorOwner(t *testing.T) {
+	pool := testdb.New(t)
+	ctx := context.Background()
+
+	var ownerID, vendorID string
+	err := pool.QueryRow(ctx, `INSERT INTO users (email, password_hash, full_name) VALUES ('owner@example.com', 'x', 'Owner') RETURNING id`).Scan(&ownerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = pool.QueryRow(ctx, `
+		INSERT INTO vendor_profiles (owner_user_id, business_name, slug, category, city, whatsapp_link)
+		VALUES ($1, 'Owner Catering', 'owner-catering', 'Catering', 'Pune', 'https://wa.me/1') RETURNING id
+	`, ownerID).Scan(&vendorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := outbox.NewDispatcher(pool)
+	Subscribe(d)
+	w := jobs.NewWorker(pool, jobs.Options{})
+	d.Register(w)
+	run := func() {
+		t.Helper()
+		if _, err := d.DispatchOnce(ctx); err != nil {
+			t.Fatal(err)
+		}
+		for {
+			ran, err := w.RunOnce(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ran {
+				return
+			}
+		}
+	}
+
+	for _, e := range []outbox.Event{
+		outbox.VendorVerified{VendorID: vendorID, OwnerUserID: ownerID},
+		outbox.VendorShortlisted{VendorID: vendorID, EventID: "e1"},
+		outbox.GroupCreated{GroupID: "g1", OwnerUserID: ownerID},
+	} {
+		if err := outbox.Publish(ctx, pool, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run()
+
+	rows, err := pool.Query(ctx, "SELECT type FROM notifications WHERE user_id = $1 ORDER BY event_id", ownerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for rows.Next() {
+		var typ string
+		rows.Scan(&typ)
+		types = append(types, typ)
+	}
+	if len(types) != 2 || types[0] != outbox.TypeVendorVerified || types[1] != outbox.TypeVendorShortlisted {
+		t.Fatalf("notifications: %v", types)
+	}
+
+	// A redelivered event is recorded as consumed and notifies nobody again.
+	var eventID int64
+	pool.QueryRow(ctx, "SELECT id FROM outbox WHERE type = $1", outbox.TypeVendorVerified).Scan(&eventID)
+	if _, err := jobs.Enqueue(ctx, pool, outbox.KindDeliver, map[string]any{"subscriber": Subscriber, "event_id": eventID}); err != nil {
+		t.Fatal(err)
+	}
+	run()
+	var n, consumed int
+	pool.QueryRow(ctx, "SELECT count(*) FROM notifications WHERE user_id = $1", ownerID).Scan(&n)
+	pool.QueryRow(ctx, "SELECT count(*) FROM outbox_consumed WHERE subscriber = $1", Subscriber).Scan(&consumed)
+	if n != 2 || consumed != 2 {
+		t.Fatalf("after redelivery: %d notifications, %d consumed", n, consumed)
+	}
+}
@@ -0,0 +1,265 @@
+// Package openapi builds an OpenAPI 3 document from a list of operations whose
+// request and response bodies are Go types. Schemas are derived from the
+// types' json tags by reflection, so the spec cannot drift from the structs
+// the handlers actually encode.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Operation describes one route. Path uses gin syntax (/vendors/:id).
+type Operation struct {
+	Method     string
+	Path       string
+	Summary    string
+	Tag        string
+	Auth       bool
+	Deprecated bool
+	Query      []Param
+	Headers    []Param
+	// Request is a value of the JSON body type, or nil.
+	Request any
+	// Form lists multipart fields for upload endpoints. A field named "file"
+	// is documented as binary.
+	Form []string
+	// Responses maps status codes to a value of the body type; nil means the
+	// response has no documented body.
+	Responses map[int]any
+}
+
+type Param struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// Document is the generated spec. It marshals directly to OpenAPI JSON.
+type Document map[string]any
+
+var pathParam = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+// OpenAPIPath converts a gin path to OpenAPI syntax (/vendors/{id}).
+func OpenAPIPath(ginPath string) string {
+	return pathParam.ReplaceAllString(ginPath, "{$1}")
+}
+
+func Build(title, version string, ops []Operation) Document {
+	g := &generator{schemas: map[string]any{}}
+	paths := map[string]map[string]any{}
+
+	for _, op := range ops {
+		path := OpenAPIPath(op.Path)
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+
+		operation := map[string]any{
+			"summary":     op.Summary,
+			"operationId": operationID(op),
+			"responses":   g.responses(op.Responses),
+		}
+		if op.Tag != "" {
+			operation["tags"] = []string{op.Tag}
+		}
+		if op.Deprecated {
+			operation["deprecated"] = true
+		}
+		if op.Auth {
+			operation["security"] = []map[string][]string{{"bearerAuth": {}}}
+		}
+
+		var params []map[string]any
+		for _, match := range pathParam.FindAllStringSubmatch(op.Path, -1) {
+			params = append(params, map[string]any{
+				"name": match[1], "in": "path", "required": true, "schema": map[string]any{"type": "string"},
+			})
+		}
+		for _, q := range op.Query {
+			params = append(params, map[string]any{
+				"name": q.Name, "in": "query", "required": q.Required, "description": q.Description,
+				"schema": map[string]any{"type": "string"},
+			})
+		}
+		for _, h := range op.Headers {
+			params = append(params, map[string]any{
+				"name": h.Name, "in": "header", "required": h.Required, "description": h.Description,
+				"schema": map[string]any{"type": "string"},
+			})
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+
+		if op.Request != nil {
+			operation["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{"application/json": map[string]any{"schema": g.schema(reflect.TypeOf(op.Request))}},
+			}
+		} else if len(op.Form) > 0 {
+			props := map[string]any{}
+			for _, field := range op.Form {
+				if field == "file" {
+					props[field] = map[string]any{"type": "string", "format": "binary"}
+				} else {
+					props[field] = map[string]any{"type": "string"}
+				}
+			}
+			operation["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{"multipart/form-data": map[string]any{"schema": map[string]any{
+					"type": "object", "properties": props,
+				}}},
+			}
+		}
+
+		paths[path][strings.ToLower(op.Method)] = operation
+	}
+
+	return Document{
+		"openapi": "3.0.3",
+		"info":    map[string]any{"title": title, "version": version},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": g.schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+}
+
+// Has reports whether the document describes method on a gin-style path.
+func (d Document) Has(method, ginPath string) bool {
+	paths, _ := d["paths"].(map[string]map[string]any)
+	_, ok := paths[OpenAPIPath(ginPath)][strings.ToLower(method)]
+	return ok
+}
+
+func operationID(op Operation) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(op.Method))
+	for _, part := range strings.FieldsFunc(op.Path, func(r rune) bool { return r == '/' || r == '-' || r == '.' }) {
+		part = strings.TrimPrefix(part, ":")
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+type generator struct {
+	schemas map[string]any
+}
+
+func (g *generator) responses(responses map[int]any) map[string]any {
+	out := map[string]any{}
+	codes := make([]int, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		resp := map[string]any{"description": http.StatusText(code)}
+		if body := responses[code]; body != nil {
+			resp["content"] = map[string]any{"application/json": map[string]any{"schema": g.schema(reflect.TypeOf(body))}}
+		}
+		out[strconv.Itoa(code)] = resp
+	}
+	return out
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema returns the schema for t, registering named structs as components.
+func (g *generator) schema(t reflect.Type) map[string]any {
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Pointer:
+		s := g.schema(t.Elem())
+		if ref, ok := s["$ref"]; ok {
+			// nullable is ignored next to $ref in 3.0, so wrap it.
+			return map[string]any{"allOf": []any{map[string]any{"$ref": ref}}, "nullable": true}
+		}
+		s["nullable"] = true
+		return s
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Interface:
+		return map[string]any{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		if _, ok := g.schemas[t.Name()]; !ok {
+			g.schemas[t.Name()] = map[string]any{} // placeholder breaks recursion
+			g.schemas[t.Name()] = g.object(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	}
+	return map[string]any{}
+}
+
+func (g *generator) object(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		// Untagged embedded structs are inlined, as encoding/json does
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded := g.object(field.Type)
+			for k, v := range embedded["properties"].(map[string]any) {
+				props[k] = v
+			}
+			if r, ok := embedded["required"].([]string); ok {
+				required = append(required, r...)
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		s := g.schema(field.Type)
+		if doc := field.Tag.Get("doc"); doc != "" {
+			s["description"] = doc
+		}
+		if field.Tag.Get("deprecated") == "true" {
+			s["deprecated"] = true
+		}
+		props[name] = s
+		if strings.Contains(field.Tag.Get("binding"), "required") {
+			required = append(required, name)
+		}
+	}
+	obj := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		obj["required"] = required
+	}
+	return obj
+}
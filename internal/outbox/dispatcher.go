@@ -0,0 +1,218 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bventy/backend/internal/jobs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// KindDeliver is the job that hands one event to one subscriber.
+const KindDeliver = "outbox.deliver"
+
+// Handler consumes a message. It may run more than once for the same message
+// if the process dies after it returns; use Message.ID to stay idempotent.
+type Handler func(ctx context.Context, m Message) error
+
+// TxHandler consumes a message inside a transaction that also records it as
+// consumed, so database-only consumers see each message exactly once.
+type TxHandler func(ctx context.Context, tx pgx.Tx, m Message) error
+
+type subscriber struct {
+	types    map[string]bool // empty means every type
+	handle   Handler
+	handleTx TxHandler
+}
+
+func (s *subscriber) wants(eventType string) bool {
+	return len(s.types) == 0 || s.types[eventType]
+}
+
+// Dispatcher fans undispatched outbox rows out to subscribers. Each
+// (subscriber, event) pair becomes a KindDeliver job, so deliveries get the
+// job queue's retries and dead-lettering, and a slow subscriber never holds
+// up the others. Delivery order is not guaranteed.
+type Dispatcher struct {
+	pool *pgxpool.Pool
+	subs map[string]*subscriber
+
+	// PollInterval is how often Run looks for new rows. Defaults to 1s.
+	PollInterval time.Duration
+	// BatchSize caps rows fanned out per transaction. Defaults to 100.
+	BatchSize int
+	// KeepDispatched is how long dispatched rows stay for inspection.
+	// Defaults to 30 days.
+	KeepDispatched time.Duration
+}
+
+func NewDispatcher(pool *pgxpool.Pool) *Dispatcher {
+	return &Dispatcher{
+		pool:           pool,
+		subs:           map[string]*subscriber{},
+		PollInterval:   time.Second,
+		BatchSize:      100,
+		KeepDispatched: 30 * 24 * time.Hour,
+	}
+}
+
+// Subscribe registers fn under name for the given event types, or all types
+// when none are given. The name is stored with pending deliveries, so keep it
+// stable across releases.
+func (d *Dispatcher) Subscribe(name string, fn Handler, types ...string) {
+	d.subs[name] = &subscriber{types: typeSet(types), handle: fn}
+}
+
+// SubscribeTx is Subscribe for consumers whose only side effects are writes
+// through tx.
+func (d *Dispatcher) SubscribeTx(name string, fn TxHandler, types ...string) {
+	d.subs[name] = &subscriber{types: typeSet(types), handleTx: fn}
+}
+
+func typeSet(types []string) map[string]bool {
+	set := map[string]bool{}
+	for _, t := range types {
+		set[t] = true
+	}
+	return set
+}
+
+// Register adds the delivery job handler to w. Every process that dispatches
+// or works jobs must subscribe the same set of names.
+func (d *Dispatcher) Register(w *jobs.Worker) {
+	jobs.Handle(w, KindDeliver, d.deliver)
+}
+
+// Run dispatches new rows every PollInterval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+	lastPrune := time.Time{}
+	for {
+		for {
+			n, err := d.DispatchOnce(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("⚠️  Outbox dispatch failed: %v", err)
+			}
+			if n < d.BatchSize {
+				break
+			}
+		}
+		if time.Since(lastPrune) > time.Hour {
+			lastPrune = time.Now()
+			if _, err := d.pool.Exec(ctx, "DELETE FROM outbox WHERE dispatched_at < now() - $1::interval", d.KeepDispatched); err != nil && ctx.Err() == nil {
+				log.Printf("⚠️  Outbox prune failed: %v", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type delivery struct {
+	Subscriber string `json:"subscriber"`
+	EventID    int64  `json:"event_id"`
+}
+
+// DispatchOnce fans out up to BatchSize undispatched rows and returns how many
+// it took. Concurrent dispatchers skip each other's rows.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	n := 0
+	err := pgx.BeginFunc(ctx, d.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `
+			SELECT id, type FROM outbox
+			WHERE dispatched_at IS NULL
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		`, d.BatchSize)
+		if err != nil {
+			return err
+		}
+		type pending struct {
+			id        int64
+			eventType string
+		}
+		var batch []pending
+		for rows.Next() {
+			var p pending
+			if err := rows.Scan(&p.id, &p.eventType); err != nil {
+				rows.Close()
+				return err
+			}
+			batch = append(batch, p)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		ids := make([]int64, 0, len(batch))
+		for _, p := range batch {
+			for name, sub := range d.subs {
+				if !sub.wants(p.eventType) {
+					continue
+				}
+				if _, err := jobs.Enqueue(ctx, tx, KindDeliver, delivery{Subscriber: name, EventID: p.id}); err != nil {
+					return err
+				}
+			}
+			ids = append(ids, p.id)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		_, err = tx.Exec(ctx, "UPDATE outbox SET dispatched_at = now() WHERE id = ANY($1)", ids)
+		n = len(ids)
+		return err
+	})
+	return n, err
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, job delivery) error {
+	sub, ok := d.subs[job.Subscriber]
+	if !ok {
+		return jobs.Permanent(fmt.Errorf("no subscriber %q", job.Subscriber))
+	}
+
+	var m Message
+	err := d.pool.QueryRow(ctx,
+		"SELECT id, type, aggregate_id, payload, created_at FROM outbox WHERE id = $1", job.EventID,
+	).Scan(&m.ID, &m.Type, &m.AggregateID, &m.Payload, &m.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return jobs.Permanent(fmt.Errorf("outbox event %d is gone", job.EventID))
+	}
+	if err != nil {
+		return err
+	}
+
+	if sub.handleTx != nil {
+		return pgx.BeginFunc(ctx, d.pool, func(tx pgx.Tx) error {
+			tag, err := tx.Exec(ctx, "INSERT INTO outbox_consumed (subscriber, event_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", job.Subscriber, m.ID)
+			if err != nil || tag.RowsAffected() == 0 {
+				return err
+			}
+			return sub.handleTx(ctx, tx, m)
+		})
+	}
+
+	var done bool
+	err = d.pool.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM outbox_consumed WHERE subscriber = $1 AND event_id = $2)", job.Subscriber, m.ID,
+	).Scan(&done)
+	if err != nil || done {
+		return err
+	}
+	if err := sub.handle(ctx, m); err != nil {
+		return err
+	}
+	_, err = d.pool.Exec(ctx, "INSERT INTO outbox_consumed (subscriber, event_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", job.Subscriber, m.ID)
+	return err
+}
@@ -0,0 +1,63 @@
+package outbox
+
+import "time"
+
+// Event types. These names are part of the webhook contract; never rename one.
+const (
+	TypeVendorVerified    = "vendor.verified"
+	TypeVendorShortlisted = "vendor.shortlisted"
+	TypeGroupCreated      = "group.created"
+	TypeEventCreated      = "event.created"
+)
+
+// Types lists every event type, for validating subscriptions.
+var Types = []string{TypeVendorVerified, TypeVendorShortlisted, TypeGroupCreated, TypeEventCreated}
+
+// VendorVerified is published when an admin approves a vendor that was not
+// already verified.
+type VendorVerified struct {
+	VendorID     string `json:"vendor_id"`
+	Slug         string `json:"slug"`
+	BusinessName string `json:"business_name"`
+	OwnerUserID  string `json:"owner_user_id"`
+	VerifiedBy   string `json:"verified_by"`
+}
+
+func (e VendorVerified) Type() string        { return TypeVendorVerified }
+func (e VendorVerified) AggregateID() string { return e.VendorID }
+
+// VendorShortlisted is published the first time a vendor is shortlisted for an
+// event.
+type VendorShortlisted struct {
+	EventID       string `json:"event_id"`
+	VendorID      string `json:"vendor_id"`
+	ShortlistedBy string `json:"shortlisted_by"`
+}
+
+func (e VendorShortlisted) Type() string        { return TypeVendorShortlisted }
+func (e VendorShortlisted) AggregateID() string { return e.VendorID }
+
+type GroupCreated struct {
+	GroupID     string `json:"group_id"`
+	Name        string `json:"name"`
+	Slug        string `json:"slug"`
+	City        string `json:"city"`
+	OwnerUserID string `json:"owner_user_id"`
+}
+
+func (e GroupCreated) Type() string        { return TypeGroupCreated }
+func (e GroupCreated) AggregateID() string { return e.GroupID }
+
+type EventCreated struct {
+	EventID          string    `json:"event_id"`
+	Title            string    `json:"title"`
+	City             string    `json:"city"`
+	EventType        string    `json:"event_type"`
+	EventDate        time.Time `json:"event_date"`
+	OrganizerUserID  *string   `json:"organizer_user_id"`
+	OrganizerGroupID *string   `json:"organizer_group_id"`
+	CreatedBy        string    `json:"created_by"`
+}
+
+func (e EventCreated) Type() string        { return TypeEventCreated }
+func (e EventCreated) AggregateID() string { return e.EventID }
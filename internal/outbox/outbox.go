@@ -0,0 +1,58 @@
+// Package outbox records domain events in the outbox table, in the same
+// transaction as the change they describe, and delivers them to subscribers
+// at least once.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Execer is satisfied by *pgxpool.Pool and pgx.Tx. Pass the transaction that
+// makes the change, so the event exists exactly when the change does.
+type Execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// Event is a typed domain event. See events.go for the catalogue.
+type Event interface {
+	// Type is the stable name subscribers filter on, e.g. "vendor.verified".
+	Type() string
+	// AggregateID is the id of the row the event is about.
+	AggregateID() string
+}
+
+// Publish writes e to the outbox.
+func Publish(ctx context.Context, q Execer, e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", e.Type(), err)
+	}
+	_, err = q.Exec(ctx,
+		"INSERT INTO outbox (type, aggregate_id, payload) VALUES ($1, $2, $3)",
+		e.Type(), e.AggregateID(), payload)
+	if err != nil {
+		return fmt.Errorf("publish %s: %w", e.Type(), err)
+	}
+	return nil
+}
+
+// Message is an event as delivered to a subscriber. ID is unique and stable
+// across redeliveries, so consumers with outside side effects can use it as
+// an idempotency key.
+type Message struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID string          `json:"aggregate_id"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// Decode unmarshals the payload into the event type named by m.Type.
+func (m Message) Decode(v any) error {
+	return json.Unmarshal(m.Payload, v)
+}
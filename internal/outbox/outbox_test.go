@@ -0,0 +1,112 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bventy/backend/internal/jobs"
+	"github.com/bventy/backend/internal/testdb"
+	"github.com/jackc/pgx/v5"
+)
+
+// drain runs jobs until none are due.
+func drain(t *testing.T, w *jobs.Worker) {
+	t.Helper()
+	for {
+		ran, err := w.RunOnce(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ran {
+			return
+		}
+	}
+}
+
+func TestPublishIsTransactional(t *testing.T) {
+	pool := testdb.New(t)
+	ctx := context.Background()
+
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		if err := Publish(ctx, tx, GroupCreated{GroupID: "g1"}); err != nil {
+			return err
+		}
+		return errors.New("roll back")
+	})
+	if err == nil {
+		t.Fatal("expected rollback error")
+	}
+	var n int
+	pool.QueryRow(ctx, "SELECT count(*) FROM outbox").Scan(&n)
+	if n != 0 {
+		t.Fatalf("rolled-back event left %d outbox rows", n)
+	}
+}
+
+func TestDispatchDeliversOncePerSubscriber(t *testing.T) {
+	pool := testdb.New(t)
+	ctx := context.Background()
+
+	d := NewDispatcher(pool)
+	w := jobs.NewWorker(pool, jobs.Options{BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	d.Register(w)
+
+	var verified []VendorVerified
+	failFirst := true
+	d.Subscribe("test.verified", func(ctx context.Context, m Message) error {
+		if failFirst {
+			failFirst = false
+			return errors.New("receiver down")
+		}
+		var e VendorVerified
+		if err := m.Decode(&e); err != nil {
+			return err
+		}
+		verified = append(verified, e)
+		return nil
+	}, TypeVendorVerified)
+	counted := 0
+	d.SubscribeTx("test.all", func(ctx context.Context, tx pgx.Tx, m Message) error {
+		counted++
+		return nil
+	})
+
+	if err := Publish(ctx, pool, VendorVerified{VendorID: "v1", Slug: "v-one"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Publish(ctx, pool, GroupCreated{GroupID: "g1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := d.DispatchOnce(ctx); err != nil || n != 2 {
+		t.Fatalf("dispatched %d, err %v; want 2", n, err)
+	}
+	if n, _ := d.DispatchOnce(ctx); n != 0 {
+		t.Fatalf("redispatched %d rows", n)
+	}
+
+	drain(t, w)
+	time.Sleep(5 * time.Millisecond) // let the retry come due
+	drain(t, w)
+	if len(verified) != 1 || verified[0].Slug != "v-one" {
+		t.Fatalf("verified subscriber got %+v", verified)
+	}
+	if counted != 2 {
+		t.Fatalf("all-types subscriber got %d messages, want 2", counted)
+	}
+
+	// A duplicate delivery, as after a crash, is skipped.
+	var eventID int64
+	pool.QueryRow(ctx, "SELECT id FROM outbox WHERE type = $1", TypeVendorVerified).Scan(&eventID)
+	for _, sub := range []string{"test.verified", "test.all"} {
+		if _, err := jobs.Enqueue(ctx, pool, KindDeliver, delivery{Subscriber: sub, EventID: eventID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	drain(t, w)
+	if len(verified) != 1 || counted != 2 {
+		t.Fatalf("duplicate delivered again: %d verified, %d counted", len(verified), counted)
+	}
+}
@@ -0,0 +1,112 @@
+package routes_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestVendorAvailability(t *testing.T) {
+	r := newServer(t)
+	admin := adminClient(t, r)
+	public := &client{t: t, r: r}
+	owner, _ := signup(t, r, "calendar")
+	organizer, _ := signup(t, r, "planner")
+
+	name := uniqueName("Busy Bees")
+	vendorID, _ := onboardVendor(t, owner, name)
+	approveVendor(t, admin, vendorID)
+
+	type day struct {
+		Date     string `json:"date"`
+		Status   string `json:"status"`
+		Booked   int    `json:"booked"`
+		Capacity int    `json:"capacity"`
+	}
+	set := func(date string, body gin.H) day {
+		t.Helper()
+		rec := owner.do(http.MethodPut, "/v1/vendor/me/availability/"+date, body)
+		expectStatus(t, rec, http.StatusOK)
+		var d day
+		decode(t, rec, &d)
+		return d
+	}
+	available := func(date string) bool {
+		t.Helper()
+		rec := public.do(http.MethodGet, "/v1/vendors?"+url.Values{"q": {name}, "available_on": {date}}.Encode(), nil)
+		expectStatus(t, rec, http.StatusOK)
+		var res struct {
+			Vendors []idOnly `json:"vendors"`
+		}
+		decode(t, rec, &res)
+		return containsID(res.Vendors, vendorID)
+	}
+
+	// Two events a day; one booked leaves the day partly free.
+	expectStatus(t, patch(t, owner, "/v1/vendor/me", gin.H{"daily_capacity": 2}), http.StatusOK)
+	expectStatus(t, patch(t, owner, "/v1/vendor/me", gin.H{"daily_capacity": 0}), http.StatusBadRequest)
+	if d := set("2027-03-13", gin.H{"booked": 1}); d.Status != "partial" || d.Capacity != 2 {
+		t.Fatalf("one of two booked: %+v", d)
+	}
+	if d := set("2027-03-14", gin.H{"booked": 1, "capacity": 1, "note": "Wedding"}); d.Status != "full" {
+		t.Fatalf("one of one booked: %+v", d)
+	}
+	if d := set("2027-03-15", gin.H{"blocked": true}); d.Status != "blocked" {
+		t.Fatalf("blocked: %+v", d)
+	}
+	expectStatus(t, owner.do(http.MethodPut, "/v1/vendor/me/availability/14-03-2027", gin.H{}), http.StatusBadRequest)
+	expectStatus(t, owner.do(http.MethodPut, "/v1/vendor/me/availability/2027-03-16", gin.H{"booked": -1}), http.StatusBadRequest)
+
+	rec := owner.do(http.MethodGet, "/v1/vendor/me/availability?from=2027-03-01&to=2027-03-31", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var calendar struct {
+		DailyCapacity int   `json:"daily_capacity"`
+		Days          []day `json:"days"`
+	}
+	decode(t, rec, &calendar)
+	if calendar.DailyCapacity != 2 || len(calendar.Days) != 3 || calendar.Days[0].Date != "2027-03-13" {
+		t.Fatalf("calendar: %+v", calendar)
+	}
+	expectStatus(t, owner.do(http.MethodGet, "/v1/vendor/me/availability?from=2027-03-01&to=2026-03-01", nil), http.StatusBadRequest)
+
+	if !available("2027-03-13") || available("2027-03-14") || available("2027-03-15") || !available("2027-03-16") {
+		t.Fatal("available_on does not follow the calendar")
+	}
+	expectStatus(t, public.do(http.MethodGet, "/v1/vendors?available_on=soon", nil), http.StatusBadRequest)
+
+	// The shortlist flags the vendor on a full day.
+	rec = organizer.do(http.MethodPost, "/v1/events", gin.H{"title": "Reception", "city": "Pune", "event_date": "2027-03-14"})
+	expectStatus(t, rec, http.StatusCreated)
+	var created struct {
+		EventID string `json:"event_id"`
+	}
+	decode(t, rec, &created)
+	expectStatus(t, organizer.do(http.MethodPost, "/v1/events/"+created.EventID+"/shortlist/"+vendorID, nil), http.StatusOK)
+	shortlistFlag := func() bool {
+		t.Helper()
+		rec := organizer.do(http.MethodGet, "/v1/events/"+created.EventID, nil)
+		expectStatus(t, rec, http.StatusOK)
+		var event struct {
+			Shortlist []struct {
+				ID          string `json:"id"`
+				Unavailable bool   `json:"unavailable"`
+			} `json:"shortlist"`
+		}
+		decode(t, rec, &event)
+		if len(event.Shortlist) != 1 || event.Shortlist[0].ID != vendorID {
+			t.Fatalf("shortlist: %+v", event)
+		}
+		return event.Shortlist[0].Unavailable
+	}
+	if !shortlistFlag() {
+		t.Fatal("vendor not flagged on a full day")
+	}
+
+	expectStatus(t, owner.do(http.MethodDelete, "/v1/vendor/me/availability/2027-03-14", nil), http.StatusOK)
+	expectStatus(t, owner.do(http.MethodDelete, "/v1/vendor/me/availability/2027-03-14", nil), http.StatusNotFound)
+	if shortlistFlag() || !available("2027-03-14") {
+		t.Fatal("day still unavailable after clearing it")
+	}
+}
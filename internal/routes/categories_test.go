@@ -0,0 +1,125 @@
+package routes_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCategoryTaxonomy(t *testing.T) {
+	r := newServer(t)
+	admin := adminClient(t, r)
+	public := &client{t: t, r: r}
+	owner, _ := signup(t, r, "cakes")
+
+	type category struct {
+		ID       string     `json:"id"`
+		ParentID *string    `json:"parent_id"`
+		Slug     string     `json:"slug"`
+		Name     string     `json:"name"`
+		Children []category `json:"children"`
+	}
+	create := func(body gin.H) category {
+		t.Helper()
+		rec := admin.do(http.MethodPost, "/v1/admin/categories", body)
+		expectStatus(t, rec, http.StatusCreated)
+		var cat category
+		decode(t, rec, &cat)
+		return cat
+	}
+	patchCategory := func(id string, body gin.H) *httptest.ResponseRecorder {
+		return admin.doWith(http.MethodPatch, "/v1/admin/categories/"+id, body,
+			map[string]string{"Content-Type": "application/merge-patch+json"})
+	}
+	name := uniqueName("Desserts")
+	parent := create(gin.H{"name": name, "aliases": []string{"Sweets " + name}})
+	if parent.Slug != strings.ToLower(strings.ReplaceAll(name, " ", "-")) {
+		t.Fatalf("slug not derived from name: %+v", parent)
+	}
+	child := create(gin.H{"name": "Cakes", "slug": parent.Slug + "-cakes", "parent_id": parent.ID})
+
+	expectStatus(t, admin.do(http.MethodPost, "/v1/admin/categories", gin.H{"name": "Again", "slug": parent.Slug}), http.StatusConflict)
+	// Categories nest one level deep.
+	expectStatus(t, admin.do(http.MethodPost, "/v1/admin/categories", gin.H{"name": "Cupcakes", "parent_id": child.ID}), http.StatusBadRequest)
+	expectStatus(t, patchCategory(parent.ID, gin.H{"parent_id": child.ID}), http.StatusBadRequest)
+	expectStatus(t, owner.do(http.MethodPost, "/v1/admin/categories", gin.H{"name": "Mine"}), http.StatusForbidden)
+
+	rec := public.do(http.MethodGet, "/v1/categories", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var tree []category
+	decode(t, rec, &tree)
+	found := false
+	for _, cat := range tree {
+		if cat.ID == parent.ID {
+			found = len(cat.Children) == 1 && cat.Children[0].ID == child.ID
+		}
+	}
+	if !found {
+		t.Fatalf("tree is missing %s with its child: %+v", parent.Slug, tree)
+	}
+
+	// Onboarding takes an alias for the main category; unknown ones are
+	// rejected.
+	body := gin.H{"business_name": uniqueName("Sugar Rush"), "category": "sweets " + strings.ToLower(name),
+		"categories": []string{child.Slug}, "city": "Pune", "whatsapp_link": "https://wa.me/910000000000"}
+	expectStatus(t, owner.do(http.MethodPost, "/v1/vendor/onboard", gin.H{"business_name": "X", "category": "Nonsense " + name,
+		"city": "Pune", "whatsapp_link": "https://wa.me/910000000000"}), http.StatusBadRequest)
+	rec = owner.do(http.MethodPost, "/v1/vendor/onboard", body)
+	expectStatus(t, rec, http.StatusCreated)
+	var onboarded struct {
+		VendorID string `json:"vendor_id"`
+	}
+	decode(t, rec, &onboarded)
+	approveVendor(t, admin, onboarded.VendorID)
+
+	var mine struct {
+		Category   string   `json:"category"`
+		Categories []string `json:"categories"`
+	}
+	rec = owner.do(http.MethodGet, "/v1/vendor/me", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &mine)
+	if mine.Category != name || len(mine.Categories) != 2 || mine.Categories[0] != parent.Slug {
+		t.Fatalf("categories after onboarding: %+v", mine)
+	}
+
+	// Searching the parent finds vendors in its subcategories.
+	rec = public.do(http.MethodGet, "/v1/vendors?"+url.Values{"category": {parent.Slug}}.Encode(), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var res struct {
+		Vendors []idOnly `json:"vendors"`
+	}
+	decode(t, rec, &res)
+	if !containsID(res.Vendors, onboarded.VendorID) {
+		t.Fatalf("category=%s: %+v", parent.Slug, res)
+	}
+
+	// Moving the main category to the child drops the old one.
+	rec = patch(t, owner, "/v1/vendor/me", gin.H{"category": child.Slug})
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &mine)
+	if mine.Category != "Cakes" || len(mine.Categories) != 1 || mine.Categories[0] != child.Slug {
+		t.Fatalf("categories after patch: %+v", mine)
+	}
+	expectStatus(t, patch(t, owner, "/v1/vendor/me", gin.H{"categories": []string{"nope-" + child.Slug}}), http.StatusBadRequest)
+
+	// Renaming a category renames it on its vendors.
+	rec = patchCategory(child.ID, gin.H{"name": "Celebration Cakes"})
+	expectStatus(t, rec, http.StatusOK)
+	rec = owner.do(http.MethodGet, "/v1/vendor/me", nil)
+	decode(t, rec, &mine)
+	if mine.Category != "Celebration Cakes" {
+		t.Fatalf("category not renamed on the vendor: %+v", mine)
+	}
+
+	// Categories in use cannot be deleted.
+	expectStatus(t, admin.do(http.MethodDelete, "/v1/admin/categories/"+child.ID, nil), http.StatusConflict)
+	expectStatus(t, admin.do(http.MethodDelete, "/v1/admin/categories/"+parent.ID, nil), http.StatusConflict)
+	unused := create(gin.H{"name": uniqueName("Unused")})
+	expectStatus(t, admin.do(http.MethodDelete, "/v1/admin/categories/"+unused.ID, nil), http.StatusOK)
+	expectStatus(t, admin.do(http.MethodDelete, "/v1/admin/categories/"+unused.ID, nil), http.StatusNotFound)
+}
@@ -0,0 +1,68 @@
+package routes_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bventy/backend/internal/routes"
+	"github.com/gin-gonic/gin"
+)
+
+func TestProfileEditsRequireCurrentETag(t *testing.T) {
+	r := newServer(t)
+	owner, _ := signup(t, r, "twodevices")
+	onboardVendor(t, owner, uniqueName("Two Devices"))
+
+	edits := []struct {
+		path string
+		body gin.H
+	}{
+		{"/v1/vendor/me", gin.H{"bio": "from the phone"}},
+		{"/v1/me", gin.H{"full_name": "From The Phone"}},
+	}
+	for _, e := range edits {
+		rec := owner.do(http.MethodGet, e.path, nil)
+		expectStatus(t, rec, http.StatusOK)
+		etag := rec.Header().Get("ETag")
+		if etag == "" {
+			t.Fatalf("GET %s: no ETag", e.path)
+		}
+
+		expectStatus(t, owner.do(http.MethodPut, e.path, e.body), http.StatusPreconditionRequired)
+
+		// The phone saves first...
+		rec = owner.doWith(http.MethodPut, e.path, e.body, map[string]string{"If-Match": etag})
+		expectStatus(t, rec, http.StatusOK)
+		next := rec.Header().Get("ETag")
+		if next == "" || next == etag {
+			t.Fatalf("PUT %s: ETag %q after update, was %q", e.path, next, etag)
+		}
+
+		// ...so the laptop, still holding the old ETag, is refused.
+		rec = owner.doWith(http.MethodPut, e.path, e.body, map[string]string{"If-Match": etag})
+		expectStatus(t, rec, http.StatusPreconditionFailed)
+		if rec.Header().Get("ETag") != next {
+			t.Fatalf("PUT %s: 412 carries ETag %q, want %q", e.path, rec.Header().Get("ETag"), next)
+		}
+
+		// Legacy routes keep working without If-Match until their sunset, but
+		// a stale one is refused there too.
+		legacy := e.path[len("/v1"):]
+		expectStatus(t, owner.doWith(http.MethodPut, legacy, e.body, map[string]string{"If-Match": etag}), http.StatusPreconditionFailed)
+		expectStatus(t, owner.do(http.MethodPut, legacy, e.body), http.StatusOK)
+	}
+}
+
+func TestLegacyProfileEditsNeedIfMatchAfterSunset(t *testing.T) {
+	newServer(t)
+	cfg := *testCfg
+	cfg.LegacyRoutesSunset = time.Now().Add(-time.Hour)
+	r := gin.New()
+	routes.RegisterRoutes(r, &cfg, testMedia)
+	owner, _ := signup(t, r, "sunset")
+	onboardVendor(t, owner, uniqueName("Sunset Caterers"))
+
+	expectStatus(t, owner.do(http.MethodPut, "/vendor/me", gin.H{"bio": "too late"}), http.StatusPreconditionRequired)
+	expectStatus(t, owner.do(http.MethodPut, "/me", gin.H{"full_name": "Too Late"}), http.StatusPreconditionRequired)
+}
@@ -0,0 +1,123 @@
+package routes_test
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestVendorDocuments(t *testing.T) {
+	r := newServer(t)
+	admin := adminClient(t, r)
+	owner, _ := signup(t, r, "kyc")
+	vendorID, _ := onboardVendor(t, owner, uniqueName("Verified Venue"))
+
+	type document struct {
+		ID         string `json:"id"`
+		Kind       string `json:"kind"`
+		Status     string `json:"status"`
+		ReviewNote string `json:"review_note"`
+		URL        string `json:"url"`
+	}
+	var list struct {
+		Missing   []string   `json:"missing"`
+		Documents []document `json:"documents"`
+	}
+	upload := func(kind, contentType string) *httptest.ResponseRecorder {
+		t.Helper()
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		w.WriteField("kind", kind)
+		part, err := w.CreatePart(map[string][]string{
+			"Content-Disposition": {fmt.Sprintf(`form-data; name="file"; filename="%s.pdf"`, kind)},
+			"Content-Type":        {contentType},
+		})
+		if err != nil {
+			t.Fatalf("create part: %v", err)
+		}
+		part.Write([]byte("%PDF-1.4 " + kind))
+		w.Close()
+		req := httptest.NewRequest(http.MethodPost, "/v1/vendor/me/documents", &buf)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		return owner.send(req)
+	}
+	approve := "/v1/admin/vendors/" + vendorID + "/approve"
+
+	rec := admin.do(http.MethodPatch, approve, nil)
+	expectStatus(t, rec, http.StatusConflict)
+	decode(t, rec, &list)
+	if len(list.Missing) != 3 {
+		t.Fatalf("missing before upload: %+v", list)
+	}
+	expectStatus(t, upload("passport", "application/pdf"), http.StatusBadRequest)
+	expectStatus(t, upload("identity", "text/plain"), http.StatusBadRequest)
+
+	docs := map[string]document{}
+	for _, kind := range []string{"gst_certificate", "identity", "address_proof"} {
+		rec := upload(kind, "application/pdf")
+		expectStatus(t, rec, http.StatusCreated)
+		var d document
+		decode(t, rec, &d)
+		if d.Status != "pending" {
+			t.Fatalf("new document: %+v", d)
+		}
+		docs[kind] = d
+	}
+
+	// Admins see each file through a signed link to the private prefix.
+	documentsPath := "/v1/admin/vendors/" + vendorID + "/documents"
+	expectStatus(t, owner.do(http.MethodGet, documentsPath, nil), http.StatusForbidden)
+	rec = admin.do(http.MethodGet, documentsPath, nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &list)
+	if len(list.Documents) != 3 || list.Documents[0].Kind != "gst_certificate" {
+		t.Fatalf("admin documents: %+v", list)
+	}
+	for _, d := range list.Documents {
+		key := strings.TrimPrefix(strings.SplitN(d.URL, "?", 2)[0], "https://media.test/signed/")
+		if !strings.HasPrefix(key, "private/vendors/"+vendorID+"/") || !testMedia.has(key) {
+			t.Fatalf("document link %q", d.URL)
+		}
+	}
+
+	review := func(kind string, body gin.H) *httptest.ResponseRecorder {
+		return admin.do(http.MethodPatch, documentsPath+"/"+docs[kind].ID, body)
+	}
+	expectStatus(t, review("address_proof", gin.H{"status": "rejected"}), http.StatusBadRequest)
+	expectStatus(t, review("address_proof", gin.H{"status": "maybe"}), http.StatusBadRequest)
+	expectStatus(t, review("address_proof", gin.H{"status": "rejected", "note": "Bill is older than three months"}), http.StatusOK)
+	expectStatus(t, review("gst_certificate", gin.H{"status": "accepted"}), http.StatusOK)
+	expectStatus(t, review("identity", gin.H{"status": "accepted"}), http.StatusOK)
+
+	rec = admin.do(http.MethodPatch, approve, nil)
+	expectStatus(t, rec, http.StatusConflict)
+	decode(t, rec, &list)
+	if !slices.Equal(list.Missing, []string{"address_proof"}) {
+		t.Fatalf("missing after review: %+v", list)
+	}
+	rec = owner.do(http.MethodGet, "/v1/vendor/me/documents", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &list)
+	if list.Documents[2].Status != "rejected" || list.Documents[2].ReviewNote == "" {
+		t.Fatalf("vendor's view of the rejection: %+v", list)
+	}
+
+	// Accepted documents stay; a rejected one is replaced and reviewed again.
+	expectStatus(t, owner.do(http.MethodDelete, "/v1/vendor/me/documents/"+docs["identity"].ID, nil), http.StatusConflict)
+	rec = upload("address_proof", "application/pdf")
+	expectStatus(t, rec, http.StatusCreated)
+	var replaced document
+	decode(t, rec, &replaced)
+	if replaced.ID != docs["address_proof"].ID || replaced.Status != "pending" || replaced.ReviewNote != "" {
+		t.Fatalf("replacement: %+v", replaced)
+	}
+	expectStatus(t, review("address_proof", gin.H{"status": "accepted"}), http.StatusOK)
+	expectStatus(t, admin.do(http.MethodPatch, approve, nil), http.StatusOK)
+}
@@ -0,0 +1,52 @@
+package routes_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFeatureFlagAdmin(t *testing.T) {
+	r := newServer(t)
+	admin := adminClient(t, r)
+	user, _ := signup(t, r, "flagged")
+	key := "test-flag-" + uniqueName("x")[2:]
+
+	expectStatus(t, user.do(http.MethodPut, "/v1/admin/flags/"+key, gin.H{"enabled": true}), http.StatusForbidden)
+	expectStatus(t, admin.do(http.MethodPut, "/v1/admin/flags/Bad_Key", gin.H{}), http.StatusBadRequest)
+	expectStatus(t, admin.do(http.MethodPut, "/v1/admin/flags/"+key, gin.H{"roles": []string{"wizard"}}), http.StatusBadRequest)
+
+	flagsFor := func(c *client) map[string]bool {
+		rec := c.do(http.MethodGet, "/v1/flags", nil)
+		expectStatus(t, rec, http.StatusOK)
+		var got map[string]bool
+		decode(t, rec, &got)
+		return got
+	}
+
+	rec := admin.do(http.MethodPut, "/v1/admin/flags/"+key, gin.H{
+		"description": "staff preview", "enabled": true, "roles": []string{"admin"},
+	})
+	expectStatus(t, rec, http.StatusOK)
+	if !flagsFor(admin)[key] || flagsFor(user)[key] {
+		t.Fatal("role targeting not applied")
+	}
+
+	expectStatus(t, admin.do(http.MethodPatch, "/v1/admin/flags/"+key, gin.H{"rollout_percent": 100}), http.StatusOK)
+	if !flagsFor(user)[key] {
+		t.Fatal("100% rollout not applied")
+	}
+
+	// The kill switch wins over targeting.
+	expectStatus(t, admin.do(http.MethodPatch, "/v1/admin/flags/"+key, gin.H{"enabled": false}), http.StatusOK)
+	if flagsFor(admin)[key] || flagsFor(user)[key] {
+		t.Fatal("disabled flag still on")
+	}
+
+	expectStatus(t, admin.do(http.MethodDelete, "/v1/admin/flags/"+key, nil), http.StatusOK)
+	expectStatus(t, admin.do(http.MethodPatch, "/v1/admin/flags/"+key, gin.H{"enabled": true}), http.StatusNotFound)
+	if _, ok := flagsFor(user)[key]; ok {
+		t.Fatal("deleted flag still evaluated")
+	}
+}
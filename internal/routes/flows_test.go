@@ -0,0 +1,418 @@
+package routes_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/jobs"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func uniqueName(prefix string) string {
+	return prefix + " " + uuid.New().String()[:8]
+}
+
+func onboardVendor(t *testing.T, c *client, name string) (id, slug string) {
+	t.Helper()
+	rec := c.do(http.MethodPost, "/v1/vendor/onboard", gin.H{
+		"business_name": name,
+		"category":      "Catering",
+		"city":          "Pune",
+		"bio":           "Best food in town",
+		"whatsapp_link": "https://wa.me/910000000000",
+	})
+	expectStatus(t, rec, http.StatusCreated)
+	var res struct {
+		VendorID string `json:"vendor_id"`
+		Slug     string `json:"slug"`
+	}
+	decode(t, rec, &res)
+	return res.VendorID, res.Slug
+}
+
+func TestHealth(t *testing.T) {
+	c := &client{t: t, r: newServer(t)}
+	rec := c.do(http.MethodGet, "/v1/health", nil)
+	expectStatus(t, rec, http.StatusOK)
+}
+
+func TestSignupAndLogin(t *testing.T) {
+	r := newServer(t)
+	user, email := signup(t, r, "user")
+
+	anon := &client{t: t, r: r}
+	rec := anon.do(http.MethodPost, "/v1/auth/signup", gin.H{
+		"email": email, "password": "password123", "full_name": "Duplicate",
+	})
+	expectStatus(t, rec, http.StatusConflict)
+
+	rec = anon.do(http.MethodPost, "/v1/auth/signup", gin.H{"email": "not-an-email", "password": "x"})
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = anon.do(http.MethodPost, "/v1/auth/login", gin.H{"email": email, "password": "wrong-password"})
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	rec = login(t, r, email).do(http.MethodGet, "/v1/me", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var me struct {
+		Email               string `json:"email"`
+		Role                string `json:"role"`
+		VendorProfileExists bool   `json:"vendor_profile_exists"`
+	}
+	decode(t, rec, &me)
+	if me.Email != email || me.Role != "user" || me.VendorProfileExists {
+		t.Fatalf("unexpected /me: %+v", me)
+	}
+
+	expectStatus(t, anon.do(http.MethodGet, "/v1/me", nil), http.StatusUnauthorized)
+	expectStatus(t, (&client{t: t, r: r, token: "garbage"}).do(http.MethodGet, "/v1/me", nil), http.StatusUnauthorized)
+	expectStatus(t, user.do(http.MethodGet, "/v1/admin/vendors", nil), http.StatusForbidden)
+}
+
+func TestVendorOnboardingAndApproval(t *testing.T) {
+	r := newServer(t)
+	owner, _ := signup(t, r, "vendor")
+	admin := adminClient(t, r)
+	public := &client{t: t, r: r}
+
+	name := uniqueName("Pro Audio")
+	vendorID, slug := onboardVendor(t, owner, name)
+	if vendorID == "" || slug == "" {
+		t.Fatal("onboard returned no vendor id or slug")
+	}
+
+	rec := owner.do(http.MethodPost, "/v1/vendor/onboard", gin.H{
+		"business_name": name, "category": "Sound", "city": "Pune", "whatsapp_link": "wa.me/1",
+	})
+	expectStatus(t, rec, http.StatusConflict)
+
+	rec = owner.do(http.MethodGet, "/v1/vendor/me", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var mine struct {
+		Slug     string `json:"slug"`
+		Verified bool   `json:"verified"`
+	}
+	decode(t, rec, &mine)
+	if mine.Slug != slug || mine.Verified {
+		t.Fatalf("unexpected vendor profile before approval: %+v", mine)
+	}
+
+	// Pending vendors are not public.
+	expectStatus(t, public.do(http.MethodGet, "/v1/vendors/slug/"+slug, nil), http.StatusNotFound)
+
+	rec = admin.do(http.MethodGet, "/v1/admin/vendors?status=pending", nil)
+	expectStatus(t, rec, http.StatusOK)
+	// Newest first, so the vendor just onboarded is on the first page.
+	var pending struct {
+		Vendors []idOnly `json:"vendors"`
+	}
+	decode(t, rec, &pending)
+	if !containsID(pending.Vendors, vendorID) {
+		t.Fatalf("vendor %s missing from pending list", vendorID)
+	}
+
+	expectStatus(t, owner.do(http.MethodPatch, "/v1/admin/vendors/"+vendorID+"/approve", nil), http.StatusForbidden)
+	approveVendor(t, admin, vendorID)
+
+	rec = public.do(http.MethodGet, "/v1/vendors", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var listed struct {
+		Vendors []idOnly `json:"vendors"`
+	}
+	decode(t, rec, &listed)
+	if !containsID(listed.Vendors, vendorID) {
+		t.Fatalf("approved vendor %s missing from public list", vendorID)
+	}
+	// The legacy route keeps serving a plain array.
+	rec = public.do(http.MethodGet, "/vendors", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var legacy []idOnly
+	decode(t, rec, &legacy)
+	if !containsID(legacy, vendorID) {
+		t.Fatalf("approved vendor %s missing from legacy list", vendorID)
+	}
+
+	rec = public.do(http.MethodGet, "/v1/vendors/slug/"+slug, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var bySlug struct {
+		ID           string `json:"id"`
+		BusinessName string `json:"business_name"`
+	}
+	decode(t, rec, &bySlug)
+	if bySlug.ID != vendorID || bySlug.BusinessName != name {
+		t.Fatalf("unexpected vendor by slug: %+v", bySlug)
+	}
+
+	expectStatus(t, admin.do(http.MethodPatch, "/v1/admin/vendors/"+vendorID+"/reject", nil), http.StatusOK)
+	expectStatus(t, public.do(http.MethodGet, "/v1/vendors/slug/"+slug, nil), http.StatusNotFound)
+}
+
+// pngImage is a small valid PNG for image uploads.
+func pngImage(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// runMediaJobs runs every due media job against testMedia.
+func runMediaJobs(t *testing.T) {
+	t.Helper()
+	w := jobs.NewWorker(db.Pool, jobs.Options{})
+	jobs.RegisterMedia(w, testMedia)
+	for {
+		ran, err := w.RunOnce(context.Background(), jobs.KindConvertImage, jobs.KindDeleteMedia)
+		if err != nil {
+			t.Fatalf("run job: %v", err)
+		}
+		if !ran {
+			return
+		}
+	}
+}
+
+func TestVendorGalleryUsesMediaStore(t *testing.T) {
+	r := newServer(t)
+	owner, _ := signup(t, r, "gallery")
+	other, _ := signup(t, r, "intruder")
+	vendorID, _ := onboardVendor(t, owner, uniqueName("Gallery Studio"))
+
+	path := "/v1/vendors/" + vendorID + "/gallery"
+	expectStatus(t, other.upload(path, "file", "a.png", "image/png", pngImage(t)), http.StatusForbidden)
+	expectStatus(t, owner.upload(path, "file", "a.png", "image/png", []byte("png")), http.StatusBadRequest)
+
+	rec := owner.upload(path, "file", "a.png", "image/png", pngImage(t))
+	expectStatus(t, rec, http.StatusAccepted)
+	var res struct {
+		URL    string `json:"url"`
+		Status string `json:"status"`
+	}
+	decode(t, rec, &res)
+	if !testMedia.has(res.URL) || !strings.HasSuffix(res.URL, ".png") || res.Status != "pending" {
+		t.Fatalf("upload %+v not stored as the original", res)
+	}
+
+	// The job swaps in the WebP copy and deletes the original.
+	runMediaJobs(t)
+	var stored string
+	err := db.Pool.QueryRow(context.Background(), "SELECT image_url FROM vendor_gallery_images WHERE vendor_id = $1", vendorID).Scan(&stored)
+	if err != nil {
+		t.Fatalf("load gallery image: %v", err)
+	}
+	if !strings.HasSuffix(stored, ".webp") || !testMedia.has(stored) || testMedia.has(res.URL) {
+		t.Fatalf("gallery image %s was not converted from %s", stored, res.URL)
+	}
+}
+
+func TestProfileImageConvertedInBackground(t *testing.T) {
+	r := newServer(t)
+	user, _ := signup(t, r, "portrait")
+
+	rec := user.upload("/v1/users/profile-image", "file", "me.png", "image/png", pngImage(t))
+	expectStatus(t, rec, http.StatusAccepted)
+	var res struct {
+		URL string `json:"url"`
+	}
+	decode(t, rec, &res)
+	var me struct {
+		ProfileImageURL *string `json:"profile_image_url"`
+	}
+	rec = user.do(http.MethodGet, "/v1/me", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &me)
+	if me.ProfileImageURL == nil || *me.ProfileImageURL != res.URL {
+		t.Fatalf("profile image before conversion: %v, want %s", me.ProfileImageURL, res.URL)
+	}
+
+	// A second upload before the first is converted wins; the first job
+	// finds its original gone and leaves the row alone.
+	rec = user.upload("/v1/users/profile-image", "file", "me2.png", "image/png", pngImage(t))
+	expectStatus(t, rec, http.StatusAccepted)
+	var second struct {
+		URL string `json:"url"`
+	}
+	decode(t, rec, &second)
+	etag := user.do(http.MethodGet, "/v1/me", nil).Header().Get("ETag")
+	runMediaJobs(t)
+
+	rec = user.do(http.MethodGet, "/v1/me", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &me)
+	if me.ProfileImageURL == nil || !strings.HasSuffix(*me.ProfileImageURL, ".webp") || !testMedia.has(*me.ProfileImageURL) {
+		t.Fatalf("profile image after conversion: %v", me.ProfileImageURL)
+	}
+	if testMedia.has(res.URL) || testMedia.has(second.URL) {
+		t.Fatalf("originals %s and %s should be deleted", res.URL, second.URL)
+	}
+	if rec.Header().Get("ETag") == etag {
+		t.Fatalf("conversion did not bump the version (ETag %s)", etag)
+	}
+}
+
+func TestGroups(t *testing.T) {
+	r := newServer(t)
+	owner, _ := signup(t, r, "owner")
+
+	name := uniqueName("Tech Meetups")
+	rec := owner.do(http.MethodPost, "/v1/groups", gin.H{"name": name, "city": "San Francisco", "description": "Tech lovers"})
+	expectStatus(t, rec, http.StatusCreated)
+	var created struct {
+		GroupID string `json:"group_id"`
+		Slug    string `json:"slug"`
+	}
+	decode(t, rec, &created)
+
+	rec = owner.do(http.MethodGet, "/v1/groups/my", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var groups struct {
+		Groups []struct {
+			ID   string `json:"id"`
+			Role string `json:"role"`
+		} `json:"groups"`
+	}
+	decode(t, rec, &groups)
+	if len(groups.Groups) != 1 || groups.Groups[0].ID != created.GroupID || groups.Groups[0].Role != "owner" {
+		t.Fatalf("unexpected groups: %+v", groups)
+	}
+
+	// Same name and city produce the same slug.
+	rec = owner.do(http.MethodPost, "/v1/groups", gin.H{"name": name, "city": "San Francisco"})
+	expectStatus(t, rec, http.StatusConflict)
+
+	expectStatus(t, owner.do(http.MethodPost, "/v1/groups", gin.H{"city": "Nowhere"}), http.StatusBadRequest)
+}
+
+func TestEventShortlist(t *testing.T) {
+	r := newServer(t)
+	organizer, _ := signup(t, r, "organizer")
+	outsider, _ := signup(t, r, "outsider")
+	vendorOwner, _ := signup(t, r, "vendor")
+	vendorID, _ := onboardVendor(t, vendorOwner, uniqueName("Shortlist Caterers"))
+
+	rec := organizer.do(http.MethodPost, "/v1/groups", gin.H{"name": uniqueName("Planners"), "city": "Pune"})
+	expectStatus(t, rec, http.StatusCreated)
+	var group struct {
+		GroupID string `json:"group_id"`
+	}
+	decode(t, rec, &group)
+
+	event := gin.H{
+		"title":              "Tech Conference",
+		"city":               "Pune",
+		"event_date":         "2026-11-20",
+		"organizer_group_id": group.GroupID,
+	}
+	expectStatus(t, outsider.do(http.MethodPost, "/v1/events", event), http.StatusForbidden)
+
+	rec = organizer.do(http.MethodPost, "/v1/events", event)
+	expectStatus(t, rec, http.StatusCreated)
+	var created struct {
+		EventID string `json:"event_id"`
+	}
+	decode(t, rec, &created)
+
+	expectStatus(t, organizer.do(http.MethodPost, "/v1/events", gin.H{
+		"title": "Bad Date", "city": "Pune", "event_date": "20/11/2026",
+	}), http.StatusBadRequest)
+
+	rec = organizer.do(http.MethodGet, "/v1/events", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var events struct {
+		Events []struct {
+			ID        string `json:"id"`
+			EventDate string `json:"event_date"`
+		} `json:"events"`
+	}
+	decode(t, rec, &events)
+	if len(events.Events) != 1 || events.Events[0].ID != created.EventID || events.Events[0].EventDate != "2026-11-20" {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+
+	shortlistPath := "/v1/events/" + created.EventID + "/shortlist/" + vendorID
+	expectStatus(t, organizer.do(http.MethodPost, shortlistPath, nil), http.StatusOK)
+	// Shortlisting twice is a no-op.
+	expectStatus(t, organizer.do(http.MethodPost, shortlistPath, nil), http.StatusOK)
+
+	var published int
+	db.Pool.QueryRow(context.Background(),
+		"SELECT count(*) FROM outbox WHERE type = 'vendor.shortlisted' AND payload->>'event_id' = $1", created.EventID,
+	).Scan(&published)
+	if published != 1 {
+		t.Fatalf("vendor.shortlisted published %d times, want 1", published)
+	}
+
+	rec = organizer.do(http.MethodGet, "/v1/events/"+created.EventID+"/shortlist", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var shortlisted struct {
+		Vendors []idOnly `json:"vendors"`
+	}
+	decode(t, rec, &shortlisted)
+	if len(shortlisted.Vendors) != 1 || shortlisted.Vendors[0].ID != vendorID {
+		t.Fatalf("unexpected shortlist: %+v", shortlisted)
+	}
+
+	rec = organizer.do(http.MethodGet, "/v1/events/"+created.EventID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var detail struct {
+		OrganizerGroupID string   `json:"organizer_group_id"`
+		Shortlist        []idOnly `json:"shortlist"`
+	}
+	decode(t, rec, &detail)
+	if detail.OrganizerGroupID != group.GroupID || len(detail.Shortlist) != 1 {
+		t.Fatalf("unexpected event detail: %+v", detail)
+	}
+
+	expectStatus(t, organizer.do(http.MethodGet, "/v1/events/"+uuid.New().String(), nil), http.StatusNotFound)
+}
+
+func TestRoleManagement(t *testing.T) {
+	r := newServer(t)
+	target, targetEmail := signup(t, r, "target")
+	admin := adminClient(t, r)
+
+	_, superEmail := signup(t, r, "super")
+	setRole(t, superEmail, "super_admin")
+	super := login(t, r, superEmail)
+
+	rec := target.do(http.MethodGet, "/v1/me", nil)
+	var me idOnly
+	decode(t, rec, &me)
+
+	path := "/v1/admin/users/" + me.ID + "/role"
+	expectStatus(t, admin.do(http.MethodPatch, path, gin.H{"role": "staff"}), http.StatusForbidden)
+	expectStatus(t, super.do(http.MethodPatch, path, gin.H{"role": "emperor"}), http.StatusBadRequest)
+	expectStatus(t, super.do(http.MethodPatch, path, gin.H{"role": "staff"}), http.StatusOK)
+	// The old token carried the old role and is revoked.
+	expectStatus(t, target.do(http.MethodGet, "/v1/me", nil), http.StatusUnauthorized)
+
+	rec = login(t, r, targetEmail).do(http.MethodGet, "/v1/me", nil)
+	var updated struct {
+		Role string `json:"role"`
+	}
+	decode(t, rec, &updated)
+	if updated.Role != "staff" {
+		t.Fatalf("expected role staff, got %q", updated.Role)
+	}
+}
+
+type idOnly struct {
+	ID string `json:"id"`
+}
+
+func containsID(items []idOnly, id string) bool {
+	for _, item := range items {
+		if item.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package routes_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bventy/backend/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func TestIdempotencyKeyReplaysCreate(t *testing.T) {
+	r := newServer(t)
+	owner, _ := signup(t, r, "retrying")
+	other, _ := signup(t, r, "bystander")
+
+	post := func(c *client, key string, body gin.H) *httptest.ResponseRecorder {
+		t.Helper()
+		return c.doWith(http.MethodPost, "/v1/groups", body, map[string]string{"Idempotency-Key": key})
+	}
+	body := gin.H{"name": uniqueName("Retry Club"), "city": "Pune"}
+
+	first := post(owner, "create-group-1", body)
+	expectStatus(t, first, http.StatusCreated)
+	if first.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatal("first response marked as replayed")
+	}
+
+	// Without the key the duplicate slug would be a 409.
+	retry := post(owner, "create-group-1", body)
+	expectStatus(t, retry, http.StatusCreated)
+	if retry.Header().Get("Idempotent-Replayed") != "true" || retry.Body.String() != first.Body.String() {
+		t.Fatalf("retry not replayed: %v %s", retry.Header(), retry.Body.String())
+	}
+
+	rec := owner.do(http.MethodGet, "/v1/groups/my", nil)
+	var groups struct {
+		Groups []idOnly `json:"groups"`
+	}
+	decode(t, rec, &groups)
+	if len(groups.Groups) != 1 {
+		t.Fatalf("retry created %d groups, want 1", len(groups.Groups))
+	}
+
+	expectStatus(t, post(owner, "create-group-1", gin.H{"name": uniqueName("Other Club"), "city": "Pune"}), http.StatusUnprocessableEntity)
+
+	// Keys are per user.
+	expectStatus(t, post(other, "create-group-1", gin.H{"name": uniqueName("Own Club"), "city": "Pune"}), http.StatusCreated)
+}
+
+func TestIdempotencyKeyReleasedOnPanic(t *testing.T) {
+	user, _ := signup(t, newServer(t), "panicky")
+
+	calls := 0
+	r := gin.New()
+	r.Use(gin.RecoveryWithWriter(io.Discard))
+	r.POST("/flaky", middleware.AuthMiddleware(testCfg), middleware.Idempotent(testCfg), func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("handler bug")
+		}
+		c.JSON(http.StatusCreated, gin.H{"calls": calls})
+	})
+	c := &client{t: t, r: r, token: user.token}
+	post := func() *httptest.ResponseRecorder {
+		return c.doWith(http.MethodPost, "/flaky", gin.H{}, map[string]string{"Idempotency-Key": "flaky-1"})
+	}
+
+	expectStatus(t, post(), http.StatusInternalServerError)
+	// The retry runs instead of waiting out the lease with a 409.
+	expectStatus(t, post(), http.StatusCreated)
+	if rec := post(); rec.Header().Get("Idempotent-Replayed") != "true" || calls != 2 {
+		t.Fatalf("after the retry: replayed=%q calls=%d", rec.Header().Get("Idempotent-Replayed"), calls)
+	}
+}
@@ -0,0 +1,94 @@
+package routes_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLocationsAndRadiusSearch(t *testing.T) {
+	r := newServer(t)
+	admin := adminClient(t, r)
+	public := &client{t: t, r: r}
+	owner, _ := signup(t, r, "bombay")
+
+	rec := public.do(http.MethodGet, "/v1/locations?q=bomb", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var locations []struct {
+		City string `json:"city"`
+	}
+	decode(t, rec, &locations)
+	if len(locations) != 1 || locations[0].City != "Mumbai" {
+		t.Fatalf("q=bomb: %+v", locations)
+	}
+
+	name := uniqueName("Bandra Bites")
+	body := gin.H{"business_name": name, "category": "Catering", "city": "bombay",
+		"latitude": 19.06, "whatsapp_link": "https://wa.me/910000000000"}
+	expectStatus(t, owner.do(http.MethodPost, "/v1/vendor/onboard", body), http.StatusBadRequest)
+	body["longitude"] = 72.83
+	rec = owner.do(http.MethodPost, "/v1/vendor/onboard", body)
+	expectStatus(t, rec, http.StatusCreated)
+	var onboarded struct {
+		VendorID string `json:"vendor_id"`
+	}
+	decode(t, rec, &onboarded)
+	approveVendor(t, admin, onboarded.VendorID)
+
+	var mine struct {
+		City string `json:"city"`
+	}
+	rec = owner.do(http.MethodGet, "/v1/vendor/me", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &mine)
+	if mine.City != "Mumbai" {
+		t.Fatalf("city not normalised: %+v", mine)
+	}
+
+	type hit struct {
+		ID         string   `json:"id"`
+		DistanceKm *float64 `json:"distance_km"`
+	}
+	search := func(q url.Values) []hit {
+		t.Helper()
+		rec := public.do(http.MethodGet, "/v1/vendors?"+q.Encode(), nil)
+		expectStatus(t, rec, http.StatusOK)
+		var res struct {
+			Vendors []hit `json:"vendors"`
+		}
+		decode(t, rec, &res)
+		return res.Vendors
+	}
+	find := func(hits []hit) *hit {
+		for i := range hits {
+			if hits[i].ID == onboarded.VendorID {
+				return &hits[i]
+			}
+		}
+		return nil
+	}
+
+	// Churchgate is about 12 km from the vendor, Pune about 120.
+	hits := search(url.Values{"near": {"18.9322,72.8264"}, "radius_km": {"20"}, "sort": {"distance"}, "limit": {"100"}})
+	h := find(hits)
+	if h == nil || h.DistanceKm == nil || *h.DistanceKm < 10 || *h.DistanceKm > 20 {
+		t.Fatalf("near Churchgate: %+v", hits)
+	}
+	for i := 1; i < len(hits); i++ {
+		if *hits[i].DistanceKm < *hits[i-1].DistanceKm {
+			t.Fatalf("not sorted by distance: %+v", hits)
+		}
+	}
+	if find(search(url.Values{"near": {"18.9322,72.8264"}, "radius_km": {"5"}})) != nil {
+		t.Fatal("vendor found outside the radius")
+	}
+	if find(search(url.Values{"city": {"Bombay"}, "q": {name}})) == nil {
+		t.Fatal("city=Bombay does not find Mumbai vendors")
+	}
+
+	for _, q := range []string{"near=19.06", "near=91,72", "radius_km=10", "near=19,72&radius_km=0", "sort=distance"} {
+		expectStatus(t, public.do(http.MethodGet, "/v1/vendors?"+q, nil), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,311 @@
+package routes_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bventy/backend/internal/config"
+	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/db/migrations"
+	"github.com/bventy/backend/internal/migrate"
+	"github.com/bventy/backend/internal/moderation"
+	"github.com/bventy/backend/internal/routes"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// The integration suite runs against a throwaway database on the server named
+// by TEST_DATABASE_URL. Without it only the tests that need no database run.
+var (
+	testCfg   *config.Config
+	testMedia *fakeMediaStore
+	testAdmin *pgxpool.Pool
+)
+
+func TestMain(m *testing.M) {
+	testMedia = newFakeMediaStore()
+
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		fmt.Println("TEST_DATABASE_URL not set, skipping integration tests")
+		os.Exit(m.Run())
+	}
+
+	ctx := context.Background()
+	var err error
+	testAdmin, err = pgxpool.New(ctx, dbURL)
+	if err != nil {
+		log.Fatalf("connect: %v", err)
+	}
+	var dropDatabase func()
+	db.Pool, dropDatabase, err = migrate.ScratchDatabase(ctx, testAdmin, "bventy_test")
+	if err != nil {
+		log.Fatalf("create database: %v", err)
+	}
+
+	migrator, err := migrate.New(db.Pool, migrations.FS)
+	if err != nil {
+		log.Fatalf("load migrations: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		log.Fatalf("migrate: %v", err)
+	}
+
+	testCfg = &config.Config{JWTSecret: "integration-test-secret", WebhooksAllowHTTP: true, WebhooksAllowPrivate: true, VendorCacheTTL: time.Minute, IdempotencyTTL: time.Hour}
+
+	code := m.Run()
+
+	dropDatabase()
+	testAdmin.Close()
+	os.Exit(code)
+}
+
+// newServer returns an engine backed by the test schema, skipping t when
+// there is no database.
+func newServer(t *testing.T) *gin.Engine {
+	t.Helper()
+	if testCfg == nil {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	routes.RegisterRoutes(r, testCfg, testMedia)
+	return r
+}
+
+// fakeMediaStore keeps uploads in memory instead of R2.
+type fakeMediaStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	deleted []string
+}
+
+func newFakeMediaStore() *fakeMediaStore {
+	return &fakeMediaStore{objects: map[string][]byte{}}
+}
+
+func (f *fakeMediaStore) UploadFile(ctx context.Context, file multipart.File, originalFilename string, contentType string, prefixPath string) (string, error) {
+	return f.put(file, prefixPath, filepath.Ext(originalFilename))
+}
+
+// ConvertToWebP copies the original under a .webp name; the bytes are not
+// re-encoded.
+func (f *fakeMediaStore) ConvertToWebP(ctx context.Context, fileURL string, prefixPath string) (string, error) {
+	f.mu.Lock()
+	data, ok := f.objects[fileURL]
+	f.mu.Unlock()
+	if !ok {
+		return "", fmt.Errorf("no object at %s", fileURL)
+	}
+	return f.put(bytes.NewReader(data), prefixPath, ".webp")
+}
+
+func (f *fakeMediaStore) DeleteFile(ctx context.Context, fileURL string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.objects, fileURL)
+	f.deleted = append(f.deleted, fileURL)
+	return nil
+}
+
+func (f *fakeMediaStore) UploadPrivateFile(ctx context.Context, file multipart.File, originalFilename string, contentType string, prefixPath string) (string, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("private/%s/%s%s", strings.Trim(prefixPath, "/"), uuid.New().String(), filepath.Ext(originalFilename))
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[key] = data
+	return key, nil
+}
+
+func (f *fakeMediaStore) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	return fmt.Sprintf("https://media.test/signed/%s?expires=%d", key, time.Now().Add(ttl).Unix()), nil
+}
+
+func (f *fakeMediaStore) DeletePrivateFile(ctx context.Context, key string) error {
+	return f.DeleteFile(ctx, key)
+}
+
+func (f *fakeMediaStore) put(file io.Reader, prefixPath, ext string) (string, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	url := fmt.Sprintf("https://media.test/%s/%s%s", strings.TrimPrefix(prefixPath, "/"), uuid.New().String(), ext)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[url] = data
+	return url, nil
+}
+
+func (f *fakeMediaStore) has(url string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.objects[url]
+	return ok
+}
+
+// client wraps the engine with an optional bearer token.
+type client struct {
+	t     *testing.T
+	r     *gin.Engine
+	token string
+}
+
+func (c *client) do(method, path string, body interface{}) *httptest.ResponseRecorder {
+	c.t.Helper()
+	return c.doWith(method, path, body, nil)
+}
+
+// doWith is do with extra request headers, e.g. If-Match.
+func (c *client) doWith(method, path string, body interface{}, headers map[string]string) *httptest.ResponseRecorder {
+	c.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			c.t.Fatalf("marshal body: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return c.send(req)
+}
+
+func (c *client) upload(path, field, filename, contentType string, content []byte) *httptest.ResponseRecorder {
+	c.t.Helper()
+	return c.uploadWithContext(context.Background(), path, field, filename, contentType, content)
+}
+
+func (c *client) uploadWithContext(ctx context.Context, path, field, filename, contentType string, content []byte) *httptest.ResponseRecorder {
+	c.t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	h := make(map[string][]string)
+	h["Content-Disposition"] = []string{fmt.Sprintf(`form-data; name=%q; filename=%q`, field, filename)}
+	h["Content-Type"] = []string{contentType}
+	part, err := w.CreatePart(h)
+	if err != nil {
+		c.t.Fatalf("create part: %v", err)
+	}
+	part.Write(content)
+	w.Close()
+
+	req := httptest.NewRequestWithContext(ctx, http.MethodPost, path, &buf)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return c.send(req)
+}
+
+func (c *client) send(req *http.Request) *httptest.ResponseRecorder {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	rec := httptest.NewRecorder()
+	c.r.ServeHTTP(rec, req)
+	return rec
+}
+
+func expectStatus(t *testing.T, rec *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if rec.Code != want {
+		t.Fatalf("expected status %d, got %d: %s", want, rec.Code, rec.Body.String())
+	}
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body.String(), err)
+	}
+}
+
+// signup registers a fresh user and returns a client authenticated as them.
+func signup(t *testing.T, r *gin.Engine, name string) (*client, string) {
+	t.Helper()
+	email := fmt.Sprintf("%s_%s@example.com", name, uuid.New().String()[:8])
+	anon := &client{t: t, r: r}
+
+	rec := anon.do(http.MethodPost, "/v1/auth/signup", gin.H{
+		"email":     email,
+		"password":  "password123",
+		"full_name": name,
+	})
+	expectStatus(t, rec, http.StatusCreated)
+	var res struct {
+		Token string `json:"token"`
+		User  struct {
+			ID string `json:"id"`
+		} `json:"user"`
+	}
+	decode(t, rec, &res)
+	if res.Token == "" || res.User.ID == "" {
+		t.Fatalf("signup returned no token/user: %s", rec.Body.String())
+	}
+	return &client{t: t, r: r, token: res.Token}, email
+}
+
+// login authenticates an existing user; used after role changes since the
+// role is baked into the JWT.
+func login(t *testing.T, r *gin.Engine, email string) *client {
+	t.Helper()
+	anon := &client{t: t, r: r}
+	rec := anon.do(http.MethodPost, "/v1/auth/login", gin.H{"email": email, "password": "password123"})
+	expectStatus(t, rec, http.StatusOK)
+	var res struct {
+		Token string `json:"token"`
+	}
+	decode(t, rec, &res)
+	return &client{t: t, r: r, token: res.Token}
+}
+
+func setRole(t *testing.T, email, role string) {
+	t.Helper()
+	if _, err := db.Pool.Exec(context.Background(), "UPDATE users SET role=$1 WHERE email=$2", role, email); err != nil {
+		t.Fatalf("set role: %v", err)
+	}
+}
+
+// approveVendor approves vendorID as admin, first recording its required
+// verification documents as accepted.
+func approveVendor(t *testing.T, admin *client, vendorID string) {
+	t.Helper()
+	_, err := db.Pool.Exec(context.Background(), `
+		INSERT INTO vendor_documents (vendor_id, kind, object_key, filename, content_type, size_bytes, status)
+		SELECT $1, kind, 'private/test/' || kind || '.pdf', kind || '.pdf', 'application/pdf', 1, 'accepted'
+		FROM unnest($2::text[]) AS kind
+		ON CONFLICT (vendor_id, kind) DO UPDATE SET status = 'accepted'`, vendorID, moderation.RequiredDocuments)
+	if err != nil {
+		t.Fatalf("accept documents: %v", err)
+	}
+	expectStatus(t, admin.do(http.MethodPatch, "/v1/admin/vendors/"+vendorID+"/approve", nil), http.StatusOK)
+}
+
+// adminClient returns a freshly logged-in admin.
+func adminClient(t *testing.T, r *gin.Engine) *client {
+	t.Helper()
+	_, email := signup(t, r, "admin")
+	setRole(t, email, "admin")
+	return login(t, r, email)
+}
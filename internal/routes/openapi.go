@@ -0,0 +1,366 @@
+package routes
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/bventy/backend/internal/flags"
+	"github.com/bventy/backend/internal/handlers"
+	"github.com/bventy/backend/internal/middleware"
+	"github.com/bventy/backend/internal/openapi"
+	"github.com/bventy/backend/internal/trash"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	errorBody   = handlers.ErrorResponse
+	messageBody = handlers.MessageResponse
+	uploadBody  = handlers.UploadResponse
+)
+
+// Operations documents every route RegisterRoutes mounts under APIPrefix,
+// relative to it. TestSpecCoversRoutes fails when the two disagree, so add an
+// entry here with every new route.
+func Operations() []openapi.Operation {
+	withAuth := func(responses map[int]any) map[int]any {
+		responses[401] = errorBody{}
+		return responses
+	}
+	adminOnly := func(responses map[int]any) map[int]any {
+		responses[403] = errorBody{}
+		return withAuth(responses)
+	}
+	// idempotent documents middleware.Idempotent on op.
+	idempotent := func(op openapi.Operation) openapi.Operation {
+		op.Headers = append(op.Headers, openapi.Param{
+			Name:        middleware.IdempotencyKeyHeader,
+			Description: "Client-chosen key; retries with the same key replay the first response",
+		})
+		op.Responses[409] = errorBody{}
+		op.Responses[422] = errorBody{}
+		return op
+	}
+	// versioned documents the If-Match check on edits of a versioned row.
+	versioned := func(op openapi.Operation) openapi.Operation {
+		op.Headers = append(op.Headers, openapi.Param{
+			Name:        "If-Match",
+			Description: "ETag from the last GET; required except on deprecated routes",
+			Required:    true,
+		})
+		op.Responses[412] = errorBody{}
+		op.Responses[428] = errorBody{}
+		return op
+	}
+	// paged documents the keyset pagination parameters of a list.
+	paged := func(op openapi.Operation) openapi.Operation {
+		op.Query = append(op.Query,
+			openapi.Param{Name: "limit", Description: "Page size, 1-200, default 50"},
+			openapi.Param{Name: "cursor", Description: "next_cursor from the previous page"},
+		)
+		op.Responses[400] = errorBody{}
+		return op
+	}
+	// Images are stored as uploaded and converted to WebP in the background.
+	upload := func(summary, path, tag string) openapi.Operation {
+		return idempotent(openapi.Operation{
+			Method: http.MethodPost, Path: path, Summary: summary, Tag: tag, Auth: true,
+			Form:      []string{"file"},
+			Responses: withAuth(map[int]any{202: handlers.ImageUploadResponse{}, 400: errorBody{}, 403: errorBody{}, 404: errorBody{}, 500: errorBody{}}),
+		})
+	}
+
+	ops := []openapi.Operation{
+		// Docs
+		{Method: http.MethodGet, Path: "/openapi.json", Summary: "This document", Tag: "docs", Responses: map[int]any{200: nil}},
+		{Method: http.MethodGet, Path: "/docs", Summary: "Interactive API docs", Tag: "docs", Responses: map[int]any{200: nil}},
+
+		// Public
+		{Method: http.MethodGet, Path: "/health", Summary: "Service and database health", Tag: "health",
+			Responses: map[int]any{200: handlers.HealthResponse{}, 503: handlers.HealthResponse{}}},
+		paged(openapi.Operation{Method: http.MethodGet, Path: "/vendors", Summary: "Search verified vendors", Tag: "vendors",
+			Query: []openapi.Param{
+				{Name: "q", Description: "Full-text query over name, category and bio"},
+				{Name: "category", Description: "Category slug or name; a top-level category includes its subcategories"},
+				{Name: "city", Description: "City name or alias, case-insensitive"},
+				{Name: "min_price", Description: "Whole rupees; vendors whose price range, or a package's, reaches it"},
+				{Name: "max_price", Description: "Whole rupees; vendors whose price range, or a package's, starts at or below it"},
+				{Name: "unit", Description: "per_event, per_guest, per_hour or per_day; vendors with a package priced per that unit, in range when min_price or max_price is set"},
+				{Name: "available_on", Description: "YYYY-MM-DD; drops vendors blocked or fully booked that day"},
+				{Name: "near", Description: "latitude,longitude in degrees; keeps vendors within radius_km and adds distance_km"},
+				{Name: "radius_km", Description: "Search radius around near, up to 500 (default 25)"},
+				{Name: "sort", Description: "relevance (default; by name without q), newest, popularity or distance (needs near)"},
+			},
+			Responses: map[int]any{200: handlers.VendorSearchResponse{}, 304: nil, 400: errorBody{}, 500: errorBody{}}}),
+		{Method: http.MethodGet, Path: "/vendors/slug/:slug", Summary: "Get a verified vendor by slug", Tag: "vendors",
+			Responses: map[int]any{200: handlers.PublicVendorDetail{}, 304: nil, 404: errorBody{}}},
+
+		// Auth
+		{Method: http.MethodPost, Path: "/auth/signup", Summary: "Create an account", Tag: "auth",
+			Request:   handlers.SignupRequest{},
+			Responses: map[int]any{201: handlers.SignupResponse{}, 400: errorBody{}, 409: errorBody{}, 500: errorBody{}}},
+		{Method: http.MethodPost, Path: "/auth/login", Summary: "Exchange credentials for a JWT", Tag: "auth",
+			Request:   handlers.LoginRequest{},
+			Responses: map[int]any{200: handlers.LoginResponse{}, 400: errorBody{}, 401: errorBody{}, 500: errorBody{}}},
+
+		// User
+		{Method: http.MethodGet, Path: "/me", Summary: "Current user, vendor flag and groups", Tag: "users", Auth: true,
+			Responses: withAuth(map[int]any{200: handlers.MeResponse{}, 404: errorBody{}})},
+		versioned(openapi.Operation{Method: http.MethodPut, Path: "/me", Summary: "Replace the current user's profile", Tag: "users", Auth: true,
+			Request:   handlers.UpdateUserRequest{},
+			Responses: withAuth(map[int]any{200: handlers.UpdateMeResponse{}, 400: errorBody{}, 404: errorBody{}, 409: errorBody{}, 500: errorBody{}})}),
+		versioned(openapi.Operation{Method: http.MethodPatch, Path: "/me", Summary: "Change some of the current user's profile (JSON merge patch)", Tag: "users", Auth: true,
+			Request:   handlers.UserPatch{},
+			Responses: withAuth(map[int]any{200: handlers.UpdateMeResponse{}, 400: errorBody{}, 404: errorBody{}, 409: errorBody{}, 415: errorBody{}, 500: errorBody{}})}),
+		upload("Upload and set the profile image", "/users/profile-image", "users"),
+		idempotent(openapi.Operation{Method: http.MethodPost, Path: "/media/upload", Summary: "Upload an image or PDF", Tag: "media", Auth: true,
+			Form:      []string{"file"},
+			Responses: withAuth(map[int]any{200: handlers.MediaUploadResponse{}, 400: errorBody{}, 500: errorBody{}})}),
+
+		// Vendor self-service
+		idempotent(openapi.Operation{Method: http.MethodPost, Path: "/vendor/onboard", Summary: "Create the current user's vendor profile", Tag: "vendor", Auth: true,
+			Request:   handlers.OnboardVendorRequest{},
+			Responses: withAuth(map[int]any{201: handlers.OnboardVendorResponse{}, 400: errorBody{}, 409: errorBody{}, 500: errorBody{}})}),
+		{Method: http.MethodGet, Path: "/vendor/me", Summary: "Get the current user's vendor profile", Tag: "vendor", Auth: true,
+			Responses: withAuth(map[int]any{200: handlers.MyVendorProfile{}, 404: errorBody{}})},
+		versioned(openapi.Operation{Method: http.MethodPut, Path: "/vendor/me", Summary: "Update the current user's vendor profile", Tag: "vendor", Auth: true,
+			Request:   handlers.UpdateVendorRequest{},
+			Responses: withAuth(map[int]any{200: messageBody{}, 400: errorBody{}, 404: errorBody{}, 500: errorBody{}})}),
+		versioned(openapi.Operation{Method: http.MethodPatch, Path: "/vendor/me", Summary: "Change some of the current user's vendor profile (JSON merge patch)", Tag: "vendor", Auth: true,
+			Request:   handlers.VendorPatch{},
+			Responses: withAuth(map[int]any{200: handlers.MyVendorProfile{}, 400: errorBody{}, 404: errorBody{}, 415: errorBody{}, 500: errorBody{}})}),
+		{Method: http.MethodGet, Path: "/vendor/me/packages", Summary: "The current user's vendor packages", Tag: "vendor", Auth: true,
+			Responses: withAuth(map[int]any{200: []handlers.VendorPackage{}, 404: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodPost, Path: "/vendor/me/packages", Summary: "Add a priced package to the current user's vendor profile", Tag: "vendor", Auth: true,
+			Request:   handlers.CreatePackageRequest{},
+			Responses: withAuth(map[int]any{201: handlers.VendorPackage{}, 400: errorBody{}, 403: errorBody{}, 404: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodPatch, Path: "/vendor/me/packages/:id", Summary: "Change some of a package's fields (JSON merge patch)", Tag: "vendor", Auth: true,
+			Request:   handlers.PackagePatch{},
+			Responses: withAuth(map[int]any{200: handlers.VendorPackage{}, 400: errorBody{}, 404: errorBody{}, 415: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodDelete, Path: "/vendor/me/packages/:id", Summary: "Delete a package", Tag: "vendor", Auth: true,
+			Responses: withAuth(map[int]any{200: messageBody{}, 404: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodGet, Path: "/vendor/me/availability", Summary: "The current user's vendor availability over a date range", Tag: "vendor", Auth: true,
+			Query: []openapi.Param{
+				{Name: "from", Description: "YYYY-MM-DD; today by default"},
+				{Name: "to", Description: "YYYY-MM-DD, up to 366 days after from; 90 days after it by default"},
+			},
+			Responses: withAuth(map[int]any{200: handlers.VendorAvailability{}, 400: errorBody{}, 404: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodPut, Path: "/vendor/me/availability/:date", Summary: "Block a day or record its bookings and capacity", Tag: "vendor", Auth: true,
+			Request:   handlers.SetAvailabilityRequest{},
+			Responses: withAuth(map[int]any{200: handlers.AvailabilityDay{}, 400: errorBody{}, 404: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodDelete, Path: "/vendor/me/availability/:date", Summary: "Free a day again", Tag: "vendor", Auth: true,
+			Responses: withAuth(map[int]any{200: messageBody{}, 400: errorBody{}, 404: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodGet, Path: "/vendor/me/documents", Summary: "The current user's verification documents", Tag: "vendor", Auth: true,
+			Responses: withAuth(map[int]any{200: handlers.VendorDocuments{}, 404: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodPost, Path: "/vendor/me/documents", Summary: "Upload a verification document (PDF, JPEG or PNG), replacing any of the same kind", Tag: "vendor", Auth: true,
+			Form:      []string{"file", "kind"},
+			Responses: withAuth(map[int]any{201: handlers.VendorDocument{}, 400: errorBody{}, 404: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodDelete, Path: "/vendor/me/documents/:id", Summary: "Delete a document that has not been accepted", Tag: "vendor", Auth: true,
+			Responses: withAuth(map[int]any{200: messageBody{}, 404: errorBody{}, 409: errorBody{}, 500: errorBody{}})},
+		upload("Add a gallery image", "/vendors/:id/gallery", "vendor"),
+		{Method: http.MethodDelete, Path: "/vendors/:id/gallery/:imageID", Summary: "Delete a gallery image", Tag: "vendor", Auth: true,
+			Responses: withAuth(map[int]any{200: messageBody{}, 403: errorBody{}, 404: errorBody{}, 500: errorBody{}})},
+		idempotent(openapi.Operation{Method: http.MethodPost, Path: "/vendors/:id/portfolio", Summary: "Add a portfolio PDF", Tag: "vendor", Auth: true,
+			Form:      []string{"file", "title"},
+			Responses: withAuth(map[int]any{200: uploadBody{}, 400: errorBody{}, 403: errorBody{}, 404: errorBody{}, 500: errorBody{}})}),
+		{Method: http.MethodDelete, Path: "/vendors/:id/portfolio/:fileID", Summary: "Delete a portfolio file", Tag: "vendor", Auth: true,
+			Responses: withAuth(map[int]any{200: messageBody{}, 403: errorBody{}, 404: errorBody{}, 500: errorBody{}})},
+
+		// Groups
+		idempotent(openapi.Operation{Method: http.MethodPost, Path: "/groups", Summary: "Create a group owned by the current user", Tag: "groups", Auth: true,
+			Request:   handlers.CreateGroupRequest{},
+			Responses: withAuth(map[int]any{201: handlers.CreateGroupResponse{}, 400: errorBody{}, 409: errorBody{}, 500: errorBody{}})}),
+		paged(openapi.Operation{Method: http.MethodGet, Path: "/groups/my", Summary: "Groups the current user belongs to", Tag: "groups", Auth: true,
+			Responses: withAuth(map[int]any{200: handlers.GroupPage{}, 500: errorBody{}})}),
+
+		// Events
+		idempotent(openapi.Operation{Method: http.MethodPost, Path: "/events", Summary: "Create an event for the user or one of their groups", Tag: "events", Auth: true,
+			Request:   handlers.CreateEventRequest{},
+			Responses: withAuth(map[int]any{201: handlers.CreateEventResponse{}, 400: errorBody{}, 403: errorBody{}, 500: errorBody{}})}),
+		paged(openapi.Operation{Method: http.MethodGet, Path: "/events", Summary: "Events organised by the user or their groups", Tag: "events", Auth: true,
+			Responses: withAuth(map[int]any{200: handlers.EventPage{}, 500: errorBody{}})}),
+		{Method: http.MethodGet, Path: "/events/:id", Summary: "Get an event with its shortlist", Tag: "events", Auth: true,
+			Responses: withAuth(map[int]any{200: handlers.EventDetail{}, 404: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodPost, Path: "/events/:id/shortlist/:vendorID", Summary: "Shortlist a vendor for an event", Tag: "events", Auth: true,
+			Responses: withAuth(map[int]any{200: messageBody{}, 404: errorBody{}, 500: errorBody{}})},
+		paged(openapi.Operation{Method: http.MethodGet, Path: "/events/:id/shortlist", Summary: "List an event's shortlisted vendors", Tag: "events", Auth: true,
+			Responses: withAuth(map[int]any{200: handlers.ShortlistPage{}, 404: errorBody{}, 500: errorBody{}})}),
+
+		// Admin
+		{Method: http.MethodGet, Path: "/admin/metrics/overview", Summary: "Platform totals", Tag: "admin", Auth: true,
+			Responses: adminOnly(map[int]any{200: handlers.MetricsOverview{}})},
+		{Method: http.MethodGet, Path: "/admin/metrics/growth", Summary: "Daily signups and events for the last 30 days", Tag: "admin", Auth: true,
+			Responses: adminOnly(map[int]any{200: handlers.MetricsGrowth{}})},
+		{Method: http.MethodGet, Path: "/admin/metrics/events", Summary: "Event breakdowns", Tag: "admin", Auth: true,
+			Responses: adminOnly(map[int]any{200: handlers.MetricsEvents{}})},
+		{Method: http.MethodGet, Path: "/admin/metrics/vendors", Summary: "Vendor engagement", Tag: "admin", Auth: true,
+			Responses: adminOnly(map[int]any{200: handlers.MetricsVendors{}})},
+		paged(openapi.Operation{Method: http.MethodGet, Path: "/admin/vendors", Summary: "List vendors for moderation", Tag: "admin", Auth: true,
+			Query:     []openapi.Param{{Name: "status", Description: "pending, verified or rejected"}},
+			Responses: adminOnly(map[int]any{200: handlers.AdminVendorPage{}, 500: errorBody{}})}),
+		{Method: http.MethodPatch, Path: "/admin/vendors/:id/approve", Summary: "Approve a vendor once its required documents are accepted", Tag: "admin", Auth: true,
+			Responses: adminOnly(map[int]any{200: messageBody{}, 404: errorBody{}, 409: handlers.MissingDocumentsResponse{}})},
+		{Method: http.MethodPatch, Path: "/admin/vendors/:id/reject", Summary: "Reject a vendor", Tag: "admin", Auth: true,
+			Responses: adminOnly(map[int]any{200: messageBody{}, 404: errorBody{}})},
+		paged(openapi.Operation{Method: http.MethodGet, Path: "/admin/users", Summary: "List users", Tag: "admin", Auth: true,
+			Responses: adminOnly(map[int]any{200: handlers.AdminUserPage{}, 500: errorBody{}})}),
+		{Method: http.MethodPatch, Path: "/admin/users/:id/role", Summary: "Change a user's role (super_admin only)", Tag: "admin", Auth: true,
+			Request:   handlers.UpdateRoleRequest{},
+			Responses: adminOnly(map[int]any{200: messageBody{}, 400: errorBody{}, 404: errorBody{}})},
+
+		// Deletion
+		{Method: http.MethodDelete, Path: "/me", Summary: "Delete the current user's account, vendor profile, owned groups and events", Tag: "users", Auth: true,
+			Responses: withAuth(map[int]any{200: messageBody{}, 404: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodDelete, Path: "/vendor/me", Summary: "Delete the current user's vendor profile", Tag: "vendor", Auth: true,
+			Responses: withAuth(map[int]any{200: messageBody{}, 404: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodDelete, Path: "/groups/:id", Summary: "Delete a group and its events (owner only)", Tag: "groups", Auth: true,
+			Responses: withAuth(map[int]any{200: messageBody{}, 403: errorBody{}, 404: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodDelete, Path: "/events/:id", Summary: "Delete an event (organiser, group owner or manager)", Tag: "events", Auth: true,
+			Responses: withAuth(map[int]any{200: messageBody{}, 403: errorBody{}, 404: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodGet, Path: "/admin/trash", Summary: "Deleted rows awaiting purge, newest first", Tag: "admin", Auth: true,
+			Query: []openapi.Param{
+				{Name: "kind", Description: "users, vendors, groups or events"},
+				{Name: "limit", Description: "1-500, default 100"},
+			},
+			Responses: adminOnly(map[int]any{200: []trash.Item{}, 400: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodDelete, Path: "/admin/users/:id", Summary: "Delete a user and everything they own (super_admin only)", Tag: "admin", Auth: true,
+			Responses: adminOnly(map[int]any{200: messageBody{}, 400: errorBody{}, 404: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodPost, Path: "/admin/users/:id/restore", Summary: "Restore a deleted user and what was deleted with them (super_admin only)", Tag: "admin", Auth: true,
+			Responses: adminOnly(map[int]any{200: messageBody{}, 404: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodDelete, Path: "/admin/vendors/:id", Summary: "Delete a vendor profile", Tag: "admin", Auth: true,
+			Responses: adminOnly(map[int]any{200: messageBody{}, 404: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodPost, Path: "/admin/vendors/:id/restore", Summary: "Restore a deleted vendor profile", Tag: "admin", Auth: true,
+			Responses: adminOnly(map[int]any{200: messageBody{}, 404: errorBody{}, 409: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodDelete, Path: "/admin/groups/:id", Summary: "Delete a group and its events", Tag: "admin", Auth: true,
+			Responses: adminOnly(map[int]any{200: messageBody{}, 404: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodPost, Path: "/admin/groups/:id/restore", Summary: "Restore a deleted group and the events deleted with it", Tag: "admin", Auth: true,
+			Responses: adminOnly(map[int]any{200: messageBody{}, 404: errorBody{}, 409: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodDelete, Path: "/admin/events/:id", Summary: "Delete an event", Tag: "admin", Auth: true,
+			Responses: adminOnly(map[int]any{200: messageBody{}, 404: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodPost, Path: "/admin/events/:id/restore", Summary: "Restore a deleted event", Tag: "admin", Auth: true,
+			Responses: adminOnly(map[int]any{200: messageBody{}, 404: errorBody{}, 409: errorBody{}, 500: errorBody{}})},
+
+		// Webhooks
+		{Method: http.MethodPost, Path: "/webhooks", Summary: "Subscribe a URL to domain events", Tag: "webhooks", Auth: true,
+			Request:   handlers.CreateWebhookRequest{},
+			Responses: withAuth(map[int]any{201: handlers.CreateWebhookResponse{}, 400: errorBody{}, 403: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodGet, Path: "/webhooks", Summary: "The current user's webhook subscriptions", Tag: "webhooks", Auth: true,
+			Responses: withAuth(map[int]any{200: []handlers.WebhookSubscription{}, 500: errorBody{}})},
+		{Method: http.MethodGet, Path: "/webhooks/:id", Summary: "Get a webhook subscription", Tag: "webhooks", Auth: true,
+			Responses: withAuth(map[int]any{200: handlers.WebhookSubscription{}, 404: errorBody{}})},
+		{Method: http.MethodPatch, Path: "/webhooks/:id", Summary: "Change a subscription's URL, event types or active flag", Tag: "webhooks", Auth: true,
+			Request:   handlers.UpdateWebhookRequest{},
+			Responses: withAuth(map[int]any{200: handlers.WebhookSubscription{}, 400: errorBody{}, 404: errorBody{}})},
+		{Method: http.MethodDelete, Path: "/webhooks/:id", Summary: "Delete a subscription and its delivery log", Tag: "webhooks", Auth: true,
+			Responses: withAuth(map[int]any{200: messageBody{}, 404: errorBody{}})},
+		{Method: http.MethodGet, Path: "/webhooks/:id/deliveries", Summary: "The subscription's 50 most recent deliveries", Tag: "webhooks", Auth: true,
+			Query:     []openapi.Param{{Name: "status", Description: "pending, succeeded or failed"}},
+			Responses: withAuth(map[int]any{200: []handlers.WebhookDelivery{}, 404: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodPost, Path: "/webhooks/:id/deliveries/:deliveryID/replay", Summary: "Send a past delivery again", Tag: "webhooks", Auth: true,
+			Responses: withAuth(map[int]any{202: handlers.WebhookDelivery{}, 404: errorBody{}, 500: errorBody{}})},
+
+		// Feature flags
+		{Method: http.MethodGet, Path: "/flags", Summary: "Every feature flag evaluated for the current user", Tag: "flags", Auth: true,
+			Responses: withAuth(map[int]any{200: map[string]bool{}})},
+		{Method: http.MethodGet, Path: "/admin/flags", Summary: "List feature flags", Tag: "admin", Auth: true,
+			Responses: adminOnly(map[int]any{200: []flags.Flag{}, 500: errorBody{}})},
+		{Method: http.MethodPut, Path: "/admin/flags/:key", Summary: "Create or replace a feature flag", Tag: "admin", Auth: true,
+			Request:   handlers.PutFlagRequest{},
+			Responses: adminOnly(map[int]any{200: flags.Flag{}, 400: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodPatch, Path: "/admin/flags/:key", Summary: "Change some of a feature flag's fields", Tag: "admin", Auth: true,
+			Request:   handlers.PatchFlagRequest{},
+			Responses: adminOnly(map[int]any{200: flags.Flag{}, 400: errorBody{}, 404: errorBody{}})},
+		{Method: http.MethodDelete, Path: "/admin/flags/:key", Summary: "Delete a feature flag", Tag: "admin", Auth: true,
+			Responses: adminOnly(map[int]any{200: messageBody{}, 404: errorBody{}})},
+
+		// Categories
+		{Method: http.MethodGet, Path: "/categories", Summary: "The vendor category tree", Tag: "vendors",
+			Responses: map[int]any{200: []handlers.Category{}, 500: errorBody{}}},
+		{Method: http.MethodPost, Path: "/admin/categories", Summary: "Create a vendor category", Tag: "admin", Auth: true,
+			Request:   handlers.CreateCategoryRequest{},
+			Responses: adminOnly(map[int]any{201: handlers.Category{}, 400: errorBody{}, 409: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodPatch, Path: "/admin/categories/:id", Summary: "Change some of a category's fields (JSON merge patch)", Tag: "admin", Auth: true,
+			Request:   handlers.CategoryPatch{},
+			Responses: adminOnly(map[int]any{200: handlers.Category{}, 400: errorBody{}, 404: errorBody{}, 409: errorBody{}, 415: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodDelete, Path: "/admin/categories/:id", Summary: "Delete a category no vendor or subcategory uses", Tag: "admin", Auth: true,
+			Responses: adminOnly(map[int]any{200: messageBody{}, 404: errorBody{}, 409: errorBody{}})},
+
+		// Reviews
+		paged(openapi.Operation{Method: http.MethodGet, Path: "/vendors/slug/:slug/reviews", Summary: "A verified vendor's reviews, newest first", Tag: "reviews",
+			Responses: map[int]any{200: handlers.ReviewPage{}, 400: errorBody{}, 404: errorBody{}, 500: errorBody{}}}),
+		{Method: http.MethodPost, Path: "/vendors/:id/reviews", Summary: "Review a vendor shortlisted on one of your past events", Tag: "reviews", Auth: true,
+			Request:   handlers.CreateReviewRequest{},
+			Responses: withAuth(map[int]any{201: handlers.Review{}, 400: errorBody{}, 403: errorBody{}, 404: errorBody{}, 409: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodPatch, Path: "/reviews/:id", Summary: "Edit your review, once (JSON merge patch)", Tag: "reviews", Auth: true,
+			Request:   handlers.ReviewPatch{},
+			Responses: withAuth(map[int]any{200: handlers.Review{}, 400: errorBody{}, 404: errorBody{}, 409: errorBody{}, 415: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodPut, Path: "/reviews/:id/reply", Summary: "Reply publicly to a review of your vendor profile", Tag: "reviews", Auth: true,
+			Request:   handlers.ReviewReplyRequest{},
+			Responses: withAuth(map[int]any{200: handlers.Review{}, 400: errorBody{}, 404: errorBody{}, 500: errorBody{}})},
+		paged(openapi.Operation{Method: http.MethodGet, Path: "/admin/reviews", Summary: "List reviews for moderation", Tag: "admin", Auth: true,
+			Query: []openapi.Param{
+				{Name: "flagged", Description: "true or false"},
+				{Name: "hidden", Description: "true or false"},
+				{Name: "vendor_id", Description: "Only this vendor's reviews"},
+			},
+			Responses: adminOnly(map[int]any{200: handlers.AdminReviewPage{}, 400: errorBody{}, 500: errorBody{}})}),
+		{Method: http.MethodPatch, Path: "/admin/reviews/:id", Summary: "Hide, flag or annotate a review", Tag: "admin", Auth: true,
+			Request:   handlers.ModerateReviewRequest{},
+			Responses: adminOnly(map[int]any{200: handlers.AdminReview{}, 400: errorBody{}, 404: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodGet, Path: "/admin/vendors/:id/documents", Summary: "A vendor's verification documents, with short-lived signed links", Tag: "admin", Auth: true,
+			Responses: adminOnly(map[int]any{200: handlers.AdminVendorDocuments{}, 404: errorBody{}, 500: errorBody{}})},
+		{Method: http.MethodPatch, Path: "/admin/vendors/:id/documents/:documentID", Summary: "Accept or reject a verification document", Tag: "admin", Auth: true,
+			Request:   handlers.ReviewDocumentRequest{},
+			Responses: adminOnly(map[int]any{200: handlers.VendorDocument{}, 400: errorBody{}, 404: errorBody{}, 500: errorBody{}})},
+
+		// Locations
+		{Method: http.MethodGet, Path: "/locations", Summary: "The reference city list", Tag: "vendors",
+			Query:     []openapi.Param{{Name: "q", Description: "Prefix of a city name or alias"}},
+			Responses: map[int]any{200: []handlers.Location{}, 500: errorBody{}}},
+	}
+
+	// Anything that touches Postgres or R2 can run out of time (504) or be
+	// cancelled (503).
+	for _, op := range ops {
+		if op.Tag != "docs" {
+			op.Responses[503] = errorBody{}
+			op.Responses[504] = errorBody{}
+		}
+	}
+	return ops
+}
+
+var (
+	specOnce sync.Once
+	spec     openapi.Document
+)
+
+// Spec returns the OpenAPI document for Operations, built on first use.
+func Spec() openapi.Document {
+	specOnce.Do(func() {
+		spec = openapi.Build("bventy API", "1.0.0", Operations())
+		spec["servers"] = []map[string]string{{"url": APIPrefix}}
+	})
+	return spec
+}
+
+func serveSpec(c *gin.Context) {
+	c.JSON(http.StatusOK, Spec())
+}
+
+const docsPage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>bventy API</title>
+  <meta charset="utf-8">
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>SwaggerUIBundle({ url: "openapi.json", dom_id: "#swagger-ui" });</script>
+</body>
+</html>`
+
+func serveDocs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+}
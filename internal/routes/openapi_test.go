@@ -0,0 +1,115 @@
+package routes_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bventy/backend/internal/config"
+	"github.com/bventy/backend/internal/routes"
+	"github.com/gin-gonic/gin"
+)
+
+// legacyOnly are root routes kept for old clients that have no /v1 twin.
+var legacyOnly = map[string]bool{
+	"GET /admin/stats":                         true,
+	"POST /superadmin/users/:id/promote-admin": true,
+}
+
+// TestSpecCoversRoutes needs no database: registering routes does not touch it.
+func TestSpecCoversRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	routes.RegisterRoutes(r, &config.Config{}, testMedia)
+
+	spec := routes.Spec()
+	registered := map[string]bool{}
+	for _, route := range r.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+	for _, route := range r.Routes() {
+		key := route.Method + " " + route.Path
+		path, versioned := strings.CutPrefix(route.Path, routes.APIPrefix+"/")
+		switch {
+		case versioned:
+			if !spec.Has(route.Method, "/"+path) {
+				t.Errorf("%s is registered but missing from the OpenAPI spec", key)
+			}
+		case route.Path == "/health" || legacyOnly[key]:
+		case !registered[route.Method+" "+routes.APIPrefix+route.Path]:
+			t.Errorf("%s is neither under %s nor an alias of a %s route", key, routes.APIPrefix, routes.APIPrefix)
+		}
+	}
+	for _, op := range routes.Operations() {
+		if !registered[op.Method+" "+routes.APIPrefix+op.Path] {
+			t.Errorf("%s %s is in the OpenAPI spec but not registered", op.Method, op.Path)
+		}
+	}
+}
+
+func TestLegacyAliasesAreDeprecated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	sunset := time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+	routes.RegisterRoutes(r, &config.Config{LegacyRoutesSunset: sunset}, testMedia)
+	c := &client{t: t, r: r}
+
+	// Both fail auth before reaching the database, which is enough to see the headers.
+	cases := []struct{ path, successor string }{
+		{"/me", "</v1/me>; rel=\"successor-version\""},
+		{"/admin/stats", "</v1/admin/metrics/overview>; rel=\"successor-version\""},
+	}
+	for _, tc := range cases {
+		rec := c.do(http.MethodGet, tc.path, nil)
+		expectStatus(t, rec, http.StatusUnauthorized)
+		if got := rec.Header().Get("Deprecation"); !strings.HasPrefix(got, "@") {
+			t.Errorf("%s: Deprecation = %q", tc.path, got)
+		}
+		if got := rec.Header().Get("Sunset"); got != "Fri, 30 Apr 2027 00:00:00 GMT" {
+			t.Errorf("%s: Sunset = %q", tc.path, got)
+		}
+		if got := rec.Header().Get("Link"); got != tc.successor {
+			t.Errorf("%s: Link = %q, want %q", tc.path, got, tc.successor)
+		}
+	}
+
+	rec := c.do(http.MethodGet, "/v1/me", nil)
+	expectStatus(t, rec, http.StatusUnauthorized)
+	if got := rec.Header().Get("Deprecation"); got != "" {
+		t.Errorf("/v1/me is deprecated: %q", got)
+	}
+}
+
+func TestServeSpec(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	routes.RegisterRoutes(r, &config.Config{}, testMedia)
+	c := &client{t: t, r: r}
+
+	rec := c.do(http.MethodGet, "/v1/openapi.json", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var doc struct {
+		OpenAPI    string                    `json:"openapi"`
+		Paths      map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	decode(t, rec, &doc)
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("openapi = %q", doc.OpenAPI)
+	}
+	if _, ok := doc.Paths["/vendors/slug/{slug}"]["get"]; !ok {
+		t.Errorf("path parameters not converted: %v", doc.Paths)
+	}
+	for _, name := range []string{"EventSummary", "MeResponse", "ErrorResponse"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("schema %s missing", name)
+		}
+	}
+
+	rec = c.do(http.MethodGet, "/v1/docs", nil)
+	expectStatus(t, rec, http.StatusOK)
+}
@@ -0,0 +1,110 @@
+package routes_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestVendorPackages(t *testing.T) {
+	r := newServer(t)
+	admin := adminClient(t, r)
+	public := &client{t: t, r: r}
+	owner, _ := signup(t, r, "packages")
+	other, _ := signup(t, r, "packages-other")
+
+	name := uniqueName("Thali House")
+	vendorID, slug := onboardVendor(t, owner, name)
+	approveVendor(t, admin, vendorID)
+
+	type pkg struct {
+		ID         string   `json:"id"`
+		Name       string   `json:"name"`
+		Inclusions []string `json:"inclusions"`
+		PriceMin   int      `json:"price_min"`
+		PriceMax   *int     `json:"price_max"`
+		Unit       string   `json:"unit"`
+		Currency   string   `json:"currency"`
+	}
+	create := func(body gin.H) pkg {
+		t.Helper()
+		rec := owner.do(http.MethodPost, "/v1/vendor/me/packages", body)
+		expectStatus(t, rec, http.StatusCreated)
+		var p pkg
+		decode(t, rec, &p)
+		return p
+	}
+	patchPackage := func(c *client, id string, body gin.H) *httptest.ResponseRecorder {
+		return c.doWith(http.MethodPatch, "/v1/vendor/me/packages/"+id, body,
+			map[string]string{"Content-Type": "application/merge-patch+json"})
+	}
+
+	wedding := create(gin.H{"name": "Wedding buffet", "inclusions": []string{" Live counters ", "Service staff"},
+		"price_min": 40000, "price_max": 60000})
+	if wedding.Unit != "per_event" || wedding.Currency != "INR" || wedding.Inclusions[0] != "Live counters" {
+		t.Fatalf("defaults not applied: %+v", wedding)
+	}
+	plate := create(gin.H{"name": "Per plate", "price_min": 1200, "unit": "per_guest", "sort_order": -1})
+
+	expectStatus(t, owner.do(http.MethodPost, "/v1/vendor/me/packages", gin.H{"name": "Bad", "price_min": 10, "price_max": 5}), http.StatusBadRequest)
+	expectStatus(t, owner.do(http.MethodPost, "/v1/vendor/me/packages", gin.H{"name": "Bad", "price_min": 10, "unit": "per_year"}), http.StatusBadRequest)
+	expectStatus(t, owner.do(http.MethodPost, "/v1/vendor/me/packages", gin.H{"name": "Bad"}), http.StatusBadRequest)
+	expectStatus(t, other.do(http.MethodPost, "/v1/vendor/me/packages", gin.H{"name": "Mine", "price_min": 10}), http.StatusNotFound)
+
+	var page struct {
+		StartingPrice *int  `json:"starting_price"`
+		Packages      []pkg `json:"packages"`
+	}
+	rec := public.do(http.MethodGet, "/v1/vendors/slug/"+slug, nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &page)
+	if len(page.Packages) != 2 || page.Packages[0].ID != plate.ID || page.StartingPrice == nil || *page.StartingPrice != 1200 {
+		t.Fatalf("vendor page: %+v", page)
+	}
+
+	found := func(q url.Values) bool {
+		t.Helper()
+		q.Set("q", name)
+		rec := public.do(http.MethodGet, "/v1/vendors?"+q.Encode(), nil)
+		expectStatus(t, rec, http.StatusOK)
+		var res struct {
+			Vendors []idOnly `json:"vendors"`
+		}
+		decode(t, rec, &res)
+		return containsID(res.Vendors, vendorID)
+	}
+	if !found(url.Values{"min_price": {"55000"}}) || !found(url.Values{"unit": {"per_guest"}, "max_price": {"1500"}}) {
+		t.Fatal("package prices not searchable")
+	}
+	if found(url.Values{"unit": {"per_hour"}}) || found(url.Values{"unit": {"per_guest"}, "min_price": {"2000"}}) {
+		t.Fatal("found with no matching package")
+	}
+	expectStatus(t, public.do(http.MethodGet, "/v1/vendors?unit=per_year", nil), http.StatusBadRequest)
+
+	// Clearing price_max fixes the price, which must not drop below price_min.
+	rec = patchPackage(owner, wedding.ID, gin.H{"price_max": nil, "name": "Wedding feast"})
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &wedding)
+	if wedding.PriceMax != nil || wedding.Name != "Wedding feast" {
+		t.Fatalf("after patch: %+v", wedding)
+	}
+	expectStatus(t, patchPackage(owner, wedding.ID, gin.H{"price_max": 1}), http.StatusBadRequest)
+	expectStatus(t, patchPackage(owner, wedding.ID, gin.H{"currency": "rupees"}), http.StatusBadRequest)
+	expectStatus(t, patchPackage(other, wedding.ID, gin.H{"name": "Stolen"}), http.StatusNotFound)
+	if found(url.Values{"min_price": {"55000"}}) {
+		t.Fatal("still found above the fixed price")
+	}
+
+	expectStatus(t, owner.do(http.MethodDelete, "/v1/vendor/me/packages/"+plate.ID, nil), http.StatusOK)
+	expectStatus(t, owner.do(http.MethodDelete, "/v1/vendor/me/packages/"+plate.ID, nil), http.StatusNotFound)
+	rec = owner.do(http.MethodGet, "/v1/vendor/me/packages", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var mine []pkg
+	decode(t, rec, &mine)
+	if len(mine) != 1 || mine[0].ID != wedding.ID {
+		t.Fatalf("packages after delete: %+v", mine)
+	}
+}
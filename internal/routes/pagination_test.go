@@ -0,0 +1,67 @@
+package routes_test
+
+import (
+	"net/http"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestKeysetPagination(t *testing.T) {
+	r := newServer(t)
+	owner, _ := signup(t, r, "pager")
+
+	var names []string
+	for range 5 {
+		name := uniqueName("Club")
+		names = append(names, name)
+		expectStatus(t, owner.do(http.MethodPost, "/v1/groups", gin.H{"name": name, "city": "Pune"}), http.StatusCreated)
+	}
+	sort.Strings(names)
+
+	var seen []string
+	path := "/v1/groups/my?limit=2"
+	for pages := 0; ; pages++ {
+		if pages == 3 {
+			t.Fatal("more pages than expected")
+		}
+		rec := owner.do(http.MethodGet, path, nil)
+		expectStatus(t, rec, http.StatusOK)
+		var page struct {
+			Groups []struct {
+				Name string `json:"name"`
+			} `json:"groups"`
+			NextCursor *string `json:"next_cursor"`
+		}
+		decode(t, rec, &page)
+		for _, g := range page.Groups {
+			seen = append(seen, g.Name)
+		}
+		if page.NextCursor == nil {
+			break
+		}
+		path = "/v1/groups/my?limit=2&cursor=" + *page.NextCursor
+	}
+	if len(seen) != len(names) {
+		t.Fatalf("paged through %v, want %v", seen, names)
+	}
+	for i := range names {
+		if seen[i] != names[i] {
+			t.Fatalf("paged through %v, want %v", seen, names)
+		}
+	}
+
+	for _, bad := range []string{"limit=0", "limit=201", "cursor=garbage", "cursor=WyJ4Il0"} {
+		expectStatus(t, owner.do(http.MethodGet, "/v1/groups/my?"+bad, nil), http.StatusBadRequest)
+	}
+
+	// Legacy routes still get every row as a bare array.
+	rec := owner.do(http.MethodGet, "/groups/my?limit=2", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var all []idOnly
+	decode(t, rec, &all)
+	if len(all) != len(names) {
+		t.Fatalf("legacy list has %d groups, want %d", len(all), len(names))
+	}
+}
@@ -0,0 +1,107 @@
+package routes_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// patch sends a merge patch with the ETag from a fresh GET of path.
+func patch(t *testing.T, c *client, path string, body gin.H) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := c.do(http.MethodGet, path, nil)
+	expectStatus(t, rec, http.StatusOK)
+	return c.doWith(http.MethodPatch, path, body, map[string]string{
+		"Content-Type": "application/merge-patch+json",
+		"If-Match":     rec.Header().Get("ETag"),
+	})
+}
+
+func TestPatchVendorKeepsOmittedFields(t *testing.T) {
+	r := newServer(t)
+	owner, _ := signup(t, r, "patcher")
+	onboardVendor(t, owner, uniqueName("Patch Florist"))
+
+	gallery := []string{"https://media.test/a.webp", "https://media.test/b.webp"}
+	rec := patch(t, owner, "/v1/vendor/me", gin.H{
+		"gallery_images":      gallery,
+		"portfolio_image_url": "https://media.test/cover.webp",
+		"portfolio_files":     []gin.H{{"name": "Menu", "url": "https://media.test/menu.pdf"}},
+	})
+	expectStatus(t, rec, http.StatusOK)
+
+	// Changing the bio alone leaves the media alone...
+	rec = patch(t, owner, "/v1/vendor/me", gin.H{"bio": "Fresh flowers daily"})
+	expectStatus(t, rec, http.StatusOK)
+	var got struct {
+		Bio               string   `json:"bio"`
+		City              string   `json:"city"`
+		PortfolioImageURL *string  `json:"portfolio_image_url"`
+		GalleryImages     []string `json:"gallery_images"`
+		PortfolioFiles    []gin.H  `json:"portfolio_files"`
+	}
+	decode(t, rec, &got)
+	if got.Bio != "Fresh flowers daily" || got.City != "Pune" || len(got.GalleryImages) != 2 ||
+		len(got.PortfolioFiles) != 1 || got.PortfolioImageURL == nil {
+		t.Fatalf("bio patch changed other fields: %+v", got)
+	}
+
+	// ...and null clears.
+	rec = patch(t, owner, "/v1/vendor/me", gin.H{"portfolio_image_url": nil, "gallery_images": nil})
+	expectStatus(t, rec, http.StatusOK)
+	got.PortfolioImageURL, got.GalleryImages = nil, nil
+	decode(t, rec, &got)
+	if got.PortfolioImageURL != nil || len(got.GalleryImages) != 0 || len(got.PortfolioFiles) != 1 {
+		t.Fatalf("null did not clear: %+v", got)
+	}
+
+	for _, bad := range []gin.H{
+		{"business_name": ""},
+		{"business_name": nil},
+		{"gallery_images": []string{"not a url"}},
+		{"portfolio_files": []gin.H{{"url": "https://media.test/x.pdf"}}},
+		{"status": "verified"},
+		{"price_min": -1},
+		{"price_min": 5000, "price_max": 1000},
+	} {
+		expectStatus(t, patch(t, owner, "/v1/vendor/me", bad), http.StatusBadRequest)
+	}
+}
+
+func TestPatchMe(t *testing.T) {
+	r := newServer(t)
+	me, _ := signup(t, r, "patchme")
+	other, _ := signup(t, r, "patchother")
+
+	username := "patch_" + uniqueName("u")[2:]
+	rec := patch(t, me, "/v1/me", gin.H{"username": username, "city": "Goa"})
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = patch(t, me, "/v1/me", gin.H{"full_name": "Patched Name"})
+	expectStatus(t, rec, http.StatusOK)
+	var res struct {
+		FullName string  `json:"full_name"`
+		Username *string `json:"username"`
+	}
+	decode(t, rec, &res)
+	if res.FullName != "Patched Name" || res.Username == nil || *res.Username != username {
+		t.Fatalf("omitted username changed: %+v", res)
+	}
+
+	expectStatus(t, patch(t, other, "/v1/me", gin.H{"username": username}), http.StatusConflict)
+	expectStatus(t, patch(t, me, "/v1/me", gin.H{"username": "no spaces allowed"}), http.StatusBadRequest)
+	expectStatus(t, patch(t, me, "/v1/me", gin.H{"full_name": nil}), http.StatusBadRequest)
+
+	rec = patch(t, me, "/v1/me", gin.H{"username": nil})
+	expectStatus(t, rec, http.StatusOK)
+	res.Username = nil
+	decode(t, rec, &res)
+	if res.Username != nil {
+		t.Fatalf("username not cleared: %v", *res.Username)
+	}
+
+	rec = me.doWith(http.MethodPatch, "/v1/me", gin.H{"city": "Pune"}, map[string]string{"Content-Type": "text/plain", "If-Match": "*"})
+	expectStatus(t, rec, http.StatusUnsupportedMediaType)
+}
@@ -0,0 +1,207 @@
+package routes_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bventy/backend/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestVendorReviews(t *testing.T) {
+	r := newServer(t)
+	admin := adminClient(t, r)
+	public := &client{t: t, r: r}
+	owner, _ := signup(t, r, "reviewed")
+	organizer, _ := signup(t, r, "reviewer")
+	stranger, _ := signup(t, r, "stranger")
+
+	vendorID, slug := onboardVendor(t, owner, uniqueName("Rated Rasoi"))
+	approveVendor(t, admin, vendorID)
+
+	newEvent := func(date string, shortlist bool) string {
+		t.Helper()
+		rec := organizer.do(http.MethodPost, "/v1/events", gin.H{"title": "Anniversary", "city": "Pune", "event_date": date})
+		expectStatus(t, rec, http.StatusCreated)
+		var created struct {
+			EventID string `json:"event_id"`
+		}
+		decode(t, rec, &created)
+		if shortlist {
+			expectStatus(t, organizer.do(http.MethodPost, "/v1/events/"+created.EventID+"/shortlist/"+vendorID, nil), http.StatusOK)
+		}
+		return created.EventID
+	}
+	past, upcoming, unrelated := newEvent("2025-01-10", true), newEvent("2099-01-10", true), newEvent("2025-01-11", false)
+
+	type review struct {
+		ID       string  `json:"id"`
+		Rating   int     `json:"rating"`
+		Body     string  `json:"body"`
+		EditedAt *string `json:"edited_at"`
+		Reply    *string `json:"reply"`
+	}
+	reviewPath := "/v1/vendors/" + vendorID + "/reviews"
+	expectStatus(t, organizer.do(http.MethodPost, reviewPath, gin.H{"event_id": upcoming, "rating": 5}), http.StatusForbidden)
+	expectStatus(t, organizer.do(http.MethodPost, reviewPath, gin.H{"event_id": unrelated, "rating": 5}), http.StatusForbidden)
+	expectStatus(t, stranger.do(http.MethodPost, reviewPath, gin.H{"event_id": past, "rating": 5}), http.StatusForbidden)
+	expectStatus(t, organizer.do(http.MethodPost, reviewPath, gin.H{"event_id": past, "rating": 6}), http.StatusBadRequest)
+
+	rec := organizer.do(http.MethodPost, reviewPath, gin.H{"event_id": past, "rating": 3, "body": "Good food, slow service"})
+	expectStatus(t, rec, http.StatusCreated)
+	var mine review
+	decode(t, rec, &mine)
+	expectStatus(t, organizer.do(http.MethodPost, reviewPath, gin.H{"event_id": past, "rating": 4}), http.StatusConflict)
+
+	// One edit, by the author only.
+	edit := func(c *client, body gin.H) *httptest.ResponseRecorder {
+		return c.doWith(http.MethodPatch, "/v1/reviews/"+mine.ID, body, map[string]string{"Content-Type": "application/merge-patch+json"})
+	}
+	expectStatus(t, edit(stranger, gin.H{"rating": 1}), http.StatusNotFound)
+	rec = edit(organizer, gin.H{"rating": 4})
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &mine)
+	if mine.Rating != 4 || mine.EditedAt == nil || mine.Body != "Good food, slow service" {
+		t.Fatalf("after edit: %+v", mine)
+	}
+	expectStatus(t, edit(organizer, gin.H{"rating": 5}), http.StatusConflict)
+
+	// The vendor's owner replies.
+	replyPath := "/v1/reviews/" + mine.ID + "/reply"
+	expectStatus(t, stranger.do(http.MethodPut, replyPath, gin.H{"reply": "Thanks"}), http.StatusNotFound)
+	rec = owner.do(http.MethodPut, replyPath, gin.H{"reply": "Thank you, we have added staff."})
+	expectStatus(t, rec, http.StatusOK)
+
+	var page struct {
+		Rating      *float64 `json:"rating"`
+		ReviewCount int      `json:"review_count"`
+	}
+	rec = public.do(http.MethodGet, "/v1/vendors/slug/"+slug, nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &page)
+	if page.Rating == nil || *page.Rating != 4 || page.ReviewCount != 1 {
+		t.Fatalf("rating on the vendor page: %+v", page)
+	}
+	var listed struct {
+		Reviews []review `json:"reviews"`
+	}
+	rec = public.do(http.MethodGet, "/v1/vendors/slug/"+slug+"/reviews", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &listed)
+	if len(listed.Reviews) != 1 || listed.Reviews[0].Reply == nil {
+		t.Fatalf("public reviews: %+v", listed)
+	}
+
+	// Hidden reviews leave the listing and the average.
+	moderate := "/v1/admin/reviews/" + mine.ID
+	expectStatus(t, owner.do(http.MethodPatch, moderate, gin.H{"hidden": true}), http.StatusForbidden)
+	rec = admin.do(http.MethodPatch, moderate, gin.H{"flagged": true, "moderation_note": "Reported by vendor"})
+	expectStatus(t, rec, http.StatusOK)
+	rec = admin.do(http.MethodGet, "/v1/admin/reviews?flagged=true&vendor_id="+vendorID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &listed)
+	if len(listed.Reviews) != 1 || listed.Reviews[0].ID != mine.ID {
+		t.Fatalf("flagged reviews: %+v", listed)
+	}
+	expectStatus(t, admin.do(http.MethodPatch, moderate, gin.H{"hidden": true}), http.StatusOK)
+	rec = public.do(http.MethodGet, "/v1/vendors/slug/"+slug, nil)
+	decode(t, rec, &page)
+	if page.Rating != nil || page.ReviewCount != 0 {
+		t.Fatalf("hidden review still counted: %+v", page)
+	}
+	rec = public.do(http.MethodGet, "/v1/vendors/slug/"+slug+"/reviews", nil)
+	decode(t, rec, &listed)
+	if len(listed.Reviews) != 0 {
+		t.Fatalf("hidden review still listed: %+v", listed)
+	}
+}
+
+// useSessionTimeZone points db.Pool at the test database through connections
+// whose session time zone is tz, until t ends.
+func useSessionTimeZone(t *testing.T, tz string) {
+	t.Helper()
+	cfg := db.Pool.Config()
+	cfg.ConnConfig.RuntimeParams["timezone"] = tz
+	pool, err := pgxpool.NewWithConfig(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	previous := db.Pool
+	db.Pool = pool
+	t.Cleanup(func() {
+		db.Pool = previous
+		pool.Close()
+	})
+}
+
+func TestReviewPagesAcrossTimeZones(t *testing.T) {
+	r := newServer(t)
+	admin := adminClient(t, r)
+	owner, _ := signup(t, r, "paged-reviews")
+	organizer, _ := signup(t, r, "paged-reviewer")
+	vendorID, slug := onboardVendor(t, owner, uniqueName("Paged Pantry"))
+	approveVendor(t, admin, vendorID)
+
+	var author idOnly
+	decode(t, organizer.do(http.MethodGet, "/v1/me", nil), &author)
+	var want []string
+	for i := range 5 {
+		rec := organizer.do(http.MethodPost, "/v1/events", gin.H{"title": "Dinner", "city": "Pune", "event_date": "2025-02-10"})
+		expectStatus(t, rec, http.StatusCreated)
+		var event struct {
+			EventID string `json:"event_id"`
+		}
+		decode(t, rec, &event)
+		var id string
+		err := db.Pool.QueryRow(context.Background(), `
+			INSERT INTO vendor_reviews (vendor_id, event_id, author_user_id, rating, created_at)
+			VALUES ($1, $2, $3, 4, now() - make_interval(hours => $4)) RETURNING id`,
+			vendorID, event.EventID, author.ID, i).Scan(&id)
+		if err != nil {
+			t.Fatalf("insert review: %v", err)
+		}
+		want = append(want, id)
+	}
+
+	// Kiritimati is UTC+14, far from wherever the test runs, so a cursor
+	// read in the session time zone would land hours off.
+	if _, offset := time.Now().Zone(); offset == 14*3600 {
+		t.Skip("local time zone matches the session's")
+	}
+	useSessionTimeZone(t, "Pacific/Kiritimati")
+
+	public := &client{t: t, r: r}
+	var seen []string
+	path := "/v1/vendors/slug/" + slug + "/reviews?limit=2"
+	for pages := 0; ; pages++ {
+		if pages == 3 {
+			t.Fatalf("more pages than expected: %v", seen)
+		}
+		rec := public.do(http.MethodGet, path, nil)
+		expectStatus(t, rec, http.StatusOK)
+		var page struct {
+			Reviews    []idOnly `json:"reviews"`
+			NextCursor *string  `json:"next_cursor"`
+		}
+		decode(t, rec, &page)
+		for _, review := range page.Reviews {
+			seen = append(seen, review.ID)
+		}
+		if page.NextCursor == nil {
+			break
+		}
+		path = "/v1/vendors/slug/" + slug + "/reviews?limit=2&cursor=" + *page.NextCursor
+	}
+	if len(seen) != len(want) {
+		t.Fatalf("paged through %v, want %v", seen, want)
+	}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Fatalf("paged through %v, want %v", seen, want)
+		}
+	}
+}
@@ -1,113 +1,264 @@
 package routes
 
 import (
+	"strings"
+	"time"
+
 	"github.com/bventy/backend/internal/config"
+	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/flags"
 	"github.com/bventy/backend/internal/handlers"
+	"github.com/bventy/backend/internal/httpcache"
 	"github.com/bventy/backend/internal/middleware"
+	"github.com/bventy/backend/internal/services"
+	"github.com/bventy/backend/internal/trash"
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoutes(r *gin.Engine) {
+// APIPrefix is where the current API version is mounted.
+const APIPrefix = "/v1"
+
+// legacyDeprecatedAt is when the unversioned routes were superseded by /v1.
+var legacyDeprecatedAt = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+
+// legacySuccessors maps the leftover routes that have no /v1 twin to their
+// replacements.
+var legacySuccessors = map[string]string{
+	"/admin/stats":                        APIPrefix + "/admin/metrics/overview",
+	"/superadmin/users/:id/promote-admin": APIPrefix + "/admin/users/:id/role",
+}
+
+func RegisterRoutes(r *gin.Engine, cfg *config.Config, media services.MediaStore) {
 
-	cfg := config.LoadConfig()
+	flagStore := flags.NewStore(flags.FromPool(db.Pool), cfg.FlagsCacheTTL)
+	vendorCache := httpcache.New(cfg.VendorCacheTTL)
 
 	// Handlers
-	authHandler := handlers.NewAuthHandler(cfg)
-	vendorHandler := handlers.NewVendorHandler(cfg)
-	adminHandler := handlers.NewAdminHandler()
-	userHandler := handlers.NewUserHandler(cfg)
-	groupHandler := handlers.NewGroupHandler()
-	eventHandler := handlers.NewEventHandler()
-	mediaHandler := handlers.NewMediaHandler(cfg)
+	h := &apiHandlers{
+		flags:    flagStore,
+		auth:     handlers.NewAuthHandler(cfg),
+		vendor:   handlers.NewVendorHandler(cfg, media, vendorCache),
+		admin:    handlers.NewAdminHandler(cfg, media, vendorCache),
+		metrics:  handlers.NewAdminMetricsHandler(cfg),
+		user:     handlers.NewUserHandler(cfg, media, vendorCache),
+		group:    handlers.NewGroupHandler(cfg),
+		event:    handlers.NewEventHandler(cfg),
+		media:    handlers.NewMediaHandler(cfg, media),
+		webhook:  handlers.NewWebhookHandler(cfg),
+		flag:     handlers.NewFlagHandler(cfg, flagStore),
+		category: handlers.NewCategoryHandler(cfg, vendorCache),
+		location: handlers.NewLocationHandler(cfg),
+		review:   handlers.NewReviewHandler(cfg, vendorCache),
+	}
 
-	// Public Routes
+	// Unversioned health check for load balancers and uptime probes
 	r.GET("/health", handlers.HealthCheck)
-	r.GET("/vendors", vendorHandler.ListVerifiedVendors)
-	r.GET("/vendors/slug/:slug", vendorHandler.GetVendorBySlug)
 
-	// Media Upload (Protected? or Public? usually protected)
-	// User didn't specify, but let's make it protected to prevent abuse.
-	// Actually, having it public is dangerous. I'll put it in Protected.
+	v1 := r.Group(APIPrefix)
+	v1.GET("/health", handlers.HealthCheck)
+	v1.GET("/openapi.json", serveSpec)
+	v1.GET("/docs", serveDocs)
+	mountAPI(v1, cfg, h)
+	mountV1Only(v1, cfg, h)
+
+	// Legacy root aliases, kept until cfg.LegacyRoutesSunset for the deployed
+	// web client. Do not add routes here.
+	legacy := r.Group("/")
+	legacy.Use(middleware.Deprecated(legacyDeprecatedAt, cfg.LegacyRoutesSunset, legacySuccessor))
+	mountAPI(legacy, cfg, h)
+
+	// Leftovers with no /v1 twin; see legacySuccessors
+	requireAuth := middleware.AuthMiddleware(cfg)
+	legacy.GET("/admin/stats", requireAuth, middleware.AdminOnly(), h.admin.GetStats)
+	legacy.POST("/superadmin/users/:id/promote-admin", requireAuth, middleware.RequireRole("super_admin"), h.user.PromoteToAdmin)
+}
 
-	authGroup := r.Group("/auth")
+type apiHandlers struct {
+	// flags gates unfinished routes; wrap them in
+	// middleware.RequireFlag(h.flags, "key") instead of commenting them out.
+	flags *flags.Store
+
+	auth     *handlers.AuthHandler
+	vendor   *handlers.VendorHandler
+	admin    *handlers.AdminHandler
+	metrics  *handlers.AdminMetricsHandler
+	user     *handlers.UserHandler
+	group    *handlers.GroupHandler
+	event    *handlers.EventHandler
+	media    *handlers.MediaHandler
+	webhook  *handlers.WebhookHandler
+	flag     *handlers.FlagHandler
+	category *handlers.CategoryHandler
+	location *handlers.LocationHandler
+	review   *handlers.ReviewHandler
+}
+
+// mountAPI registers the versioned API on g. It is mounted twice: under /v1
+// and, deprecated, at the root.
+func mountAPI(g *gin.RouterGroup, cfg *config.Config, h *apiHandlers) {
+	// Public Routes
+	g.GET("/vendors", h.vendor.SearchVendors)
+	g.GET("/vendors/slug/:slug", h.vendor.GetVendorBySlug)
+
+	authGroup := g.Group("/auth")
 	{
-		authGroup.POST("/signup", authHandler.Signup)
-		authGroup.POST("/login", authHandler.Login)
+		authGroup.POST("/signup", h.auth.Signup)
+		authGroup.POST("/login", h.auth.Login)
 	}
 
 	// Protected Routes (Require Auth)
-	protected := r.Group("/")
+	protected := g.Group("/")
 	protected.Use(middleware.AuthMiddleware(cfg))
+	// Creates and uploads that clients may retry with an Idempotency-Key
+	idempotent := middleware.Idempotent(cfg)
 	{
 		// User & Dashboard
-		protected.GET("/me", userHandler.GetMe)
-		protected.PUT("/me", userHandler.UpdateMe)
+		protected.GET("/me", h.user.GetMe)
+		protected.PUT("/me", h.user.UpdateMe)
 
 		// Profile Image
-		protected.POST("/users/profile-image", userHandler.UploadProfileImage)
+		protected.POST("/users/profile-image", idempotent, h.user.UploadProfileImage)
 
 		// Media
-		protected.POST("/media/upload", mediaHandler.Upload)
+		protected.POST("/media/upload", idempotent, h.media.Upload)
 
 		// Vendor Onboarding & Management
-		protected.POST("/vendor/onboard", vendorHandler.OnboardVendor)
-		protected.GET("/vendor/me", vendorHandler.GetMyProfile)
-		protected.PUT("/vendor/me", vendorHandler.UpdateVendor)
+		protected.POST("/vendor/onboard", idempotent, h.vendor.OnboardVendor)
+		protected.GET("/vendor/me", h.vendor.GetMyProfile)
+		protected.PUT("/vendor/me", h.vendor.UpdateVendor)
 
 		// Vendor Gallery & Portfolio
-		protected.POST("/vendors/:id/gallery", vendorHandler.UploadGalleryImage)
-		protected.DELETE("/vendors/:id/gallery/:imageID", vendorHandler.DeleteGalleryImage)
-		protected.POST("/vendors/:id/portfolio", vendorHandler.UploadPortfolioFile)
-		protected.DELETE("/vendors/:id/portfolio/:fileID", vendorHandler.DeletePortfolioFile)
+		protected.POST("/vendors/:id/gallery", idempotent, h.vendor.UploadGalleryImage)
+		protected.DELETE("/vendors/:id/gallery/:imageID", h.vendor.DeleteGalleryImage)
+		protected.POST("/vendors/:id/portfolio", idempotent, h.vendor.UploadPortfolioFile)
+		protected.DELETE("/vendors/:id/portfolio/:fileID", h.vendor.DeletePortfolioFile)
 
 		// Groups
-		protected.POST("/groups", groupHandler.CreateGroup)
-		protected.GET("/groups/my", groupHandler.ListMyGroups)
+		protected.POST("/groups", idempotent, h.group.CreateGroup)
+		protected.GET("/groups/my", h.group.ListMyGroups)
 
 		// Events
-		protected.POST("/events", eventHandler.CreateEvent)
-		protected.GET("/events", eventHandler.ListMyEvents)
-		protected.GET("/events/:id", eventHandler.GetEventById)
-		protected.POST("/events/:id/shortlist/:vendorID", eventHandler.ShortlistVendor)
-		protected.GET("/events/:id/shortlist", eventHandler.GetShortlistedVendors)
+		protected.POST("/events", idempotent, h.event.CreateEvent)
+		protected.GET("/events", h.event.ListMyEvents)
+		protected.GET("/events/:id", h.event.GetEventById)
+		protected.POST("/events/:id/shortlist/:vendorID", h.event.ShortlistVendor)
+		protected.GET("/events/:id/shortlist", h.event.GetShortlistedVendors)
 
 		// Admin Routes (Admin & Super Admin)
 		adminRoutes := protected.Group("/admin")
 		adminRoutes.Use(middleware.AdminOnly())
 		{
-			// Dashboard Stats (Legacy)
-			adminRoutes.GET("/stats", adminHandler.GetStats)
-
 			// Analytics Layer
-			adminMetricsHandler := handlers.NewAdminMetricsHandler()
-			adminRoutes.GET("/metrics/overview", adminMetricsHandler.GetAdminMetricsOverview)
-			adminRoutes.GET("/metrics/growth", adminMetricsHandler.GetAdminMetricsGrowth)
-			adminRoutes.GET("/metrics/events", adminMetricsHandler.GetAdminMetricsEvents)
-			adminRoutes.GET("/metrics/vendors", adminMetricsHandler.GetAdminMetricsVendors)
+			adminRoutes.GET("/metrics/overview", h.metrics.GetAdminMetricsOverview)
+			adminRoutes.GET("/metrics/growth", h.metrics.GetAdminMetricsGrowth)
+			adminRoutes.GET("/metrics/events", h.metrics.GetAdminMetricsEvents)
+			adminRoutes.GET("/metrics/vendors", h.metrics.GetAdminMetricsVendors)
 
 			// Vendor Management
-			// Note: Keeping RequirePermission for granular control if needed, but AdminOnly covers general access.
-			// If we want to strictly follow "Only admin and super_admin", AdminOnly is sufficient.
-			// Existing code used "vendor.verify" permission. I'll keep it for safety but main gate is AdminOnly.
-			adminRoutes.GET("/vendors", adminHandler.GetVendors)
-			adminRoutes.PATCH("/vendors/:id/approve", adminHandler.VerifyVendor)
-			adminRoutes.PATCH("/vendors/:id/reject", adminHandler.RejectVendor)
+			adminRoutes.GET("/vendors", h.admin.GetVendors)
+			adminRoutes.PATCH("/vendors/:id/approve", h.admin.VerifyVendor)
+			adminRoutes.PATCH("/vendors/:id/reject", h.admin.RejectVendor)
 
 			// User Management
-			adminRoutes.GET("/users", adminHandler.GetUsers)
+			adminRoutes.GET("/users", h.admin.GetUsers)
 
 			// Role Management (Super Admin Only)
-			// We can use a specific route group or just checking the role in handler (which we added middleware for in route)
-			// Better to be explicit in route definition if possible.
-			adminRoutes.PATCH("/users/:id/role", middleware.RequireRole("super_admin"), adminHandler.UpdateUserRole)
+			adminRoutes.PATCH("/users/:id/role", middleware.RequireRole("super_admin"), h.admin.UpdateUserRole)
 		}
+	}
+}
+
+// mountV1Only registers routes added after the /v1 cut-over. They have no
+// legacy root alias.
+func mountV1Only(g *gin.RouterGroup, cfg *config.Config, h *apiHandlers) {
+	g.GET("/categories", h.category.ListCategories)
+	g.GET("/locations", h.location.ListLocations)
+	g.GET("/vendors/slug/:slug/reviews", h.review.ListVendorReviews)
 
-		// Super Admin Routes (Legacy/Specific)
-		superAdminRoutes := protected.Group("/superadmin")
-		superAdminRoutes.Use(middleware.RequireRole("super_admin"))
+	protected := g.Group("/")
+	protected.Use(middleware.AuthMiddleware(cfg))
+	{
+		// Partial profile updates
+		protected.PATCH("/me", h.user.PatchMe)
+		protected.PATCH("/vendor/me", h.vendor.PatchVendor)
+
+		// Vendor packages
+		protected.GET("/vendor/me/packages", h.vendor.ListMyPackages)
+		protected.POST("/vendor/me/packages", h.vendor.CreatePackage)
+		protected.PATCH("/vendor/me/packages/:id", h.vendor.PatchPackage)
+		protected.DELETE("/vendor/me/packages/:id", h.vendor.DeletePackage)
+
+		// Reviews
+		protected.POST("/vendors/:id/reviews", h.review.CreateReview)
+		protected.PATCH("/reviews/:id", h.review.EditReview)
+		protected.PUT("/reviews/:id/reply", h.review.ReplyToReview)
+
+		// Vendor availability
+		protected.GET("/vendor/me/availability", h.vendor.GetMyAvailability)
+		protected.PUT("/vendor/me/availability/:date", h.vendor.SetAvailability)
+		protected.DELETE("/vendor/me/availability/:date", h.vendor.ClearAvailability)
+
+		// Verification documents (KYC)
+		protected.GET("/vendor/me/documents", h.vendor.ListMyDocuments)
+		protected.POST("/vendor/me/documents", h.vendor.UploadDocument)
+		protected.DELETE("/vendor/me/documents/:id", h.vendor.DeleteDocument)
+
+		// Deletion (soft; admins can restore until the purge)
+		protected.DELETE("/me", h.user.DeleteMe)
+		protected.DELETE("/vendor/me", h.vendor.DeleteMyProfile)
+		protected.DELETE("/groups/:id", h.group.DeleteGroup)
+		protected.DELETE("/events/:id", h.event.DeleteEvent)
+
+		// Webhooks
+		protected.POST("/webhooks", h.webhook.CreateWebhook)
+		protected.GET("/webhooks", h.webhook.ListWebhooks)
+		protected.GET("/webhooks/:id", h.webhook.GetWebhook)
+		protected.PATCH("/webhooks/:id", h.webhook.UpdateWebhook)
+		protected.DELETE("/webhooks/:id", h.webhook.DeleteWebhook)
+		protected.GET("/webhooks/:id/deliveries", h.webhook.ListDeliveries)
+		protected.POST("/webhooks/:id/deliveries/:deliveryID/replay", h.webhook.ReplayDelivery)
+
+		// Feature flags
+		protected.GET("/flags", h.flag.GetMyFlags)
+
+		adminRoutes := protected.Group("/admin")
+		adminRoutes.Use(middleware.AdminOnly())
 		{
-			// Keep existing if needed, or deprecate/move to admin
-			superAdminRoutes.POST("/users/:id/promote-admin", userHandler.PromoteToAdmin)
+			adminRoutes.GET("/flags", h.flag.ListFlags)
+			adminRoutes.PUT("/flags/:key", h.flag.PutFlag)
+			adminRoutes.PATCH("/flags/:key", h.flag.PatchFlag)
+			adminRoutes.DELETE("/flags/:key", h.flag.DeleteFlag)
+
+			// Vendor categories
+			adminRoutes.POST("/categories", h.category.CreateCategory)
+			adminRoutes.PATCH("/categories/:id", h.category.PatchCategory)
+			adminRoutes.DELETE("/categories/:id", h.category.DeleteCategory)
+
+			// Review moderation
+			adminRoutes.GET("/reviews", h.review.ListReviews)
+			adminRoutes.PATCH("/reviews/:id", h.review.ModerateReview)
+
+			// Vendor verification documents
+			adminRoutes.GET("/vendors/:id/documents", h.admin.ListVendorDocuments)
+			adminRoutes.PATCH("/vendors/:id/documents/:documentID", h.admin.ReviewDocument)
+
+			// Trash
+			adminRoutes.GET("/trash", h.admin.ListTrash)
+			superAdmin := middleware.RequireRole("super_admin")
+			adminRoutes.DELETE("/users/:id", superAdmin, h.admin.DeleteEntity(trash.Users))
+			adminRoutes.POST("/users/:id/restore", superAdmin, h.admin.RestoreEntity(trash.Users))
+			for _, kind := range []trash.Kind{trash.Vendors, trash.Groups, trash.Events} {
+				adminRoutes.DELETE("/"+string(kind)+"/:id", h.admin.DeleteEntity(kind))
+				adminRoutes.POST("/"+string(kind)+"/:id/restore", h.admin.RestoreEntity(kind))
+			}
 		}
 	}
 }
+
+// legacySuccessor returns the /v1 path that replaces the legacy route c hit.
+func legacySuccessor(c *gin.Context) string {
+	if next, ok := legacySuccessors[c.FullPath()]; ok {
+		return strings.ReplaceAll(next, ":id", c.Param("id"))
+	}
+	return APIPrefix + c.Request.URL.Path
+}
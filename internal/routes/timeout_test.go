@@ -0,0 +1,92 @@
+package routes_test
+
+import (
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bventy/backend/internal/config"
+	"github.com/bventy/backend/internal/routes"
+	"github.com/gin-gonic/gin"
+)
+
+// blockingMediaStore never finishes an upload on its own; it waits for the
+// handler's context and reports how it ended.
+type blockingMediaStore struct {
+	started chan struct{}
+	ended   chan error
+}
+
+func newBlockingMediaStore() *blockingMediaStore {
+	return &blockingMediaStore{started: make(chan struct{}, 1), ended: make(chan error, 1)}
+}
+
+func (b *blockingMediaStore) UploadFile(ctx context.Context, file multipart.File, originalFilename string, contentType string, prefixPath string) (string, error) {
+	b.started <- struct{}{}
+	<-ctx.Done()
+	b.ended <- ctx.Err()
+	return "", ctx.Err()
+}
+
+func (b *blockingMediaStore) ConvertToWebP(ctx context.Context, fileURL string, prefixPath string) (string, error) {
+	return "", nil
+}
+
+func (b *blockingMediaStore) DeleteFile(ctx context.Context, fileURL string) error {
+	return nil
+}
+
+func (b *blockingMediaStore) UploadPrivateFile(ctx context.Context, file multipart.File, originalFilename string, contentType string, prefixPath string) (string, error) {
+	return b.UploadFile(ctx, file, originalFilename, contentType, prefixPath)
+}
+
+func (b *blockingMediaStore) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	return "", nil
+}
+
+func (b *blockingMediaStore) DeletePrivateFile(ctx context.Context, key string) error {
+	return nil
+}
+
+// timeoutServer serves uploads from a blocking store. The database is only
+// needed for signup and the session check, which run without the timeouts.
+func timeoutServer(t *testing.T, timeouts config.Timeouts) (*client, *blockingMediaStore) {
+	t.Helper()
+	user, _ := signup(t, newServer(t), "timeout")
+
+	cfg := *testCfg
+	cfg.Timeouts = timeouts
+	store := newBlockingMediaStore()
+	r := gin.New()
+	routes.RegisterRoutes(r, &cfg, store)
+	return &client{t: t, r: r, token: user.token}, store
+}
+
+func TestMediaDeadlineReturns504(t *testing.T) {
+	c, store := timeoutServer(t, config.Timeouts{Media: 20 * time.Millisecond})
+
+	rec := c.upload("/v1/media/upload", "file", "a.png", "image/png", []byte("png"))
+	expectStatus(t, rec, http.StatusGatewayTimeout)
+	if err := <-store.ended; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("store saw %v, want deadline exceeded", err)
+	}
+}
+
+func TestClientDisconnectCancelsUpload(t *testing.T) {
+	c, store := timeoutServer(t, config.Timeouts{Media: time.Minute})
+
+	ctx, disconnect := context.WithCancel(context.Background())
+	go func() {
+		<-store.started
+		disconnect()
+	}()
+
+	rec := c.uploadWithContext(ctx, "/v1/media/upload", "file", "a.pdf", "application/pdf", []byte("%PDF"))
+	if err := <-store.ended; !errors.Is(err, context.Canceled) {
+		t.Fatalf("store saw %v, want canceled", err)
+	}
+	expectStatus(t, rec, http.StatusServiceUnavailable)
+}
@@ -0,0 +1,117 @@
+package routes_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/trash"
+	"github.com/gin-gonic/gin"
+)
+
+func TestSoftDeleteAndRestore(t *testing.T) {
+	r := newServer(t)
+	owner, ownerEmail := signup(t, r, "leaver")
+	outsider, _ := signup(t, r, "outsider")
+	admin := adminClient(t, r)
+	_, superEmail := signup(t, r, "super")
+	setRole(t, superEmail, "super_admin")
+	super := login(t, r, superEmail)
+	public := &client{t: t, r: r}
+
+	var me idOnly
+	decode(t, owner.do(http.MethodGet, "/v1/me", nil), &me)
+	vendorID, slug := onboardVendor(t, owner, uniqueName("Leaving Lights"))
+	approveVendor(t, admin, vendorID)
+
+	rec := owner.do(http.MethodPost, "/v1/groups", gin.H{"name": uniqueName("Leavers"), "city": "Pune"})
+	expectStatus(t, rec, http.StatusCreated)
+	var group struct {
+		GroupID string `json:"group_id"`
+	}
+	decode(t, rec, &group)
+	newEvent := func(body gin.H) string {
+		rec := owner.do(http.MethodPost, "/v1/events", body)
+		expectStatus(t, rec, http.StatusCreated)
+		var created struct {
+			EventID string `json:"event_id"`
+		}
+		decode(t, rec, &created)
+		return created.EventID
+	}
+	groupEvent := newEvent(gin.H{"title": "Meetup", "city": "Pune", "event_date": "2026-12-01", "organizer_group_id": group.GroupID})
+	ownEvent := newEvent(gin.H{"title": "Party", "city": "Pune", "event_date": "2026-12-02"})
+
+	// Only the organiser or group owner may delete.
+	expectStatus(t, outsider.do(http.MethodDelete, "/v1/groups/"+group.GroupID, nil), http.StatusForbidden)
+	expectStatus(t, outsider.do(http.MethodDelete, "/v1/events/"+ownEvent, nil), http.StatusForbidden)
+
+	expectStatus(t, owner.do(http.MethodPost, "/v1/events/"+ownEvent+"/shortlist/"+vendorID, nil), http.StatusOK)
+	expectStatus(t, owner.do(http.MethodGet, "/v1/events/"+ownEvent+"/shortlist", nil), http.StatusOK)
+	expectStatus(t, owner.do(http.MethodDelete, "/v1/events/"+ownEvent, nil), http.StatusOK)
+	expectStatus(t, owner.do(http.MethodGet, "/v1/events/"+ownEvent, nil), http.StatusNotFound)
+	expectStatus(t, owner.do(http.MethodGet, "/v1/events/"+ownEvent+"/shortlist", nil), http.StatusNotFound)
+	expectStatus(t, owner.do(http.MethodDelete, "/v1/events/"+ownEvent, nil), http.StatusNotFound)
+
+	// Deleting the user takes their vendor profile, group and its events along.
+	userPath := "/v1/admin/users/" + me.ID
+	expectStatus(t, admin.do(http.MethodDelete, userPath, nil), http.StatusForbidden)
+	expectStatus(t, super.do(http.MethodDelete, userPath, nil), http.StatusOK)
+
+	anon := &client{t: t, r: r}
+	expectStatus(t, anon.do(http.MethodPost, "/v1/auth/login", gin.H{"email": ownerEmail, "password": "password123"}), http.StatusUnauthorized)
+	expectStatus(t, owner.do(http.MethodGet, "/v1/me", nil), http.StatusUnauthorized)
+	expectStatus(t, public.do(http.MethodGet, "/v1/vendors/slug/"+slug, nil), http.StatusNotFound)
+	expectStatus(t, outsider.do(http.MethodGet, "/v1/events/"+groupEvent, nil), http.StatusNotFound)
+
+	rec = admin.do(http.MethodGet, "/v1/admin/trash?kind=vendors", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var deleted []idOnly
+	decode(t, rec, &deleted)
+	if !containsID(deleted, vendorID) {
+		t.Fatalf("vendor %s missing from trash: %+v", vendorID, deleted)
+	}
+	expectStatus(t, admin.do(http.MethodGet, "/v1/admin/trash?kind=planets", nil), http.StatusBadRequest)
+
+	// The vendor cannot come back without its owner.
+	expectStatus(t, admin.do(http.MethodPost, "/v1/admin/vendors/"+vendorID+"/restore", nil), http.StatusConflict)
+
+	// Restoring the user brings back what was deleted with them, but not
+	// the event deleted on its own before.
+	expectStatus(t, super.do(http.MethodPost, userPath+"/restore", nil), http.StatusOK)
+	expectStatus(t, super.do(http.MethodPost, userPath+"/restore", nil), http.StatusNotFound)
+	owner = login(t, r, ownerEmail)
+	expectStatus(t, public.do(http.MethodGet, "/v1/vendors/slug/"+slug, nil), http.StatusOK)
+	expectStatus(t, owner.do(http.MethodGet, "/v1/events/"+groupEvent, nil), http.StatusOK)
+	expectStatus(t, owner.do(http.MethodGet, "/v1/events/"+ownEvent, nil), http.StatusNotFound)
+
+	expectStatus(t, admin.do(http.MethodPost, "/v1/admin/events/"+ownEvent+"/restore", nil), http.StatusOK)
+	expectStatus(t, owner.do(http.MethodGet, "/v1/events/"+ownEvent, nil), http.StatusOK)
+
+	// Past the retention period the purge removes rows for good.
+	expectStatus(t, owner.do(http.MethodDelete, "/v1/groups/"+group.GroupID, nil), http.StatusOK)
+	ctx := context.Background()
+	if _, err := db.Pool.Exec(ctx, `UPDATE groups SET deleted_at = deleted_at - interval '2 hours' WHERE id = $1`, group.GroupID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Pool.Exec(ctx, `UPDATE events SET deleted_at = deleted_at - interval '2 hours' WHERE id = $1`, groupEvent); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := trash.Purge(ctx, db.Pool, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	var remaining int
+	db.Pool.QueryRow(ctx, `SELECT count(*) FROM events WHERE id = $1`, groupEvent).Scan(&remaining)
+	if remaining != 0 {
+		t.Fatalf("purged group event still has %d row(s)", remaining)
+	}
+	expectStatus(t, admin.do(http.MethodPost, "/v1/admin/groups/"+group.GroupID+"/restore", nil), http.StatusNotFound)
+
+	// Self-service account deletion
+	expectStatus(t, owner.do(http.MethodDelete, "/v1/vendor/me", nil), http.StatusOK)
+	expectStatus(t, owner.do(http.MethodGet, "/v1/vendor/me", nil), http.StatusNotFound)
+	expectStatus(t, owner.do(http.MethodDelete, "/v1/me", nil), http.StatusOK)
+	expectStatus(t, owner.do(http.MethodGet, "/v1/me", nil), http.StatusUnauthorized)
+}
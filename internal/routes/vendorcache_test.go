@@ -0,0 +1,52 @@
+package routes_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPublicVendorCaching(t *testing.T) {
+	r := newServer(t)
+	owner, _ := signup(t, r, "cached")
+	admin := adminClient(t, r)
+	public := &client{t: t, r: r}
+
+	vendorID, slug := onboardVendor(t, owner, uniqueName("Cached Decor"))
+	approveVendor(t, admin, vendorID)
+
+	conditional := func(path, etag string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("If-None-Match", etag)
+		return public.send(req)
+	}
+
+	for _, path := range []string{"/v1/vendors", "/v1/vendors/slug/" + slug} {
+		rec := public.do(http.MethodGet, path, nil)
+		expectStatus(t, rec, http.StatusOK)
+		etag := rec.Header().Get("ETag")
+		if etag == "" || rec.Header().Get("Last-Modified") == "" || rec.Header().Get("Cache-Control") == "" {
+			t.Fatalf("%s: missing caching headers: %v", path, rec.Header())
+		}
+
+		rec = conditional(path, etag)
+		expectStatus(t, rec, http.StatusNotModified)
+		if rec.Body.Len() != 0 {
+			t.Fatalf("%s: 304 with a body", path)
+		}
+
+		// An update on this instance is visible at once, under a new ETag.
+		expectStatus(t, owner.doWith(http.MethodPut, "/v1/vendor/me", gin.H{"bio": "Updated for " + path}, map[string]string{"If-Match": "*"}), http.StatusOK)
+		rec = conditional(path, etag)
+		expectStatus(t, rec, http.StatusOK)
+		if rec.Header().Get("ETag") == etag {
+			t.Fatalf("%s: ETag unchanged after update", path)
+		}
+	}
+
+	expectStatus(t, admin.do(http.MethodPatch, "/v1/admin/vendors/"+vendorID+"/reject", nil), http.StatusOK)
+	expectStatus(t, public.do(http.MethodGet, "/v1/vendors/slug/"+slug, nil), http.StatusNotFound)
+}
@@ -0,0 +1,99 @@
+package routes_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestVendorSearch(t *testing.T) {
+	r := newServer(t)
+	admin := adminClient(t, r)
+	public := &client{t: t, r: r}
+	// A city of its own keeps vendors from other tests out of the results.
+	city := uniqueName("Searchville")
+
+	onboard := func(prefix string, body gin.H) string {
+		c, _ := signup(t, r, prefix)
+		body["business_name"] = uniqueName(body["business_name"].(string))
+		body["city"] = city
+		body["whatsapp_link"] = "https://wa.me/910000000000"
+		rec := c.do(http.MethodPost, "/v1/vendor/onboard", body)
+		expectStatus(t, rec, http.StatusCreated)
+		var res struct {
+			VendorID string `json:"vendor_id"`
+		}
+		decode(t, rec, &res)
+		approveVendor(t, admin, res.VendorID)
+		return res.VendorID
+	}
+	cheap := onboard("snapper", gin.H{"business_name": "Candid Clicks", "category": "Photography",
+		"bio": "Wedding photographer", "price_min": 20000, "price_max": 45000})
+	pricey := onboard("lens", gin.H{"business_name": "Grand Frames", "category": "Photography",
+		"bio": "Photographer for luxury weddings", "price_min": 80000})
+	caterer := onboard("cook", gin.H{"business_name": "Spice Route", "category": "Catering", "bio": "Biryani for 500"})
+
+	type result struct {
+		Vendors    []idOnly `json:"vendors"`
+		NextCursor *string  `json:"next_cursor"`
+		Total      int      `json:"total"`
+		Facets     struct {
+			Categories []struct {
+				Value string `json:"value"`
+				Count int    `json:"count"`
+			} `json:"categories"`
+		} `json:"facets"`
+	}
+	search := func(params url.Values) result {
+		t.Helper()
+		params.Set("city", city)
+		rec := public.do(http.MethodGet, "/v1/vendors?"+params.Encode(), nil)
+		expectStatus(t, rec, http.StatusOK)
+		var res result
+		decode(t, rec, &res)
+		return res
+	}
+
+	// Plurals match through stemming.
+	res := search(url.Values{"q": {"photographers"}})
+	if res.Total != 2 || !containsID(res.Vendors, cheap) || !containsID(res.Vendors, pricey) {
+		t.Fatalf("q=photographers: %+v", res)
+	}
+
+	res = search(url.Values{"q": {"photographer"}, "max_price": {"50000"}})
+	if res.Total != 1 || res.Vendors[0].ID != cheap {
+		t.Fatalf("max_price=50000: %+v", res)
+	}
+	res = search(url.Values{"min_price": {"50000"}})
+	if res.Total != 1 || res.Vendors[0].ID != pricey {
+		t.Fatalf("min_price=50000: %+v", res)
+	}
+
+	// Category facets ignore the category filter itself.
+	res = search(url.Values{"category": {"catering"}})
+	if res.Total != 1 || res.Vendors[0].ID != caterer {
+		t.Fatalf("category=catering: %+v", res)
+	}
+	counts := map[string]int{}
+	for _, f := range res.Facets.Categories {
+		counts[f.Value] = f.Count
+	}
+	if counts["photography"] != 2 || counts["catering"] != 1 {
+		t.Fatalf("category facets: %+v", res.Facets.Categories)
+	}
+
+	res = search(url.Values{"sort": {"newest"}, "limit": {"2"}})
+	if res.Total != 3 || len(res.Vendors) != 2 || res.Vendors[0].ID != caterer || res.NextCursor == nil {
+		t.Fatalf("sort=newest: %+v", res)
+	}
+	res = search(url.Values{"sort": {"newest"}, "limit": {"2"}, "cursor": {*res.NextCursor}})
+	if len(res.Vendors) != 1 || res.Vendors[0].ID != cheap || res.NextCursor != nil {
+		t.Fatalf("sort=newest, second page: %+v", res)
+	}
+
+	for _, bad := range []string{"sort=cheapest", "min_price=-1", "min_price=10&max_price=5"} {
+		expectStatus(t, public.do(http.MethodGet, "/v1/vendors?"+bad, nil), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,239 @@
+package routes_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bventy/backend/internal/db"
+	"github.com/bventy/backend/internal/jobs"
+	"github.com/bventy/backend/internal/outbox"
+	"github.com/bventy/backend/internal/routes"
+	"github.com/bventy/backend/internal/webhooks"
+	"github.com/bventy/backend/internal/webhooks/webhooktest"
+	"github.com/gin-gonic/gin"
+)
+
+// deliverWebhooks runs the outbox fan-out and every due webhook job. The
+// test receivers listen on loopback, so private addresses are allowed.
+func deliverWebhooks(t *testing.T) {
+	t.Helper()
+	deliverWebhooksWith(t, webhooks.NewClient(true))
+}
+
+func deliverWebhooksWith(t *testing.T, client *http.Client) {
+	t.Helper()
+	ctx := context.Background()
+	d := outbox.NewDispatcher(db.Pool)
+	webhooks.Subscribe(d)
+	w := jobs.NewWorker(db.Pool, jobs.Options{})
+	d.Register(w)
+	webhooks.Register(w, db.Pool, client)
+
+	for {
+		n, err := d.DispatchOnce(ctx)
+		if err != nil {
+			t.Fatalf("dispatch: %v", err)
+		}
+		if n == 0 {
+			break
+		}
+	}
+	for {
+		ran, err := w.RunOnce(ctx)
+		if err != nil {
+			t.Fatalf("run job: %v", err)
+		}
+		if !ran {
+			return
+		}
+	}
+}
+
+func TestWebhookDeliveryAndReplay(t *testing.T) {
+	r := newServer(t)
+	owner, _ := signup(t, r, "partner")
+	vendorID, _ := onboardVendor(t, owner, uniqueName("Hooked Caterers"))
+	receiver := webhooktest.NewReceiver(t, "receiver-secret-0123456789")
+
+	// Non-admins only get their own vendors' events.
+	expectStatus(t, owner.do(http.MethodPost, "/v1/webhooks", gin.H{
+		"url": receiver.URL, "event_types": []string{"group.created"},
+	}), http.StatusBadRequest)
+	expectStatus(t, owner.do(http.MethodPost, "/v1/webhooks", gin.H{
+		"url": receiver.URL, "event_types": []string{"vendor.verified"}, "all_vendors": true,
+	}), http.StatusForbidden)
+
+	rec := owner.do(http.MethodPost, "/v1/webhooks", gin.H{
+		"url": receiver.URL, "event_types": []string{"vendor.verified", "vendor.shortlisted"}, "secret": receiver.Secret,
+	})
+	expectStatus(t, rec, http.StatusCreated)
+	var created struct {
+		Subscription struct {
+			ID string `json:"id"`
+		} `json:"subscription"`
+		Secret string `json:"secret"`
+	}
+	decode(t, rec, &created)
+	hookPath := "/v1/webhooks/" + created.Subscription.ID
+
+	stranger, _ := signup(t, r, "stranger")
+	expectStatus(t, stranger.do(http.MethodGet, hookPath, nil), http.StatusNotFound)
+
+	admin := adminClient(t, r)
+	approveVendor(t, admin, vendorID)
+	deliverWebhooks(t)
+
+	got := receiver.Requests()
+	if len(got) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(got))
+	}
+	if got[0].SignatureErr != nil || got[0].Event != "vendor.verified" || got[0].Envelope.Type != "vendor.verified" {
+		t.Fatalf("unexpected delivery: %+v", got[0])
+	}
+
+	rec = owner.do(http.MethodGet, hookPath+"/deliveries", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var deliveries []struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+	decode(t, rec, &deliveries)
+	if len(deliveries) != 1 || deliveries[0].Status != "succeeded" || deliveries[0].ID != got[0].DeliveryID {
+		t.Fatalf("unexpected delivery log: %+v", deliveries)
+	}
+
+	// A replay is a new delivery of the same event; a failing receiver leaves
+	// it pending for a retry.
+	receiver.SetStatus(http.StatusInternalServerError)
+	rec = owner.do(http.MethodPost, hookPath+"/deliveries/"+deliveries[0].ID+"/replay", nil)
+	expectStatus(t, rec, http.StatusAccepted)
+	deliverWebhooks(t)
+
+	got = receiver.Requests()
+	if len(got) != 2 || got[1].Envelope.ID != got[0].Envelope.ID || got[1].DeliveryID == got[0].DeliveryID {
+		t.Fatalf("replay should resend event %d as a new delivery: %+v", got[0].Envelope.ID, got)
+	}
+	rec = owner.do(http.MethodGet, hookPath+"/deliveries?status=pending", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var pending []struct {
+		Attempts       int  `json:"attempts"`
+		ResponseStatus *int `json:"response_status"`
+	}
+	decode(t, rec, &pending)
+	if len(pending) != 1 || pending[0].Attempts != 1 || pending[0].ResponseStatus == nil || *pending[0].ResponseStatus != 500 {
+		t.Fatalf("unexpected pending deliveries: %+v", pending)
+	}
+
+	expectStatus(t, owner.do(http.MethodDelete, hookPath, nil), http.StatusOK)
+	expectStatus(t, owner.do(http.MethodGet, hookPath, nil), http.StatusNotFound)
+}
+
+func TestWebhooksRefuseInternalAddresses(t *testing.T) {
+	r := newServer(t)
+	owner, _ := signup(t, r, "ssrf")
+	vendorID, _ := onboardVendor(t, owner, uniqueName("Curious Caterers"))
+	receiver := webhooktest.NewReceiver(t, "receiver-secret-0123456789")
+
+	cfg := *testCfg
+	cfg.WebhooksAllowPrivate = false
+	strict := gin.New()
+	routes.RegisterRoutes(strict, &cfg, testMedia)
+	strictOwner := &client{t: t, r: strict, token: owner.token}
+	for _, u := range []string{receiver.URL, "https://127.0.0.1/hook", "https://[::1]/hook", "https://169.254.169.254/latest", "https://10.0.0.8/hook", "https://localhost/hook"} {
+		rec := strictOwner.do(http.MethodPost, "/v1/webhooks", gin.H{"url": u, "event_types": []string{"vendor.verified"}})
+		expectStatus(t, rec, http.StatusBadRequest)
+	}
+
+	// A subscription that got through (say, its name now resolves to
+	// loopback) is refused when the delivery dials, and not retried.
+	rec := owner.do(http.MethodPost, "/v1/webhooks", gin.H{
+		"url": receiver.URL, "event_types": []string{"vendor.verified"}, "secret": receiver.Secret,
+	})
+	expectStatus(t, rec, http.StatusCreated)
+	var created struct {
+		Subscription struct {
+			ID string `json:"id"`
+		} `json:"subscription"`
+	}
+	decode(t, rec, &created)
+
+	approveVendor(t, adminClient(t, r), vendorID)
+	deliverWebhooksWith(t, webhooks.Client)
+	if got := receiver.Requests(); len(got) != 0 {
+		t.Fatalf("receiver on loopback was reached: %+v", got)
+	}
+	rec = owner.do(http.MethodGet, "/v1/webhooks/"+created.Subscription.ID+"/deliveries", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var deliveries []struct {
+		Status    string  `json:"status"`
+		LastError *string `json:"last_error"`
+	}
+	decode(t, rec, &deliveries)
+	if len(deliveries) != 1 || deliveries[0].Status != "failed" || deliveries[0].LastError == nil ||
+		!strings.Contains(*deliveries[0].LastError, webhooks.ErrForbiddenAddress.Error()) {
+		t.Fatalf("unexpected delivery log: %+v", deliveries)
+	}
+}
+
+func TestAllVendorsWebhooksNeedAnAdmin(t *testing.T) {
+	r := newServer(t)
+	owner, _ := signup(t, r, "watched")
+	vendorID, _ := onboardVendor(t, owner, uniqueName("Watched Caterers"))
+	_, superEmail := signup(t, r, "super")
+	setRole(t, superEmail, "super_admin")
+	super := login(t, r, superEmail)
+
+	subscribe := func(admin *client, receiver *webhooktest.Receiver) string {
+		t.Helper()
+		rec := admin.do(http.MethodPost, "/v1/webhooks", gin.H{
+			"url": receiver.URL, "event_types": []string{"vendor.verified"}, "all_vendors": true, "secret": receiver.Secret,
+		})
+		expectStatus(t, rec, http.StatusCreated)
+		var created struct {
+			Subscription idOnly `json:"subscription"`
+		}
+		decode(t, rec, &created)
+		return "/v1/webhooks/" + created.Subscription.ID
+	}
+
+	// Demoted through the admin API: the subscription is switched off.
+	_, demotedEmail := signup(t, r, "demoted")
+	setRole(t, demotedEmail, "admin")
+	demoted := login(t, r, demotedEmail)
+	var me idOnly
+	decode(t, demoted.do(http.MethodGet, "/v1/me", nil), &me)
+	demotedReceiver := webhooktest.NewReceiver(t, "receiver-secret-0123456789")
+	demotedHook := subscribe(demoted, demotedReceiver)
+	expectStatus(t, super.do(http.MethodPatch, "/v1/admin/users/"+me.ID+"/role", gin.H{"role": "user"}), http.StatusOK)
+	rec := login(t, r, demotedEmail).do(http.MethodGet, demotedHook, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var sub struct {
+		Active bool `json:"active"`
+	}
+	decode(t, rec, &sub)
+	if sub.Active {
+		t.Fatal("all_vendors subscription of a demoted admin is still active")
+	}
+
+	// Demoted behind the API's back: fan-out still skips the subscription.
+	_, staleEmail := signup(t, r, "stale")
+	setRole(t, staleEmail, "admin")
+	staleReceiver := webhooktest.NewReceiver(t, "receiver-secret-0123456789")
+	subscribe(login(t, r, staleEmail), staleReceiver)
+	setRole(t, staleEmail, "user")
+
+	adminReceiver := webhooktest.NewReceiver(t, "receiver-secret-0123456789")
+	admin := adminClient(t, r)
+	subscribe(admin, adminReceiver)
+
+	approveVendor(t, admin, vendorID)
+	deliverWebhooks(t)
+	if got := adminReceiver.Requests(); len(got) != 1 {
+		t.Fatalf("admin receiver got %d requests, want 1", len(got))
+	}
+	if got := append(demotedReceiver.Requests(), staleReceiver.Requests()...); len(got) != 0 {
+		t.Fatalf("demoted admins' receivers were reached: %+v", got)
+	}
+}
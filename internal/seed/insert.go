@@ -0,0 +1,136 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Tables lists every table the seeder writes, for Reset.
+var Tables = []string{
+	"event_shortlisted_vendors", "events", "group_invites", "group_members", "groups",
+	"vendor_categories", "vendor_gallery_images", "vendor_portfolio_files", "vendor_profiles", "user_permissions", "users",
+}
+
+// Reset truncates every seeded table.
+func Reset(ctx context.Context, pool *pgxpool.Pool) error {
+	_, err := pool.Exec(ctx, "TRUNCATE TABLE "+strings.Join(Tables, ", ")+" CASCADE")
+	return err
+}
+
+// Insert bulk-loads ds in one transaction. urls maps the media keys in ds to
+// public URLs (see WritePlaceholders); every user gets passwordHash.
+func Insert(ctx context.Context, pool *pgxpool.Pool, ds *Dataset, urls map[string]string, passwordHash string) error {
+	return pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		users := make([][]any, len(ds.Users))
+		for i, u := range ds.Users {
+			users[i] = []any{pgUUID(u.ID), u.Email, passwordHash, u.FullName, u.Username, u.Phone, u.City, u.Role,
+				urls[u.ProfileImageKey], u.CreatedAt, u.CreatedAt}
+		}
+		if err := copyRows(ctx, tx, "users", []string{"id", "email", "password_hash", "full_name", "username", "phone", "city", "role",
+			"profile_image_url", "created_at", "updated_at"}, users); err != nil {
+			return err
+		}
+
+		var vendors, gallery [][]any
+		for _, v := range ds.Vendors {
+			galleryURLs := []string{}
+			for i, key := range v.GalleryKeys {
+				galleryURLs = append(galleryURLs, urls[key])
+				gallery = append(gallery, []any{pgUUID(v.ID), urls[key], i + 1})
+			}
+			vendors = append(vendors, []any{pgUUID(v.ID), pgUUID(v.OwnerUserID), v.BusinessName, v.Slug, v.Category, v.City,
+				v.WhatsappLink, v.Bio, v.Status, urls[v.CoverKey], galleryURLs, v.CreatedAt, v.CreatedAt})
+		}
+		if err := copyRows(ctx, tx, "vendor_profiles", []string{"id", "owner_user_id", "business_name", "slug", "category", "city",
+			"whatsapp_link", "bio", "status", "portfolio_image_url", "gallery_images", "created_at", "updated_at"}, vendors); err != nil {
+			return err
+		}
+		if err := copyRows(ctx, tx, "vendor_gallery_images", []string{"vendor_id", "image_url", "sort_order"}, gallery); err != nil {
+			return err
+		}
+		// Seed categories are the ones migration 025 creates, matched by name.
+		if _, err := tx.Exec(ctx, `
+			UPDATE vendor_profiles vp SET category_id = c.id FROM categories c WHERE c.name = vp.category AND vp.category_id IS NULL`); err != nil {
+			return fmt.Errorf("seed vendor categories: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO vendor_categories (vendor_id, category_id)
+			SELECT id, category_id FROM vendor_profiles WHERE category_id IS NOT NULL
+			ON CONFLICT DO NOTHING`); err != nil {
+			return fmt.Errorf("seed vendor categories: %w", err)
+		}
+
+		var groups, members, invites [][]any
+		for _, g := range ds.Groups {
+			groups = append(groups, []any{pgUUID(g.ID), g.Name, g.Slug, g.Description, g.City, pgUUID(g.OwnerUserID), g.CreatedAt, g.CreatedAt})
+		}
+		for _, m := range ds.GroupMembers {
+			members = append(members, []any{pgUUID(m.GroupID), pgUUID(m.UserID), m.Role})
+		}
+		for _, inv := range ds.GroupInvites {
+			invites = append(invites, []any{pgUUID(inv.ID), pgUUID(inv.GroupID), inv.InvitedEmail, inv.Role, inv.Status, pgUUID(inv.InvitedBy)})
+		}
+		if err := copyRows(ctx, tx, "groups", []string{"id", "name", "slug", "description", "city", "owner_user_id", "created_at", "updated_at"}, groups); err != nil {
+			return err
+		}
+		if err := copyRows(ctx, tx, "group_members", []string{"group_id", "user_id", "role"}, members); err != nil {
+			return err
+		}
+		if err := copyRows(ctx, tx, "group_invites", []string{"id", "group_id", "invited_email", "role", "status", "invited_by"}, invites); err != nil {
+			return err
+		}
+
+		var events, shortlists [][]any
+		for _, e := range ds.Events {
+			events = append(events, []any{pgUUID(e.ID), e.Title, e.City, e.EventType, e.EventDate, e.BudgetMin, e.BudgetMax, e.Status,
+				pgUUIDPtr(e.OrganizerUserID), pgUUIDPtr(e.OrganizerGroupID), e.CreatedAt, e.CreatedAt})
+		}
+		for _, s := range ds.Shortlists {
+			shortlists = append(shortlists, []any{pgUUID(s.EventID), pgUUID(s.VendorID)})
+		}
+		if err := copyRows(ctx, tx, "events", []string{"id", "title", "city", "event_type", "event_date", "budget_min", "budget_max", "status",
+			"organizer_user_id", "organizer_group_id", "created_at", "updated_at"}, events); err != nil {
+			return err
+		}
+		if err := copyRows(ctx, tx, "event_shortlisted_vendors", []string{"event_id", "vendor_id"}, shortlists); err != nil {
+			return err
+		}
+
+		// Seed cities are on the list migration 026 creates.
+		for _, table := range []string{"users", "groups", "vendor_profiles", "events"} {
+			if _, err := tx.Exec(ctx, `
+				UPDATE `+table+` t SET location_id = l.id FROM locations l
+				WHERE lower(t.city) = lower(l.city) AND t.location_id IS NULL`); err != nil {
+				return fmt.Errorf("seed %s locations: %w", table, err)
+			}
+		}
+		return nil
+	})
+}
+
+func copyRows(ctx context.Context, tx pgx.Tx, table string, columns []string, rows [][]any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{table}, columns, pgx.CopyFromRows(rows)); err != nil {
+		return fmt.Errorf("seed %s: %w", table, err)
+	}
+	return nil
+}
+
+func pgUUID(id uuid.UUID) pgtype.UUID {
+	return pgtype.UUID{Bytes: id, Valid: true}
+}
+
+func pgUUIDPtr(id *uuid.UUID) pgtype.UUID {
+	if id == nil {
+		return pgtype.UUID{}
+	}
+	return pgUUID(*id)
+}
@@ -0,0 +1,86 @@
+package seed
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/chai2010/webp"
+)
+
+const (
+	VendorImagesPerCategory = 4
+	AvatarCount             = 8
+)
+
+// Saver stores generated media under a fixed key and returns its public URL.
+// services.LocalMediaStore implements it.
+type Saver interface {
+	Save(key string, data []byte) (string, error)
+}
+
+func VendorImageKey(category string, n int) string {
+	return fmt.Sprintf("seed/vendors/%s/%d.webp", strings.ToLower(category), n)
+}
+
+func AvatarKey(n int) string {
+	return fmt.Sprintf("seed/avatars/%d.webp", n)
+}
+
+// WritePlaceholders renders every placeholder image the dataset can reference
+// and returns their URLs by key. Images are small gradients whose colours are
+// derived from the key, so reruns produce identical files.
+func WritePlaceholders(store Saver) (map[string]string, error) {
+	urls := map[string]string{}
+	save := func(key string, w, h int) error {
+		var buf bytes.Buffer
+		if err := webp.Encode(&buf, placeholder(key, w, h), &webp.Options{Quality: 70}); err != nil {
+			return fmt.Errorf("encode %s: %w", key, err)
+		}
+		url, err := store.Save(key, buf.Bytes())
+		if err != nil {
+			return err
+		}
+		urls[key] = url
+		return nil
+	}
+
+	for _, category := range Categories {
+		for n := 0; n < VendorImagesPerCategory; n++ {
+			if err := save(VendorImageKey(category.Name, n), 640, 480); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for n := 0; n < AvatarCount; n++ {
+		if err := save(AvatarKey(n), 256, 256); err != nil {
+			return nil, err
+		}
+	}
+	return urls, nil
+}
+
+func placeholder(key string, w, h int) image.Image {
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	sum := hash.Sum32()
+	from := color.RGBA{uint8(sum), uint8(sum >> 8), uint8(sum >> 16), 255}
+	to := color.RGBA{255 - from.R/2, 255 - from.G/2, 255 - from.B/2, 255}
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			t := float64(x+y) / float64(w+h)
+			img.Set(x, y, color.RGBA{
+				R: uint8(float64(from.R)*(1-t) + float64(to.R)*t),
+				G: uint8(float64(from.G)*(1-t) + float64(to.G)*t),
+				B: uint8(float64(from.B)*(1-t) + float64(to.B)*t),
+				A: 255,
+			})
+		}
+	}
+	return img
+}
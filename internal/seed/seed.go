@@ -0,0 +1,321 @@
+// Package seed generates a deterministic demo dataset: users, vendors across
+// categories and cities, groups with members and invites, and events with
+// shortlists. The same Options always produce the same Dataset.
+package seed
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Options struct {
+	Seed    int64
+	Users   int
+	Vendors int
+	Groups  int
+	Events  int
+	// Anchor is "today" for the dataset; event dates and created_at values
+	// are spread around it.
+	Anchor time.Time
+}
+
+type User struct {
+	ID              uuid.UUID
+	Email           string
+	FullName        string
+	Username        string
+	Phone           string
+	City            string
+	Role            string
+	ProfileImageKey string
+	CreatedAt       time.Time
+}
+
+type Vendor struct {
+	ID           uuid.UUID
+	OwnerUserID  uuid.UUID
+	BusinessName string
+	Slug         string
+	Category     string
+	City         string
+	Bio          string
+	WhatsappLink string
+	Status       string
+	CoverKey     string
+	GalleryKeys  []string
+	CreatedAt    time.Time
+}
+
+type Group struct {
+	ID          uuid.UUID
+	Name        string
+	Slug        string
+	Description string
+	City        string
+	OwnerUserID uuid.UUID
+	CreatedAt   time.Time
+}
+
+type GroupMember struct {
+	GroupID uuid.UUID
+	UserID  uuid.UUID
+	Role    string
+}
+
+type GroupInvite struct {
+	ID           uuid.UUID
+	GroupID      uuid.UUID
+	InvitedEmail string
+	Role         string
+	Status       string
+	InvitedBy    uuid.UUID
+}
+
+type Event struct {
+	ID               uuid.UUID
+	Title            string
+	City             string
+	EventType        string
+	EventDate        time.Time
+	BudgetMin        int
+	BudgetMax        int
+	Status           string
+	OrganizerUserID  *uuid.UUID
+	OrganizerGroupID *uuid.UUID
+	CreatedAt        time.Time
+}
+
+type Shortlist struct {
+	EventID  uuid.UUID
+	VendorID uuid.UUID
+}
+
+type Dataset struct {
+	Users        []User
+	Vendors      []Vendor
+	Groups       []Group
+	GroupMembers []GroupMember
+	GroupInvites []GroupInvite
+	Events       []Event
+	Shortlists   []Shortlist
+}
+
+var (
+	cities = []string{"Pune", "Mumbai", "Bengaluru", "Delhi", "Hyderabad", "Chennai", "Kolkata", "Ahmedabad", "Jaipur", "Goa"}
+
+	firstNames = []string{"Aarav", "Aditi", "Amit", "Ananya", "Anjali", "Arjun", "Deepa", "Farhan", "Isha", "Kabir",
+		"Kavya", "Kunal", "Meera", "Neha", "Nikhil", "Pooja", "Priya", "Rahul", "Rohan", "Sanjay",
+		"Sneha", "Suresh", "Tanvi", "Varun", "Vikram", "Zoya"}
+	lastNames = []string{"Patil", "Deshmukh", "Joshi", "Shah", "Verma", "Kulkarni", "Gadgil", "Tare", "Malhotra", "Rao",
+		"Kadam", "Shinde", "Mehta", "More", "Singh", "Iyer", "Nair", "Reddy", "Banerjee", "Khan"}
+
+	// Categories maps each vendor category to business-name stems and a bio.
+	Categories = []struct {
+		Name  string
+		Stems []string
+		Bio   string
+	}{
+		{"Photography", []string{"SnapShot Studio", "Candid Frames", "Golden Hour Photo", "Lens & Light"}, "Wedding and event photography that captures moments that last a lifetime."},
+		{"Decor", []string{"Dream Decorators", "Petals & Props", "Theme Makers", "Royal Drapes"}, "Floral and thematic decoration for weddings, parties and corporate events."},
+		{"Venue", []string{"The Grand Pavilion", "Lakeview Lawns", "Skyline Banquets", "Heritage Courtyard"}, "A premium venue for weddings, birthdays and seminars."},
+		{"Catering", []string{"Plate & Palate", "Spice Route Caterers", "Tiffin Tales", "Royal Rasoi"}, "Multi-cuisine catering with a focus on hygiene and taste."},
+		{"DJ", []string{"Beats Collective", "Bass Drop DJs", "Night Owl Sound", "Mix Masters"}, "Professional sound and music for parties, weddings and corporate gigs."},
+		{"Makeup", []string{"Glow & Glam", "Bridal Blush", "Contour Studio", "Kohl & Co"}, "Bridal and party makeup by certified professionals."},
+		{"Lighting", []string{"Stellar Lights", "Sparkle Lighting", "Lumen Works", "Fairy Lights Co"}, "Ambient and stage lighting for indoor and outdoor events."},
+		{"Florist", []string{"Bloom Room", "Marigold Stories", "Petal Post", "Garden Gate Florals"}, "Fresh floral arrangements for every occasion."},
+		{"Transport", []string{"SafeRide Transport", "Baraat Wheels", "Guest Shuttle Co", "Vintage Rides"}, "Guest transport and luxury car rentals for events."},
+		{"Stationery", []string{"Ink & Paper", "Invite Studio", "Letterpress Lane", "Card Couture"}, "Custom invitations and event stationery with a modern touch."},
+	}
+
+	eventTypes  = []string{"Wedding", "Corporate", "Birthday", "Festival", "Gala", "Conference", "Sangeet", "Reception"}
+	eventTitles = []string{"Annual Meetup", "Wedding Celebration", "Startup Awards", "Charity Auction", "Music Night",
+		"Product Launch", "Family Reunion", "Sports Day", "Cultural Fest", "Anniversary Dinner"}
+	groupStems = []string{"Event Planners", "Wedding Hub", "Startup Circle", "Foodies Club", "Music Lovers", "Community Collective"}
+
+	slugPattern = regexp.MustCompile("[^a-z0-9]+")
+)
+
+// Generate builds the dataset. The first two users are the well-known demo
+// accounts superadmin@gmail.com and admin@gmail.com.
+func Generate(opts Options) *Dataset {
+	rng := rand.New(rand.NewSource(opts.Seed))
+	g := &generator{rng: rng, anchor: opts.Anchor, slugs: map[string]int{}}
+	ds := &Dataset{}
+
+	ds.Users = append(ds.Users,
+		g.user("superadmin@gmail.com", "Bventy Super Admin", "superadmin", "super_admin"),
+		g.user("admin@gmail.com", "Bventy Admin", "admin", "admin"),
+	)
+	for i := 0; i < opts.Users; i++ {
+		first, last := pick(rng, firstNames), pick(rng, lastNames)
+		username := fmt.Sprintf("%s_%s_%d", strings.ToLower(first), strings.ToLower(last), i+1)
+		email := fmt.Sprintf("%s.%s.%d@example.com", strings.ToLower(first), strings.ToLower(last), i+1)
+		ds.Users = append(ds.Users, g.user(email, first+" "+last, username, "user"))
+	}
+	regular := ds.Users[2:]
+
+	// Vendor owners are the first regular users; the owner is unique per vendor.
+	vendorCount := min(opts.Vendors, len(regular))
+	for i := 0; i < vendorCount; i++ {
+		category := Categories[rng.Intn(len(Categories))]
+		city := pick(rng, cities)
+		name := pick(rng, category.Stems)
+		status := "verified"
+		switch r := rng.Float64(); {
+		case r < 0.05:
+			status = "rejected"
+		case r < 0.25:
+			status = "pending"
+		}
+		v := Vendor{
+			ID:           g.uuid(),
+			OwnerUserID:  regular[i].ID,
+			BusinessName: name,
+			Slug:         g.slug(name + "-" + city),
+			Category:     category.Name,
+			City:         city,
+			Bio:          category.Bio,
+			WhatsappLink: fmt.Sprintf("https://wa.me/9198%08d", i+1),
+			Status:       status,
+			CoverKey:     VendorImageKey(category.Name, rng.Intn(VendorImagesPerCategory)),
+			CreatedAt:    g.pastTime(180),
+		}
+		for n := rng.Intn(5); n > 0; n-- {
+			v.GalleryKeys = append(v.GalleryKeys, VendorImageKey(category.Name, rng.Intn(VendorImagesPerCategory)))
+		}
+		ds.Vendors = append(ds.Vendors, v)
+	}
+
+	var verified []Vendor
+	for _, v := range ds.Vendors {
+		if v.Status == "verified" {
+			verified = append(verified, v)
+		}
+	}
+
+	for i := 0; i < opts.Groups && len(regular) > 0; i++ {
+		owner := regular[rng.Intn(len(regular))]
+		city := pick(rng, cities)
+		name := fmt.Sprintf("%s %s", city, pick(rng, groupStems))
+		grp := Group{
+			ID:          g.uuid(),
+			Name:        name,
+			Slug:        g.slug(name + "-" + city),
+			Description: fmt.Sprintf("A community of organizers and friends in %s.", city),
+			City:        city,
+			OwnerUserID: owner.ID,
+			CreatedAt:   g.pastTime(120),
+		}
+		ds.Groups = append(ds.Groups, grp)
+		ds.GroupMembers = append(ds.GroupMembers, GroupMember{GroupID: grp.ID, UserID: owner.ID, Role: "owner"})
+
+		members := map[uuid.UUID]bool{owner.ID: true}
+		for n := rng.Intn(6); n > 0; n-- {
+			u := regular[rng.Intn(len(regular))]
+			if members[u.ID] {
+				continue
+			}
+			members[u.ID] = true
+			role := "member"
+			if rng.Float64() < 0.2 {
+				role = "manager"
+			}
+			ds.GroupMembers = append(ds.GroupMembers, GroupMember{GroupID: grp.ID, UserID: u.ID, Role: role})
+		}
+		for n := rng.Intn(4); n > 0; n-- {
+			ds.GroupInvites = append(ds.GroupInvites, GroupInvite{
+				ID:           g.uuid(),
+				GroupID:      grp.ID,
+				InvitedEmail: fmt.Sprintf("invitee.%d.%d@example.com", i+1, n),
+				Role:         pick(rng, []string{"member", "member", "manager"}),
+				Status:       pick(rng, []string{"pending", "pending", "accepted", "expired"}),
+				InvitedBy:    owner.ID,
+			})
+		}
+	}
+
+	for i := 0; i < opts.Events && len(regular) > 0; i++ {
+		budgetMin := (rng.Intn(40) + 1) * 25000
+		e := Event{
+			ID:        g.uuid(),
+			Title:     pick(rng, eventTitles),
+			City:      pick(rng, cities),
+			EventType: pick(rng, eventTypes),
+			EventDate: opts.Anchor.AddDate(0, 0, rng.Intn(300)-60),
+			BudgetMin: budgetMin,
+			BudgetMax: budgetMin * (rng.Intn(3) + 2),
+			Status:    pick(rng, []string{"draft", "planning", "planning", "confirmed"}),
+			CreatedAt: g.pastTime(90),
+		}
+		if len(ds.Groups) > 0 && rng.Float64() < 0.3 {
+			id := ds.Groups[rng.Intn(len(ds.Groups))].ID
+			e.OrganizerGroupID = &id
+		} else {
+			id := regular[rng.Intn(len(regular))].ID
+			e.OrganizerUserID = &id
+		}
+		ds.Events = append(ds.Events, e)
+
+		picked := map[uuid.UUID]bool{}
+		for n := rng.Intn(6); n > 0 && len(verified) > 0; n-- {
+			v := verified[rng.Intn(len(verified))]
+			if picked[v.ID] {
+				continue
+			}
+			picked[v.ID] = true
+			ds.Shortlists = append(ds.Shortlists, Shortlist{EventID: e.ID, VendorID: v.ID})
+		}
+	}
+
+	return ds
+}
+
+type generator struct {
+	rng    *rand.Rand
+	anchor time.Time
+	slugs  map[string]int
+	users  int
+}
+
+func (g *generator) uuid() uuid.UUID {
+	id, _ := uuid.NewRandomFromReader(g.rng)
+	return id
+}
+
+func (g *generator) user(email, fullName, username, role string) User {
+	g.users++
+	return User{
+		ID:              g.uuid(),
+		Email:           email,
+		FullName:        fullName,
+		Username:        username,
+		Phone:           fmt.Sprintf("+9199%08d", g.users),
+		City:            pick(g.rng, cities),
+		Role:            role,
+		ProfileImageKey: AvatarKey(g.rng.Intn(AvatarCount)),
+		CreatedAt:       g.pastTime(365),
+	}
+}
+
+// slug mirrors handlers.generateSlug and appends a counter on collisions.
+func (g *generator) slug(base string) string {
+	s := strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(base), "-"), "-")
+	g.slugs[s]++
+	if n := g.slugs[s]; n > 1 {
+		return fmt.Sprintf("%s-%d", s, n)
+	}
+	return s
+}
+
+func (g *generator) pastTime(maxDays int) time.Time {
+	return g.anchor.Add(-time.Duration(g.rng.Int63n(int64(maxDays) * int64(24*time.Hour))))
+}
+
+func pick[T any](rng *rand.Rand, items []T) T {
+	return items[rng.Intn(len(items))]
+}
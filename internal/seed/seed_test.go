@@ -0,0 +1,72 @@
+package seed
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testOptions(seed int64) Options {
+	return Options{Seed: seed, Users: 40, Vendors: 25, Groups: 8, Events: 30, Anchor: time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)}
+}
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	a := Generate(testOptions(7))
+	b := Generate(testOptions(7))
+	if !reflect.DeepEqual(a, b) {
+		t.Fatal("same seed produced different datasets")
+	}
+	if reflect.DeepEqual(a.Users, Generate(testOptions(8)).Users) {
+		t.Fatal("different seeds produced identical users")
+	}
+}
+
+func TestGenerateRespectsConstraints(t *testing.T) {
+	ds := Generate(testOptions(42))
+
+	if len(ds.Users) != 42 || ds.Users[0].Role != "super_admin" || ds.Users[1].Role != "admin" {
+		t.Fatalf("unexpected users: %d, roles %s/%s", len(ds.Users), ds.Users[0].Role, ds.Users[1].Role)
+	}
+	unique := func(kind string, values []string) {
+		seen := map[string]bool{}
+		for _, v := range values {
+			if seen[v] {
+				t.Fatalf("duplicate %s %q", kind, v)
+			}
+			seen[v] = true
+		}
+	}
+	var emails, usernames, vendorSlugs, owners, groupSlugs []string
+	for _, u := range ds.Users {
+		emails = append(emails, u.Email)
+		usernames = append(usernames, u.Username)
+	}
+	status := map[string]string{}
+	for _, v := range ds.Vendors {
+		vendorSlugs = append(vendorSlugs, v.Slug)
+		owners = append(owners, v.OwnerUserID.String())
+		status[v.ID.String()] = v.Status
+	}
+	for _, g := range ds.Groups {
+		groupSlugs = append(groupSlugs, g.Slug)
+	}
+	unique("email", emails)
+	unique("username", usernames)
+	unique("vendor slug", vendorSlugs)
+	unique("vendor owner", owners)
+	unique("group slug", groupSlugs)
+
+	for _, e := range ds.Events {
+		if (e.OrganizerUserID == nil) == (e.OrganizerGroupID == nil) {
+			t.Fatalf("event %s must have exactly one organizer", e.ID)
+		}
+		if e.BudgetMax < e.BudgetMin {
+			t.Fatalf("event %s budget max below min", e.ID)
+		}
+	}
+	for _, s := range ds.Shortlists {
+		if status[s.VendorID.String()] != "verified" {
+			t.Fatalf("shortlisted vendor %s is not verified", s.VendorID)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LocalMediaStore keeps media on the local filesystem instead of R2. The API
+// serves Dir under PublicBaseURL when MEDIA_BACKEND=local, which makes it
+// usable for demos and local environments without R2 credentials.
+type LocalMediaStore struct {
+	Dir           string
+	PublicBaseURL string
+
+	// Private files live outside Dir, under PrivateDir, and are served by
+	// PrivateHandler to URLs signed with signingKey. See EnablePrivate.
+	PrivateDir     string
+	PrivateBaseURL string
+	signingKey     []byte
+}
+
+func NewLocalMediaStore(dir, publicBaseURL string) (*LocalMediaStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("unable to create media dir: %w", err)
+	}
+	return &LocalMediaStore{
+		Dir:           dir,
+		PublicBaseURL: strings.TrimSuffix(publicBaseURL, "/"),
+	}, nil
+}
+
+// UploadFile stores a raw file (e.g. PDF) under prefixPath
+func (s *LocalMediaStore) UploadFile(ctx context.Context, file multipart.File, originalFilename string, contentType string, prefixPath string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	key := fmt.Sprintf("%s/%s%s", prefixPath, uuid.New().String(), filepath.Ext(originalFilename))
+	return s.Save(key, data)
+}
+
+// ConvertToWebP converts the image at fileURL to WebP and stores it under prefixPath
+func (s *LocalMediaStore) ConvertToWebP(ctx context.Context, fileURL string, prefixPath string) (string, error) {
+	path, err := s.path(strings.TrimPrefix(fileURL, s.PublicBaseURL))
+	if err != nil {
+		return "", err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read local file: %w", err)
+	}
+	defer file.Close()
+	buf, err := encodeWebP(file)
+	if err != nil {
+		return "", err
+	}
+	// Encoding is the slow part; don't write if the caller gave up meanwhile.
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("%s/%s.webp", prefixPath, uuid.New().String())
+	return s.Save(key, buf.Bytes())
+}
+
+// DeleteFile removes a file given its public URL
+func (s *LocalMediaStore) DeleteFile(ctx context.Context, fileURL string) error {
+	if fileURL == "" {
+		return nil
+	}
+	path, err := s.path(strings.TrimPrefix(fileURL, s.PublicBaseURL))
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete local file: %w", err)
+	}
+	return nil
+}
+
+// Save writes data at key and returns its public URL. Callers that generate
+// their own content (such as the seeder) use it to pick stable keys.
+func (s *LocalMediaStore) Save(key string, data []byte) (string, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create media dir: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write local file: %w", err)
+	}
+	return s.PublicBaseURL + "/" + filepath.ToSlash(strings.TrimPrefix(key, "/")), nil
+}
+
+// path resolves key inside Dir, refusing keys that would escape it.
+func (s *LocalMediaStore) path(key string) (string, error) {
+	clean := filepath.Clean("/" + strings.TrimPrefix(key, "/"))
+	if clean == "/" {
+		return "", fmt.Errorf("invalid media key %q", key)
+	}
+	return filepath.Join(s.Dir, clean), nil
+}
+
+// EnablePrivate turns on private files, stored under dir and signed with key.
+// The caller mounts PrivateHandler at baseURL.
+func (s *LocalMediaStore) EnablePrivate(dir, baseURL string, key []byte) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("unable to create private media dir: %w", err)
+	}
+	s.PrivateDir = dir
+	s.PrivateBaseURL = strings.TrimSuffix(baseURL, "/")
+	s.signingKey = key
+	return nil
+}
+
+var errPrivateDisabled = errors.New("private media is not enabled")
+
+// UploadPrivateFile stores a file under PrivateDir and returns its key
+func (s *LocalMediaStore) UploadPrivateFile(ctx context.Context, file multipart.File, originalFilename string, contentType string, prefixPath string) (string, error) {
+	if s.PrivateDir == "" {
+		return "", errPrivateDisabled
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	key := privateKey(prefixPath, originalFilename)
+	path := s.privatePath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("failed to create private media dir: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write local file: %w", err)
+	}
+	return key, nil
+}
+
+// SignedURL returns a PrivateHandler URL for key that expires after ttl
+func (s *LocalMediaStore) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if s.PrivateDir == "" {
+		return "", errPrivateDisabled
+	}
+	if err := checkPrivateKey(key); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	q := url.Values{"expires": {expires}, "signature": {s.sign(key, expires)}}
+	return s.PrivateBaseURL + "/" + strings.TrimPrefix(key, PrivatePrefix+"/") + "?" + q.Encode(), nil
+}
+
+// DeletePrivateFile removes a private file given its key
+func (s *LocalMediaStore) DeletePrivateFile(ctx context.Context, key string) error {
+	if s.PrivateDir == "" {
+		return errPrivateDisabled
+	}
+	if err := checkPrivateKey(key); err != nil {
+		return err
+	}
+	if err := os.Remove(s.privatePath(key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete local file: %w", err)
+	}
+	return nil
+}
+
+// PrivateHandler serves private files to unexpired signed URLs. Mount it with
+// the path of PrivateBaseURL stripped.
+func (s *LocalMediaStore) PrivateHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := PrivatePrefix + "/" + strings.TrimPrefix(r.URL.Path, "/")
+		expires := r.URL.Query().Get("expires")
+		at, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil || time.Now().Unix() > at || checkPrivateKey(key) != nil ||
+			!hmac.Equal([]byte(r.URL.Query().Get("signature")), []byte(s.sign(key, expires))) {
+			http.Error(w, "invalid or expired signature", http.StatusForbidden)
+			return
+		}
+		http.ServeFile(w, r, s.privatePath(key))
+	})
+}
+
+func (s *LocalMediaStore) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// privatePath resolves a checked private key inside PrivateDir.
+func (s *LocalMediaStore) privatePath(key string) string {
+	return filepath.Join(s.PrivateDir, filepath.FromSlash(strings.TrimPrefix(key, PrivatePrefix+"/")))
+}
@@ -3,13 +3,16 @@ package services
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"image"
 	"image/jpeg"
 	"image/png"
+	"io"
 	"mime/multipart"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -21,13 +24,77 @@ import (
 	"github.com/google/uuid"
 )
 
+// MediaStore is the storage surface the handlers depend on. MediaService is
+// the R2-backed implementation; tests substitute an in-memory one.
+type MediaStore interface {
+	UploadFile(ctx context.Context, file multipart.File, originalFilename string, contentType string, prefixPath string) (string, error)
+	// ConvertToWebP re-encodes the stored image at fileURL as WebP under
+	// prefixPath and returns the new file's URL; the original is kept.
+	ConvertToWebP(ctx context.Context, fileURL string, prefixPath string) (string, error)
+	DeleteFile(ctx context.Context, fileURL string) error
+
+	// Private files have no public URL. UploadPrivateFile returns the
+	// object's key, which SignedURL turns into a link valid for ttl.
+	UploadPrivateFile(ctx context.Context, file multipart.File, originalFilename string, contentType string, prefixPath string) (string, error)
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+	DeletePrivateFile(ctx context.Context, key string) error
+}
+
+// PrivatePrefix starts every private file's key. With R2 the files live in
+// their own bucket, which has no public domain.
+const PrivatePrefix = "private"
+
+// privateKey builds a fresh key for a private upload under prefixPath.
+func privateKey(prefixPath, originalFilename string) string {
+	return fmt.Sprintf("%s/%s/%s%s", PrivatePrefix, strings.Trim(prefixPath, "/"), uuid.New().String(), strings.ToLower(filepath.Ext(originalFilename)))
+}
+
+// checkPrivateKey refuses keys outside PrivatePrefix, so a stored key can
+// never be used to sign or delete public media.
+func checkPrivateKey(key string) error {
+	if !strings.HasPrefix(key, PrivatePrefix+"/") || strings.Contains(key, "..") {
+		return fmt.Errorf("invalid private media key %q", key)
+	}
+	return nil
+}
+
+// NewMediaStore returns the store selected by MEDIA_BACKEND: "local" writes
+// under LocalMediaDir, anything else uses R2.
+func NewMediaStore(cfg *internalConfig.Config) (MediaStore, error) {
+	if cfg.MediaBackend == "local" {
+		store, err := NewLocalMediaStore(cfg.LocalMediaDir, cfg.LocalMediaBaseURL)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.PrivateMediaSecret == "" || cfg.PrivateMediaSecret == cfg.JWTSecret {
+			return nil, errors.New("PRIVATE_MEDIA_SECRET must be set and differ from JWT_SECRET")
+		}
+		if err := store.EnablePrivate(cfg.LocalPrivateDir, cfg.LocalPrivateURL, []byte(cfg.PrivateMediaSecret)); err != nil {
+			return nil, err
+		}
+		return store, nil
+	}
+	store, err := NewMediaService(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
 type MediaService struct {
 	Client        *s3.Client
 	Bucket        string
 	PublicBaseURL string
+	// PrivateBucket holds private files. It must not be Bucket, which is
+	// served on the public media domain.
+	PrivateBucket string
 }
 
 func NewMediaService(cfg *internalConfig.Config) (*MediaService, error) {
+	if cfg.R2PrivateBucket == "" || cfg.R2PrivateBucket == cfg.R2Bucket {
+		return nil, errors.New("R2_PRIVATE_BUCKET must be set to a bucket other than R2_BUCKET")
+	}
+
 	r2Resolver := aws.EndpointResolverWithOptionsFunc(func(service, region string, options ...interface{}) (aws.Endpoint, error) {
 		return aws.Endpoint{
 			URL: cfg.R2Endpoint,
@@ -49,18 +116,19 @@ func NewMediaService(cfg *internalConfig.Config) (*MediaService, error) {
 		Client:        client,
 		Bucket:        cfg.R2Bucket,
 		PublicBaseURL: cfg.R2PublicBaseURL,
+		PrivateBucket: cfg.R2PrivateBucket,
 	}, nil
 }
 
 // UploadFile uploads a raw file (e.g. PDF) to a specific path
-func (s *MediaService) UploadFile(file multipart.File, originalFilename string, contentType string, prefixPath string) (string, error) {
+func (s *MediaService) UploadFile(ctx context.Context, file multipart.File, originalFilename string, contentType string, prefixPath string) (string, error) {
 	ext := filepath.Ext(originalFilename)
 	uniqueName := fmt.Sprintf("%s/%s%s", prefixPath, uuid.New().String(), ext)
 
 	// Ensure prefix doesn't start with /
 	uniqueName = strings.TrimPrefix(uniqueName, "/")
 
-	_, err := s.Client.PutObject(context.TODO(), &s3.PutObjectInput{
+	_, err := s.Client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket:      aws.String(s.Bucket),
 		Key:         aws.String(uniqueName),
 		Body:        file,
@@ -74,35 +142,25 @@ func (s *MediaService) UploadFile(file multipart.File, originalFilename string,
 	return publicURL, nil
 }
 
-// CompressAndUploadImage decodes image, resizes (optional), compresses to WebP, and uploads
-func (s *MediaService) CompressAndUploadImage(file multipart.File, originalFilename string, prefixPath string) (string, error) {
-	// Decode image
-	img, _, err := image.Decode(file)
+// ConvertToWebP downloads the image at fileURL, compresses it to WebP, and uploads it
+func (s *MediaService) ConvertToWebP(ctx context.Context, fileURL string, prefixPath string) (string, error) {
+	obj, err := s.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.key(fileURL)),
+	})
 	if err != nil {
-		// Try to reset file seeker if allowed, but usually multipart file is seekable
-		file.Seek(0, 0)
-		// Fallback decode?
-		return "", fmt.Errorf("failed to decode image: %w", err)
+		return "", fmt.Errorf("failed to download from R2: %w", err)
 	}
-
-	// Resize if needed (e.g. max width 1920? User didn't specify resize, just compression. Let's keep original size or safeguard huge images)
-	// User said "Compress to WebP (quality 80)".
-	// Let's ensure max width 1920 for safety? Or Just compress?
-	// Given "production-ready", resizing big images is good practice.
-	// But let's stick to prompt: "Compress to WebP".
-	// We will use high quality resizing if we do resize.
-	// For now, let's just compress.
-
-	// Encode to WebP
-	var buf bytes.Buffer
-	if err := webp.Encode(&buf, img, &webp.Options{Lossless: false, Quality: 80}); err != nil {
-		return "", fmt.Errorf("failed to encode webp: %w", err)
+	defer obj.Body.Close()
+	buf, err := encodeWebP(obj.Body)
+	if err != nil {
+		return "", err
 	}
 
 	uniqueName := fmt.Sprintf("%s/%s.webp", prefixPath, uuid.New().String())
 	uniqueName = strings.TrimPrefix(uniqueName, "/")
 
-	_, err = s.Client.PutObject(context.TODO(), &s3.PutObjectInput{
+	_, err = s.Client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket:      aws.String(s.Bucket),
 		Key:         aws.String(uniqueName),
 		Body:        bytes.NewReader(buf.Bytes()),
@@ -116,23 +174,108 @@ func (s *MediaService) CompressAndUploadImage(file multipart.File, originalFilen
 	return publicURL, nil
 }
 
+// ErrNotImage means an upload is not an image the WebP encoder can read.
+var ErrNotImage = errors.New("not a supported image")
+
+// CheckImage reads just enough of file to tell that it is a JPEG, PNG or
+// WebP image, then rewinds it. Handlers call it before storing an upload
+// that will be converted later, so a bad file is refused up front.
+func CheckImage(file multipart.File) error {
+	_, _, err := image.DecodeConfig(file)
+	if _, seekErr := file.Seek(0, io.SeekStart); seekErr != nil {
+		return seekErr
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotImage, err)
+	}
+	return nil
+}
+
+// encodeWebP decodes an uploaded image and re-encodes it as WebP (quality 80).
+func encodeWebP(r io.Reader) (*bytes.Buffer, error) {
+	// Decode image
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w: %v", ErrNotImage, err)
+	}
+
+	// Resize if needed (e.g. max width 1920? User didn't specify resize, just compression. Let's keep original size or safeguard huge images)
+	// User said "Compress to WebP (quality 80)".
+	// Let's ensure max width 1920 for safety? Or Just compress?
+	// Given "production-ready", resizing big images is good practice.
+	// But let's stick to prompt: "Compress to WebP".
+	// We will use high quality resizing if we do resize.
+	// For now, let's just compress.
+
+	// Encode to WebP
+	var buf bytes.Buffer
+	if err := webp.Encode(&buf, img, &webp.Options{Lossless: false, Quality: 80}); err != nil {
+		return nil, fmt.Errorf("failed to encode webp: %w", err)
+	}
+	return &buf, nil
+}
+
 // DeleteFile deletes a file from R2 given its full public URL
-func (s *MediaService) DeleteFile(fileURL string) error {
+func (s *MediaService) DeleteFile(ctx context.Context, fileURL string) error {
 	if fileURL == "" {
 		return nil
 	}
 
-	// Extract Key from URL
+	_, err := s.Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.key(fileURL)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete from R2: %w", err)
+	}
+	return nil
+}
+
+// key turns a public URL back into its object key.
+func (s *MediaService) key(fileURL string) string {
 	// URL: https://media.bventy.in/uploads/xyz.jpg
 	// Base: https://media.bventy.in
 	// Key: uploads/xyz.jpg
+	return strings.TrimPrefix(strings.TrimPrefix(fileURL, s.PublicBaseURL), "/")
+}
+
+// UploadPrivateFile uploads a file to the private bucket and returns its key
+func (s *MediaService) UploadPrivateFile(ctx context.Context, file multipart.File, originalFilename string, contentType string, prefixPath string) (string, error) {
+	key := privateKey(prefixPath, originalFilename)
+	_, err := s.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.PrivateBucket),
+		Key:         aws.String(key),
+		Body:        file,
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload to R2: %w", err)
+	}
+	return key, nil
+}
 
-	// Robust way: Trim prefix
-	key := strings.TrimPrefix(fileURL, s.PublicBaseURL)
-	key = strings.TrimPrefix(key, "/")
+// SignedURL presigns a GET of a private file, valid for ttl
+func (s *MediaService) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if err := checkPrivateKey(key); err != nil {
+		return "", err
+	}
+	req, err := s3.NewPresignClient(s.Client).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.PrivateBucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign R2 URL: %w", err)
+	}
+	return req.URL, nil
+}
 
-	_, err := s.Client.DeleteObject(context.TODO(), &s3.DeleteObjectInput{
-		Bucket: aws.String(s.Bucket),
+// DeletePrivateFile deletes a private file given its key
+func (s *MediaService) DeletePrivateFile(ctx context.Context, key string) error {
+	if err := checkPrivateKey(key); err != nil {
+		return err
+	}
+	_, err := s.Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.PrivateBucket),
 		Key:    aws.String(key),
 	})
 	if err != nil {
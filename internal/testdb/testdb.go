@@ -0,0 +1,47 @@
+// Package testdb gives tests a throwaway, fully migrated database on the
+// server named by TEST_DATABASE_URL.
+package testdb
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/bventy/backend/internal/db/migrations"
+	"github.com/bventy/backend/internal/migrate"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// New returns a pool on a new database with every migration applied, dropped
+// when t ends. It skips t when TEST_DATABASE_URL is unset.
+func New(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+
+	admin, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool, drop, err := migrate.ScratchDatabase(ctx, admin, "test")
+	if err != nil {
+		admin.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		drop()
+		admin.Close()
+	})
+
+	migrator, err := migrate.New(pool, migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return pool
+}
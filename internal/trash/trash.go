@@ -0,0 +1,253 @@
+// Package trash soft-deletes users, vendor profiles, groups and events,
+// restores them, and purges them for good once the retention period is over.
+//
+// Deleting a row also deletes the live rows that hang off it (a user's vendor
+// profile, groups and events; a group's events) in the same transaction, so
+// they all share one deleted_at. Restore brings back exactly the rows with
+// that timestamp, leaving alone anything that was deleted on its own before.
+package trash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bventy/backend/internal/jobs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Kind is a soft-deletable entity, named like its admin route.
+type Kind string
+
+const (
+	Users   Kind = "users"
+	Vendors Kind = "vendors"
+	Groups  Kind = "groups"
+	Events  Kind = "events"
+)
+
+var (
+	// ErrNotFound means there is no row of that kind and id in the expected
+	// state: live for Delete, deleted for Restore.
+	ErrNotFound = errors.New("trash: not found")
+	// ErrParentDeleted means the row cannot be restored while the user or
+	// group it belongs to is still deleted.
+	ErrParentDeleted = errors.New("trash: parent is deleted")
+)
+
+// Delete soft-deletes the live row and everything under it. now() is fixed
+// for the transaction, which is what gives the cascade one timestamp.
+func Delete(ctx context.Context, tx pgx.Tx, kind Kind, id string) error {
+	var steps []string
+	switch kind {
+	case Users:
+		steps = []string{
+			`UPDATE users SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL`,
+			`UPDATE vendor_profiles SET deleted_at = now(), updated_at = now() WHERE owner_user_id = $1 AND deleted_at IS NULL`,
+			// Events before groups, while the groups still read as live
+			`UPDATE events SET deleted_at = now() WHERE deleted_at IS NULL AND (organizer_user_id = $1
+				OR organizer_group_id IN (SELECT id FROM groups WHERE owner_user_id = $1 AND deleted_at IS NULL))`,
+			`UPDATE groups SET deleted_at = now() WHERE owner_user_id = $1 AND deleted_at IS NULL`,
+		}
+	case Vendors:
+		// updated_at moves too, so cached vendor lists change their ETag
+		steps = []string{`UPDATE vendor_profiles SET deleted_at = now(), updated_at = now() WHERE id = $1 AND deleted_at IS NULL`}
+	case Groups:
+		steps = []string{
+			`UPDATE groups SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL`,
+			`UPDATE events SET deleted_at = now() WHERE organizer_group_id = $1 AND deleted_at IS NULL`,
+		}
+	case Events:
+		steps = []string{`UPDATE events SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL`}
+	default:
+		return fmt.Errorf("trash: unknown kind %q", kind)
+	}
+
+	for i, sql := range steps {
+		tag, err := tx.Exec(ctx, sql, id)
+		if err != nil {
+			return err
+		}
+		if i == 0 && tag.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+	}
+	return nil
+}
+
+// Restore undeletes the row and whatever was deleted along with it.
+func Restore(ctx context.Context, tx pgx.Tx, kind Kind, id string) error {
+	var lock, parentDeleted string
+	var steps []string
+	switch kind {
+	case Users:
+		lock = `SELECT deleted_at FROM users WHERE id = $1 FOR UPDATE`
+		steps = []string{
+			`UPDATE users SET deleted_at = NULL WHERE id = $1`,
+			`UPDATE vendor_profiles SET deleted_at = NULL, updated_at = now() WHERE owner_user_id = $1 AND deleted_at = $2`,
+			`UPDATE groups SET deleted_at = NULL WHERE owner_user_id = $1 AND deleted_at = $2`,
+			`UPDATE events SET deleted_at = NULL WHERE deleted_at = $2 AND (organizer_user_id = $1
+				OR organizer_group_id IN (SELECT id FROM groups WHERE owner_user_id = $1))`,
+		}
+	case Vendors:
+		lock = `SELECT deleted_at FROM vendor_profiles WHERE id = $1 FOR UPDATE`
+		parentDeleted = `SELECT EXISTS (SELECT 1 FROM vendor_profiles vp JOIN users u ON u.id = vp.owner_user_id
+			WHERE vp.id = $1 AND u.deleted_at IS NOT NULL)`
+		steps = []string{`UPDATE vendor_profiles SET deleted_at = NULL, updated_at = now() WHERE id = $1`}
+	case Groups:
+		lock = `SELECT deleted_at FROM groups WHERE id = $1 FOR UPDATE`
+		parentDeleted = `SELECT EXISTS (SELECT 1 FROM groups g JOIN users u ON u.id = g.owner_user_id
+			WHERE g.id = $1 AND u.deleted_at IS NOT NULL)`
+		steps = []string{
+			`UPDATE groups SET deleted_at = NULL WHERE id = $1`,
+			`UPDATE events SET deleted_at = NULL WHERE organizer_group_id = $1 AND deleted_at = $2`,
+		}
+	case Events:
+		lock = `SELECT deleted_at FROM events WHERE id = $1 FOR UPDATE`
+		parentDeleted = `SELECT EXISTS (SELECT 1 FROM events e
+			LEFT JOIN users u ON u.id = e.organizer_user_id
+			LEFT JOIN groups g ON g.id = e.organizer_group_id
+			WHERE e.id = $1 AND (u.deleted_at IS NOT NULL OR g.deleted_at IS NOT NULL))`
+		steps = []string{`UPDATE events SET deleted_at = NULL WHERE id = $1`}
+	default:
+		return fmt.Errorf("trash: unknown kind %q", kind)
+	}
+
+	var deletedAt *time.Time
+	err := tx.QueryRow(ctx, lock, id).Scan(&deletedAt)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && deletedAt == nil) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if parentDeleted != "" {
+		var deleted bool
+		if err := tx.QueryRow(ctx, parentDeleted, id).Scan(&deleted); err != nil {
+			return err
+		}
+		if deleted {
+			return ErrParentDeleted
+		}
+	}
+
+	for i, sql := range steps {
+		args := []any{id}
+		if i > 0 {
+			args = append(args, *deletedAt)
+		}
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Item is one deleted row, as listed for admins.
+type Item struct {
+	Kind      Kind      `json:"kind"`
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// List returns deleted rows, newest first. An empty kind lists all of them.
+func List(ctx context.Context, pool *pgxpool.Pool, kind Kind, limit int) ([]Item, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT kind, id, name, deleted_at FROM (
+			SELECT 'users' AS kind, id::text, COALESCE(full_name, email) AS name, deleted_at FROM users WHERE deleted_at IS NOT NULL
+			UNION ALL
+			SELECT 'vendors', id::text, business_name, deleted_at FROM vendor_profiles WHERE deleted_at IS NOT NULL
+			UNION ALL
+			SELECT 'groups', id::text, name, deleted_at FROM groups WHERE deleted_at IS NOT NULL
+			UNION ALL
+			SELECT 'events', id::text, title, deleted_at FROM events WHERE deleted_at IS NOT NULL
+		) t
+		WHERE $1 = '' OR kind = $1
+		ORDER BY deleted_at DESC, id
+		LIMIT $2
+	`, string(kind), limit)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[Item])
+}
+
+// purges hard-delete expired rows, children first: rows deleted together
+// share a timestamp, so a parent's cascade has always been purged already and
+// its media queued. Each returns how many rows went, their media URLs and
+// the keys of their private media.
+var purges = []struct {
+	kind Kind
+	sql  string
+}{
+	{Events, `
+		WITH gone AS (DELETE FROM events WHERE deleted_at < now() - $1::interval RETURNING cover_image_url)
+		SELECT count(*), COALESCE(array_agg(cover_image_url) FILTER (WHERE cover_image_url <> ''), '{}'), '{}'::text[] FROM gone`},
+	{Groups, `
+		WITH gone AS (DELETE FROM groups WHERE deleted_at < now() - $1::interval RETURNING id)
+		SELECT count(*), '{}'::text[], '{}'::text[] FROM gone`},
+	// The media tables are read from the statement's snapshot, before the
+	// cascade removes their rows.
+	{Vendors, `
+		WITH gone AS (
+			DELETE FROM vendor_profiles WHERE deleted_at < now() - $1::interval
+			RETURNING id, portfolio_image_url, gallery_images, portfolio_files
+		), media AS (
+			SELECT portfolio_image_url AS url FROM gone
+			UNION SELECT unnest(gallery_images) FROM gone
+			UNION SELECT f.elem->>'url' FROM gone, jsonb_array_elements(COALESCE(gone.portfolio_files, '[]'::jsonb)) AS f(elem)
+			UNION SELECT g.image_url FROM vendor_gallery_images g JOIN gone ON g.vendor_id = gone.id
+			UNION SELECT p.file_url FROM vendor_portfolio_files p JOIN gone ON p.vendor_id = gone.id
+		)
+		SELECT (SELECT count(*) FROM gone), COALESCE((SELECT array_agg(url) FROM media WHERE url <> ''), '{}'),
+			COALESCE((SELECT array_agg(d.object_key) FROM vendor_documents d JOIN gone ON d.vendor_id = gone.id), '{}')`},
+	{Users, `
+		WITH gone AS (DELETE FROM users WHERE deleted_at < now() - $1::interval RETURNING profile_image_url)
+		SELECT count(*), COALESCE(array_agg(profile_image_url) FILTER (WHERE profile_image_url <> ''), '{}'), '{}'::text[] FROM gone`},
+}
+
+// Purge hard-deletes rows deleted more than retention ago and queues their
+// media for deletion in the same transaction. It returns how many rows of
+// each kind it removed.
+func Purge(ctx context.Context, pool *pgxpool.Pool, retention time.Duration) (map[Kind]int64, error) {
+	purged := map[Kind]int64{}
+	for _, p := range purges {
+		n, err := purge(ctx, pool, p.sql, retention)
+		if err != nil {
+			return purged, fmt.Errorf("purge %s: %w", p.kind, err)
+		}
+		if n > 0 {
+			purged[p.kind] = n
+		}
+	}
+	return purged, nil
+}
+
+func purge(ctx context.Context, pool *pgxpool.Pool, sql string, retention time.Duration) (int64, error) {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var n int64
+	var urls, keys []string
+	if err := tx.QueryRow(ctx, sql, retention).Scan(&n, &urls, &keys); err != nil {
+		return 0, err
+	}
+	for _, url := range urls {
+		if err := jobs.EnqueueDeleteMedia(ctx, tx, url); err != nil {
+			return 0, err
+		}
+	}
+	for _, key := range keys {
+		if err := jobs.EnqueueDeletePrivateMedia(ctx, tx, key); err != nil {
+			return 0, err
+		}
+	}
+	return n, tx.Commit(ctx)
+}
@@ -0,0 +1,210 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bventy/backend/internal/jobs"
+	"github.com/bventy/backend/internal/outbox"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// Subscriber is the outbox subscriber that fans events out to
+	// webhook_subscriptions.
+	Subscriber = "webhooks"
+	// KindDeliver is the job that POSTs one webhook_deliveries row.
+	KindDeliver = "webhook.deliver"
+)
+
+// Envelope is the JSON body of every webhook request.
+type Envelope struct {
+	ID        int64           `json:"id"` // event id; the same across retries and replays
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Subscribe adds the fan-out subscriber to d. Fan-out only writes rows and
+// enqueues jobs, so it runs in the outbox's transaction and happens exactly
+// once per event.
+func Subscribe(d *outbox.Dispatcher) {
+	d.SubscribeTx(Subscriber, fanOut)
+}
+
+// DeactivateAllVendors turns off userID's all_vendors subscriptions unless
+// role is an admin role. Call it with the transaction that changes the role;
+// fan-out ignores such subscriptions of non-admins regardless, so this keeps
+// the subscription list honest rather than guarding delivery.
+func DeactivateAllVendors(ctx context.Context, q outbox.Execer, userID, role string) error {
+	if role == "admin" || role == "super_admin" {
+		return nil
+	}
+	_, err := q.Exec(ctx, `
+		UPDATE webhook_subscriptions SET active = false, updated_at = now()
+		WHERE owner_user_id = $1 AND all_vendors AND active
+	`, userID)
+	return err
+}
+
+func fanOut(ctx context.Context, tx pgx.Tx, m outbox.Message) error {
+	// Vendor events go to the vendor owner's subscriptions as well as the
+	// all-vendor ones; everything else only to the latter. All-vendor
+	// subscriptions count only while their owner is still an admin.
+	var vendorOwner *string
+	if strings.HasPrefix(m.Type, "vendor.") {
+		err := tx.QueryRow(ctx, "SELECT owner_user_id FROM vendor_profiles WHERE id = $1", m.AggregateID).Scan(&vendorOwner)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+	}
+
+	body, err := json.Marshal(Envelope{ID: m.ID, Type: m.Type, CreatedAt: m.CreatedAt, Data: m.Payload})
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, `
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, body)
+		SELECT id, $1, $2, $3 FROM webhook_subscriptions
+		WHERE active AND $2 = ANY(event_types)
+		  AND (owner_user_id = $4 OR (all_vendors AND EXISTS (
+		    SELECT 1 FROM users u WHERE u.id = owner_user_id AND u.role IN ('admin', 'super_admin'))))
+		  AND NOT EXISTS (SELECT 1 FROM users u WHERE u.id = owner_user_id AND u.deleted_at IS NOT NULL)
+		RETURNING id
+	`, m.ID, m.Type, body, vendorOwner)
+	if err != nil {
+		return err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := EnqueueDelivery(ctx, tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type deliverJob struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+// EnqueueDelivery schedules the webhook_deliveries row id to be sent.
+func EnqueueDelivery(ctx context.Context, q jobs.Querier, id string) error {
+	_, err := jobs.Enqueue(ctx, q, KindDeliver, deliverJob{DeliveryID: id})
+	return err
+}
+
+// Client is the default delivery client; it refuses non-public addresses.
+var Client = NewClient(false)
+
+// Register adds the delivery job handler to w, sending with client.
+func Register(w *jobs.Worker, pool *pgxpool.Pool, client *http.Client) {
+	w.HandleFunc(KindDeliver, func(ctx context.Context, job jobs.Job) error {
+		var p deliverJob
+		if err := json.Unmarshal(job.Payload, &p); err != nil {
+			return jobs.Permanent(err)
+		}
+		return deliver(ctx, pool, client, p.DeliveryID, job.Attempt >= job.MaxAttempts)
+	})
+}
+
+func deliver(ctx context.Context, pool *pgxpool.Pool, client *http.Client, id string, lastAttempt bool) error {
+	var (
+		body             []byte
+		eventType        string
+		status           string
+		url, secret      string
+		subscriptionLive bool
+	)
+	err := pool.QueryRow(ctx, `
+		SELECT d.body, d.event_type, d.status, s.url, s.secret, s.active
+		FROM webhook_deliveries d JOIN webhook_subscriptions s ON s.id = d.subscription_id
+		WHERE d.id = $1
+	`, id).Scan(&body, &eventType, &status, &url, &secret, &subscriptionLive)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return jobs.Permanent(fmt.Errorf("delivery %s or its subscription was deleted", id))
+	}
+	if err != nil {
+		return err
+	}
+	if status == "succeeded" {
+		return nil
+	}
+	if !subscriptionLive {
+		_, err := pool.Exec(ctx, "UPDATE webhook_deliveries SET status = 'failed', last_error = 'subscription disabled' WHERE id = $1", id)
+		if err != nil {
+			return err
+		}
+		return jobs.Permanent(errors.New("subscription disabled"))
+	}
+
+	code, sendErr := Send(ctx, client, url, secret, id, eventType, body)
+
+	var responseStatus *int
+	if code != 0 {
+		responseStatus = &code
+	}
+	var lastError *string
+	newStatus := "succeeded"
+	if sendErr != nil {
+		msg := sendErr.Error()
+		lastError = &msg
+		newStatus = "pending"
+		if lastAttempt || jobs.IsPermanent(sendErr) {
+			newStatus = "failed"
+		}
+	}
+	_, err = pool.Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = attempts + 1, response_status = $3, last_error = $4,
+			delivered_at = CASE WHEN $2 = 'succeeded' THEN now() END
+		WHERE id = $1
+	`, id, newStatus, responseStatus, lastError)
+	if err != nil {
+		return err
+	}
+	return sendErr
+}
+
+// Send signs and POSTs body to url. It returns the response status (0 when no
+// response arrived) and an error unless the receiver answered 2xx.
+func Send(ctx context.Context, client *http.Client, url, secret, deliveryID, eventType string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, jobs.Permanent(err)
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "bventy-webhooks/1")
+	req.Header.Set(HeaderEvent, eventType)
+	req.Header.Set(HeaderDelivery, deliveryID)
+	req.Header.Set(HeaderTimestamp, fmt.Sprint(now.Unix()))
+	req.Header.Set(HeaderSignature, Sign(secret, now, body))
+
+	resp, err := client.Do(req)
+	if errors.Is(err, ErrForbiddenAddress) {
+		return 0, jobs.Permanent(err)
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
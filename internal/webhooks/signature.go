@@ -0,0 +1,63 @@
+// Package webhooks delivers domain events to subscriber URLs as signed HTTP
+// POSTs.
+//
+// Every request carries:
+//
+//	X-Bventy-Event:     the event type, e.g. vendor.verified
+//	X-Bventy-Delivery:  the delivery id (new for each replay)
+//	X-Bventy-Timestamp: unix seconds when the request was signed
+//	X-Bventy-Signature: sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">
+//
+// Receivers should recompute the signature with their secret, compare it in
+// constant time, reject stale timestamps, and dedupe on the body's "id", which
+// is the event id and stays the same across retries and replays.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderEvent     = "X-Bventy-Event"
+	HeaderDelivery  = "X-Bventy-Delivery"
+	HeaderTimestamp = "X-Bventy-Timestamp"
+	HeaderSignature = "X-Bventy-Signature"
+)
+
+// Sign returns the X-Bventy-Signature value for body sent at ts.
+func Sign(secret string, ts time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+var (
+	ErrBadSignature = errors.New("webhook signature does not match")
+	ErrStale        = errors.New("webhook timestamp outside tolerance")
+)
+
+// Verify checks a received request's signature and timestamp headers against
+// body. tolerance bounds clock skew and replay of captured requests.
+func Verify(secret, signature, timestamp string, body []byte, tolerance time.Duration, now time.Time) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrBadSignature
+	}
+	ts := time.Unix(unix, 0)
+	if d := now.Sub(ts); d > tolerance || d < -tolerance {
+		return ErrStale
+	}
+	want := Sign(secret, ts, body)
+	if !strings.HasPrefix(signature, "sha256=") || !hmac.Equal([]byte(signature), []byte(want)) {
+		return ErrBadSignature
+	}
+	return nil
+}
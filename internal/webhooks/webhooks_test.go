@@ -0,0 +1,79 @@
+package webhooks_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/bventy/backend/internal/webhooks"
+	"github.com/bventy/backend/internal/webhooks/webhooktest"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	secret, body := "s3cret-s3cret-s3cret", []byte(`{"id":1}`)
+	now := time.Unix(1_800_000_000, 0)
+	sig := webhooks.Sign(secret, now, body)
+	ts := "1800000000"
+
+	if err := webhooks.Verify(secret, sig, ts, body, time.Minute, now.Add(30*time.Second)); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	if err := webhooks.Verify(secret, sig, ts, []byte(`{"id":2}`), time.Minute, now); err != webhooks.ErrBadSignature {
+		t.Fatalf("tampered body: got %v", err)
+	}
+	if err := webhooks.Verify("other-secret-value", sig, ts, body, time.Minute, now); err != webhooks.ErrBadSignature {
+		t.Fatalf("wrong secret: got %v", err)
+	}
+	if err := webhooks.Verify(secret, sig, ts, body, time.Minute, now.Add(2*time.Minute)); err != webhooks.ErrStale {
+		t.Fatalf("stale timestamp: got %v", err)
+	}
+}
+
+func TestSendSignsRequest(t *testing.T) {
+	receiver := webhooktest.NewReceiver(t, "receiver-secret-0123456789")
+	body := []byte(`{"id":7,"type":"vendor.verified","created_at":"2026-10-18T00:00:00Z","data":{}}`)
+	client := webhooks.NewClient(true) // the receiver listens on loopback
+
+	code, err := webhooks.Send(context.Background(), client, receiver.URL, receiver.Secret, "d-1", "vendor.verified", body)
+	if err != nil || code != http.StatusOK {
+		t.Fatalf("send: %d %v", code, err)
+	}
+	got := receiver.Requests()
+	if len(got) != 1 || got[0].SignatureErr != nil || got[0].DeliveryID != "d-1" || got[0].Envelope.ID != 7 {
+		t.Fatalf("unexpected request: %+v", got)
+	}
+
+	receiver.SetStatus(http.StatusBadGateway)
+	if code, err := webhooks.Send(context.Background(), client, receiver.URL, receiver.Secret, "d-2", "vendor.verified", body); err == nil || code != http.StatusBadGateway {
+		t.Fatalf("non-2xx should fail with its status: %d %v", code, err)
+	}
+}
+
+func TestClientRefusesInternalAddresses(t *testing.T) {
+	receiver := webhooktest.NewReceiver(t, "receiver-secret-0123456789")
+
+	code, err := webhooks.Send(context.Background(), webhooks.Client, receiver.URL, receiver.Secret, "d-1", "vendor.verified", []byte(`{}`))
+	if !errors.Is(err, webhooks.ErrForbiddenAddress) || code != 0 {
+		t.Fatalf("loopback receiver: %d %v", code, err)
+	}
+	if got := receiver.Requests(); len(got) != 0 {
+		t.Fatalf("receiver was reached: %+v", got)
+	}
+
+	for addr, public := range map[string]bool{
+		"127.0.0.1": false, "::1": false, "10.1.2.3": false, "172.16.0.1": false,
+		"192.168.1.1": false, "169.254.169.254": false, "fe80::1": false, "fd00::1": false,
+		"0.0.0.0": false, "::": false, "::ffff:127.0.0.1": false, "224.0.0.1": false,
+		"255.255.255.255": false, "100.100.100.200": false, "100.64.0.1": false, "198.18.0.1": false,
+		"64:ff9b::a9fe:a9fe": false, "64:ff9b::10.0.0.1": false, "64:ff9b:1::1": false, "ff02::1": false,
+		"::ffff:100.100.100.200": false, "100.128.0.1": true, "198.20.0.1": true,
+		"93.184.216.34": true, "2606:4700::1111": true,
+	} {
+		if got := webhooks.IsPublic(netip.MustParseAddr(addr)); got != public {
+			t.Errorf("IsPublic(%s) = %v", addr, got)
+		}
+	}
+}
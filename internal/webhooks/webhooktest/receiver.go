@@ -0,0 +1,78 @@
+// Package webhooktest provides a local HTTP receiver for exercising webhook
+// deliveries end to end.
+package webhooktest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bventy/backend/internal/webhooks"
+)
+
+// Request is one webhook the receiver got.
+type Request struct {
+	Event      string
+	DeliveryID string
+	Envelope   webhooks.Envelope
+	// SignatureErr is nil when the signature and timestamp verified.
+	SignatureErr error
+}
+
+// Receiver is an httptest server that verifies signatures with Secret and
+// records every request. It answers with Status, 200 by default.
+type Receiver struct {
+	URL    string
+	Secret string
+
+	mu       sync.Mutex
+	status   int
+	requests []Request
+}
+
+// NewReceiver starts a receiver that is closed when t ends.
+func NewReceiver(t *testing.T, secret string) *Receiver {
+	t.Helper()
+	r := &Receiver{Secret: secret, status: http.StatusOK}
+	srv := httptest.NewServer(http.HandlerFunc(r.serve))
+	t.Cleanup(srv.Close)
+	r.URL = srv.URL
+	return r
+}
+
+// SetStatus changes the status code later requests get, e.g. 500 to force
+// retries.
+func (r *Receiver) SetStatus(code int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = code
+}
+
+// Requests returns what has been received so far.
+func (r *Receiver) Requests() []Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Request(nil), r.requests...)
+}
+
+func (r *Receiver) serve(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	got := Request{
+		Event:      req.Header.Get(webhooks.HeaderEvent),
+		DeliveryID: req.Header.Get(webhooks.HeaderDelivery),
+		SignatureErr: webhooks.Verify(r.Secret,
+			req.Header.Get(webhooks.HeaderSignature), req.Header.Get(webhooks.HeaderTimestamp),
+			body, 5*time.Minute, time.Now()),
+	}
+	json.Unmarshal(body, &got.Envelope)
+
+	r.mu.Lock()
+	r.requests = append(r.requests, got)
+	status := r.status
+	r.mu.Unlock()
+	w.WriteHeader(status)
+}